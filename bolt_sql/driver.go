@@ -0,0 +1,198 @@
+package bolt_sql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+
+	"github.com/mindstand/go-bolt/connection"
+	"github.com/mindstand/go-bolt/errors"
+)
+
+// DriverName is the name the driver is registered with database/sql under
+const DriverName = "neo4j-bolt"
+
+func init() {
+	sql.Register(DriverName, &Driver{})
+}
+
+// Driver opens bolt connections for database/sql. The data source name is a bolt connection string,
+// routing schemes connect straight to the server named in it
+type Driver struct{}
+
+// Open opens a connection to the server in connStr
+func (d *Driver) Open(connStr string) (driver.Conn, error) {
+	bolt, err := connection.CreateBoltConn(connStr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &conn{bolt: bolt}, nil
+}
+
+// OpenConnector lets database/sql open connections without parsing connStr every time
+func (d *Driver) OpenConnector(connStr string) (driver.Connector, error) {
+	return NewConnector(connStr, connection.Config{}), nil
+}
+
+// connector opens connections with a fixed config
+type connector struct {
+	open func() (connection.IConnection, error)
+}
+
+// NewConnector returns a connector for sql.OpenDB that opens connections with config,
+// for settings the connection string can't hold like auth providers, tls configs and dialers
+func NewConnector(connStr string, config connection.Config) driver.Connector {
+	return &connector{
+		open: func() (connection.IConnection, error) {
+			return connection.CreateBoltConnWithConfig(connStr, config)
+		},
+	}
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	bolt, err := c.open()
+	if err != nil {
+		return nil, err
+	}
+
+	return &conn{bolt: bolt}, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return &Driver{}
+}
+
+// conn is a database/sql connection on top of a bolt connection. Bolt can't cancel a query once it
+// is sent so contexts are only checked before a query starts
+type conn struct {
+	bolt connection.IConnection
+	// open transaction, statements run inside it until it is finished
+	tx connection.ITransaction
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+// PrepareContext rewrites ? placeholders, neo4j has no separate prepare step
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	rewritten, numInput := rewritePlaceholders(query)
+	return &stmt{conn: c, query: rewritten, numInput: numInput}, nil
+}
+
+func (c *conn) Close() error {
+	return c.bolt.Close()
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+// BeginTx opens a transaction. Neo4j only has its default isolation level and bolt doesn't
+// tell the server a transaction is read only, so neither option is supported
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) {
+		return nil, errors.New("neo4j only supports the default isolation level")
+	}
+
+	if opts.ReadOnly {
+		return nil, errors.New("read only transactions are not supported")
+	}
+
+	if c.tx != nil {
+		return nil, errors.New("transaction already open")
+	}
+
+	tx, err := c.bolt.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	c.tx = tx
+	return &transaction{conn: c}, nil
+}
+
+// Ping round trips a RESET, broken connections are reported as driver.ErrBadConn so they are dropped
+func (c *conn) Ping(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if !c.bolt.ValidateOpen() || c.bolt.Ping() != nil {
+		return driver.ErrBadConn
+	}
+
+	return nil
+}
+
+// ResetSession rolls back anything left open before the connection is reused
+func (c *conn) ResetSession(ctx context.Context) error {
+	if !c.bolt.ValidateOpen() {
+		return driver.ErrBadConn
+	}
+
+	c.tx = nil
+	return c.bolt.MakeIdle()
+}
+
+func (c *conn) IsValid() bool {
+	return c.bolt.ValidateOpen()
+}
+
+// CheckNamedValue passes maps, lists and bolt types through to the encoder untouched,
+// everything else goes through database/sql's default conversion
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if nv.Value == nil {
+		return nil
+	}
+
+	if _, ok := nv.Value.(driver.Valuer); ok {
+		return driver.ErrSkip
+	}
+
+	switch reflect.TypeOf(nv.Value).Kind() {
+	case reflect.Map, reflect.Slice, reflect.Struct:
+		return nil
+	default:
+		return driver.ErrSkip
+	}
+}
+
+// transaction finishes the connection's open transaction
+type transaction struct {
+	conn *conn
+}
+
+func (t *transaction) Commit() error {
+	if t.conn.tx == nil {
+		return errors.New("transaction already finished")
+	}
+
+	tx := t.conn.tx
+	t.conn.tx = nil
+	return tx.Commit()
+}
+
+func (t *transaction) Rollback() error {
+	if t.conn.tx == nil {
+		return errors.New("transaction already finished")
+	}
+
+	tx := t.conn.tx
+	t.conn.tx = nil
+	return tx.Rollback()
+}
@@ -0,0 +1,310 @@
+package bolt_sql
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/mindstand/go-bolt/connection"
+	"github.com/mindstand/go-bolt/structures/graph"
+	"github.com/mindstand/go-bolt/structures/types"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeQuery records the last query and params it was given
+type fakeQuery struct {
+	query  string
+	params connection.QueryParams
+}
+
+type fakeResult struct {
+	connection.IResult
+	metadata map[string]interface{}
+}
+
+func (f *fakeResult) Metadata() map[string]interface{} {
+	return f.metadata
+}
+
+func (f *fakeResult) GetStats() (map[string]interface{}, bool) {
+	stats, ok := f.metadata["stats"].(map[string]interface{})
+	return stats, ok
+}
+
+type fakeStream struct {
+	connection.IResultStream
+	fields    []string
+	records   [][]interface{}
+	discarded bool
+}
+
+func (f *fakeStream) Fields() []string {
+	return f.fields
+}
+
+func (f *fakeStream) Next() ([]interface{}, bool, error) {
+	if len(f.records) == 0 {
+		return nil, false, nil
+	}
+	record := f.records[0]
+	f.records = f.records[1:]
+	return record, true, nil
+}
+
+func (f *fakeStream) IsOpen() bool {
+	return len(f.records) != 0
+}
+
+func (f *fakeStream) Discard() (connection.IResult, error) {
+	f.discarded = true
+	f.records = nil
+	return &fakeResult{}, nil
+}
+
+type fakeTx struct {
+	connection.ITransaction
+	last       *fakeQuery
+	committed  bool
+	rolledBack bool
+	records    [][]interface{}
+}
+
+func (f *fakeTx) Exec(query string, params connection.QueryParams) (connection.IResult, error) {
+	*f.last = fakeQuery{query: query, params: params}
+	return &fakeResult{}, nil
+}
+
+func (f *fakeTx) QueryStream(query string, params connection.QueryParams) (connection.IResultStream, error) {
+	*f.last = fakeQuery{query: query, params: params}
+	return &fakeStream{fields: []string{"n"}, records: f.records}, nil
+}
+
+func (f *fakeTx) Commit() error {
+	f.committed = true
+	return nil
+}
+
+func (f *fakeTx) Rollback() error {
+	f.rolledBack = true
+	return nil
+}
+
+type fakeConn struct {
+	connection.IConnection
+	last    fakeQuery
+	records [][]interface{}
+	stats   map[string]interface{}
+	txs     []*fakeTx
+	closed  bool
+}
+
+func (f *fakeConn) Exec(query string, params connection.QueryParams) (connection.IResult, error) {
+	f.last = fakeQuery{query: query, params: params}
+	return &fakeResult{metadata: map[string]interface{}{"stats": f.stats}}, nil
+}
+
+func (f *fakeConn) Query(query string, params connection.QueryParams) ([][]interface{}, connection.IResult, error) {
+	f.last = fakeQuery{query: query, params: params}
+	return f.records, &fakeResult{metadata: map[string]interface{}{"fields": []interface{}{"n", "name"}}}, nil
+}
+
+func (f *fakeConn) Begin() (connection.ITransaction, error) {
+	tx := &fakeTx{last: &f.last, records: f.records}
+	f.txs = append(f.txs, tx)
+	return tx, nil
+}
+
+func (f *fakeConn) ValidateOpen() bool {
+	return !f.closed
+}
+
+func (f *fakeConn) Ping() error {
+	return nil
+}
+
+func (f *fakeConn) MakeIdle() error {
+	return nil
+}
+
+func (f *fakeConn) Close() error {
+	f.closed = true
+	return nil
+}
+
+// openFake opens a database whose only connection is bolt
+func openFake(bolt *fakeConn) *sql.DB {
+	db := sql.OpenDB(&connector{open: func() (connection.IConnection, error) {
+		return bolt, nil
+	}})
+	db.SetMaxOpenConns(1)
+	return db
+}
+
+func TestRegistered(t *testing.T) {
+	req := require.New(t)
+	req.Contains(sql.Drivers(), DriverName)
+}
+
+func TestRewritePlaceholders(t *testing.T) {
+	req := require.New(t)
+
+	cases := []struct {
+		query    string
+		expected string
+		numInput int
+	}{
+		{"MATCH (n) RETURN n", "MATCH (n) RETURN n", -1},
+		{"MATCH (n {id: $id}) RETURN n", "MATCH (n {id: $id}) RETURN n", -1},
+		{"CREATE (n {a: ?, b: ?})", "CREATE (n {a: $p1, b: $p2})", 2},
+		{"RETURN '?', \"it\\\"s ?\", ?", "RETURN '?', \"it\\\"s ?\", $p1", 1},
+		{"MATCH (n:`odd?label`) RETURN n.x = ?", "MATCH (n:`odd?label`) RETURN n.x = $p1", 1},
+		{"RETURN ? // why?\nAND /* or? */ ?", "RETURN $p1 // why?\nAND /* or? */ $p2", 2},
+	}
+
+	for _, c := range cases {
+		query, numInput := rewritePlaceholders(c.query)
+		req.Equal(c.expected, query, c.query)
+		req.Equal(c.numInput, numInput, c.query)
+	}
+}
+
+func TestExec(t *testing.T) {
+	req := require.New(t)
+	bolt := &fakeConn{stats: map[string]interface{}{"nodes-created": int64(2), "properties-set": int64(4)}}
+	db := openFake(bolt)
+	defer db.Close()
+
+	res, err := db.Exec("CREATE (:A {x: ?}), (:A {x: ?})", 1, "two")
+	req.Nil(err)
+	req.Equal("CREATE (:A {x: $p1}), (:A {x: $p2})", bolt.last.query)
+	req.Equal(connection.QueryParams{"p1": int64(1), "p2": "two"}, bolt.last.params)
+
+	affected, err := res.RowsAffected()
+	req.Nil(err)
+	req.EqualValues(6, affected)
+
+	_, err = res.LastInsertId()
+	req.NotNil(err)
+
+	// named arguments keep their names, maps are passed through untouched
+	_, err = db.Exec("CREATE (:A $props)", sql.Named("props", map[string]interface{}{"x": 1}))
+	req.Nil(err)
+	req.Equal(connection.QueryParams{"props": map[string]interface{}{"x": 1}}, bolt.last.params)
+}
+
+func TestQuery(t *testing.T) {
+	req := require.New(t)
+	node := graph.Node{NodeIdentity: 1, Labels: []string{"A"}, Properties: map[string]interface{}{"x": int64(1)}}
+	bolt := &fakeConn{records: [][]interface{}{{node, "one"}, {nil, "two"}}}
+	db := openFake(bolt)
+	defer db.Close()
+
+	rows, err := db.Query("MATCH (n) RETURN n, n.name")
+	req.Nil(err)
+
+	columns, err := rows.Columns()
+	req.Nil(err)
+	req.Equal([]string{"n", "name"}, columns)
+
+	var nodes []Node
+	var names []string
+	for rows.Next() {
+		var n Node
+		var name string
+		req.Nil(rows.Scan(&n, &name))
+		nodes = append(nodes, n)
+		names = append(names, name)
+	}
+	req.Nil(rows.Err())
+	req.Nil(rows.Close())
+
+	req.Equal([]string{"one", "two"}, names)
+	req.True(nodes[0].Valid)
+	req.Equal(node, nodes[0].Node)
+	req.False(nodes[1].Valid)
+}
+
+func TestQueryDateTime(t *testing.T) {
+	req := require.New(t)
+	london, err := time.LoadLocation("Europe/London")
+	req.Nil(err)
+	val := time.Date(2021, 3, 28, 3, 0, 0, 0, london)
+
+	bolt := &fakeConn{records: [][]interface{}{{types.NewDateTime(val), "one"}}}
+	db := openFake(bolt)
+	defer db.Close()
+
+	// datetimes come out as time.Time, in their zone
+	var scanned time.Time
+	var name string
+	req.Nil(db.QueryRow("RETURN datetime(), 'one'").Scan(&scanned, &name))
+	req.True(val.Equal(scanned))
+	req.Equal("Europe/London", scanned.Location().String())
+}
+
+func TestTransaction(t *testing.T) {
+	req := require.New(t)
+	bolt := &fakeConn{records: [][]interface{}{{int64(1)}, {int64(2)}, {int64(3)}}}
+	db := openFake(bolt)
+	defer db.Close()
+
+	tx, err := db.Begin()
+	req.Nil(err)
+
+	_, err = tx.Exec("CREATE (:A {x: ?})", 1)
+	req.Nil(err)
+	req.Equal(connection.QueryParams{"p1": int64(1)}, bolt.last.params)
+
+	// the stream is discarded when rows are closed early
+	rows, err := tx.Query("UNWIND [1, 2, 3] AS n RETURN n")
+	req.Nil(err)
+	req.True(rows.Next())
+	var n int64
+	req.Nil(rows.Scan(&n))
+	req.EqualValues(1, n)
+	req.Nil(rows.Close())
+
+	req.Nil(tx.Commit())
+	req.Len(bolt.txs, 1)
+	req.True(bolt.txs[0].committed)
+
+	tx, err = db.Begin()
+	req.Nil(err)
+	req.Nil(tx.Rollback())
+	req.Len(bolt.txs, 2)
+	req.True(bolt.txs[1].rolledBack)
+
+	_, err = db.BeginTx(context.Background(), &sql.TxOptions{ReadOnly: true})
+	req.NotNil(err)
+
+	_, err = db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelSerializable})
+	req.NotNil(err)
+}
+
+func TestScanners(t *testing.T) {
+	req := require.New(t)
+
+	var rel Relationship
+	req.Nil(rel.Scan(graph.Relationship{RelIdentity: 3, Type: "KNOWS"}))
+	req.True(rel.Valid)
+	req.Equal("KNOWS", rel.Type)
+	req.NotNil(rel.Scan("not a relationship"))
+
+	var path Path
+	req.Nil(path.Scan(graph.Path{Sequence: []int{1, 1}}))
+	req.True(path.Valid)
+	req.Nil(path.Scan(nil))
+	req.False(path.Valid)
+
+	var m Map
+	req.Nil(m.Scan(map[string]interface{}{"a": int64(1)}))
+	req.Equal(Map{"a": int64(1)}, m)
+	req.Nil(m.Scan(nil))
+	req.Nil(m)
+
+	var l List
+	req.Nil(l.Scan([]interface{}{"a", int64(1)}))
+	req.Equal(List{"a", int64(1)}, l)
+	req.NotNil(l.Scan(int64(1)))
+}
@@ -0,0 +1,268 @@
+package bolt_sql
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/mindstand/go-bolt/connection"
+	"github.com/mindstand/go-bolt/errors"
+	"github.com/mindstand/go-bolt/structures/types"
+)
+
+// placeholderPrefix names the parameters ? placeholders are rewritten to, the first ? becomes $p1
+const placeholderPrefix = "p"
+
+// updateCounters are the stats added up for RowsAffected
+var updateCounters = []string{
+	"nodes-created",
+	"nodes-deleted",
+	"relationships-created",
+	"relationships-deleted",
+	"properties-set",
+	"labels-added",
+	"labels-removed",
+}
+
+// stmt runs a query with ? placeholders rewritten to bolt parameters
+type stmt struct {
+	conn  *conn
+	query string
+	// number of ? placeholders, -1 when there are none and arguments are passed by name
+	numInput int
+}
+
+func (s *stmt) Close() error {
+	return nil
+}
+
+func (s *stmt) NumInput() int {
+	return s.numInput
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	params := queryParams(args)
+
+	var res connection.IResult
+	var err error
+	if s.conn.tx != nil {
+		res, err = s.conn.tx.Exec(s.query, params)
+	} else {
+		res, err = s.conn.bolt.Exec(s.query, params)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &result{res: res}, nil
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	params := queryParams(args)
+
+	// inside a transaction records are pulled as they are read
+	if s.conn.tx != nil {
+		stream, err := s.conn.tx.QueryStream(s.query, params)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rows{columns: stream.Fields(), stream: stream}, nil
+	}
+
+	records, res, err := s.conn.bolt.Query(s.query, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var columns []string
+	if res != nil {
+		fields, _ := res.Metadata()["fields"].([]interface{})
+		for _, field := range fields {
+			name, _ := field.(string)
+			columns = append(columns, name)
+		}
+	}
+
+	return &rows{columns: columns, records: records}, nil
+}
+
+// namedValues numbers arguments passed without names
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+	return named
+}
+
+// queryParams maps sql.Named arguments to parameters of the same name and positional ones to
+// the names ? placeholders were rewritten to
+func queryParams(args []driver.NamedValue) connection.QueryParams {
+	params := make(connection.QueryParams, len(args))
+	for _, arg := range args {
+		if arg.Name != "" {
+			params[arg.Name] = arg.Value
+		} else {
+			params[placeholderPrefix+strconv.Itoa(arg.Ordinal)] = arg.Value
+		}
+	}
+	return params
+}
+
+// rewritePlaceholders replaces every ? outside of strings, quoted names and comments with $p1, $p2 and so on.
+// It returns the rewritten query and the number of placeholders, -1 if there were none
+func rewritePlaceholders(query string) (string, int) {
+	var builder strings.Builder
+	count := 0
+
+	// quote is the character closing the string or name being read, 0 outside of one
+	var quote byte
+	for i := 0; i < len(query); i++ {
+		ch := query[i]
+
+		switch {
+		case quote != 0:
+			builder.WriteByte(ch)
+			if ch == '\\' && quote != '`' && i+1 < len(query) {
+				i++
+				builder.WriteByte(query[i])
+			} else if ch == quote {
+				quote = 0
+			}
+			continue
+		case ch == '\'' || ch == '"' || ch == '`':
+			quote = ch
+		case ch == '/' && i+1 < len(query) && query[i+1] == '/':
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+			builder.WriteString(query[i : i+end])
+			i += end - 1
+			continue
+		case ch == '/' && i+1 < len(query) && query[i+1] == '*':
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				end = len(query) - i
+			} else {
+				end += 4
+			}
+			builder.WriteString(query[i : i+end])
+			i += end - 1
+			continue
+		case ch == '?':
+			count++
+			builder.WriteString("$" + placeholderPrefix + strconv.Itoa(count))
+			continue
+		}
+
+		builder.WriteByte(ch)
+	}
+
+	if count == 0 {
+		return query, -1
+	}
+	return builder.String(), count
+}
+
+// result reports the update counters neo4j returned, there are no insert ids in a graph
+type result struct {
+	res connection.IResult
+}
+
+func (r *result) LastInsertId() (int64, error) {
+	return 0, errors.New("neo4j has no last insert id, return the id from the query instead")
+}
+
+// RowsAffected adds up the nodes and relationships created or deleted, properties set and labels changed
+func (r *result) RowsAffected() (int64, error) {
+	if r.res == nil {
+		return 0, nil
+	}
+
+	stats, ok := r.res.GetStats()
+	if !ok {
+		return 0, nil
+	}
+
+	var affected int64
+	for _, counter := range updateCounters {
+		if num, ok := stats[counter].(int64); ok {
+			affected += num
+		}
+	}
+	return affected, nil
+}
+
+// rows reads records either pulled up front or from an open stream
+type rows struct {
+	columns []string
+	records [][]interface{}
+	stream  connection.IResultStream
+}
+
+func (r *rows) Columns() []string {
+	return r.columns
+}
+
+// Close throws away anything left in the stream so the transaction can carry on
+func (r *rows) Close() error {
+	r.records = nil
+	if r.stream != nil && r.stream.IsOpen() {
+		_, err := r.stream.Discard()
+		return err
+	}
+	return nil
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	var record []interface{}
+	if r.stream != nil {
+		next, ok, err := r.stream.Next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return io.EOF
+		}
+		record = next
+	} else {
+		if len(r.records) == 0 {
+			return io.EOF
+		}
+		record = r.records[0]
+		r.records = r.records[1:]
+	}
+
+	if len(record) != len(dest) {
+		return errors.New("record has [%v] values, expected [%v]", len(record), len(dest))
+	}
+
+	for i, val := range record {
+		// datetimes scan into time.Time like any other sql driver's
+		if dateTime, ok := val.(types.DateTime); ok {
+			val = dateTime.Time
+		}
+		dest[i] = val
+	}
+	return nil
+}
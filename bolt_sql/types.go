@@ -0,0 +1,101 @@
+package bolt_sql
+
+import (
+	"github.com/mindstand/go-bolt/errors"
+	"github.com/mindstand/go-bolt/structures/graph"
+)
+
+// Node scans a node column. Valid is false when the column was null
+type Node struct {
+	graph.Node
+	Valid bool
+}
+
+// Scan implements sql.Scanner
+func (n *Node) Scan(src interface{}) error {
+	switch val := src.(type) {
+	case nil:
+		n.Node, n.Valid = graph.Node{}, false
+	case graph.Node:
+		n.Node, n.Valid = val, true
+	case *graph.Node:
+		n.Node, n.Valid = *val, true
+	default:
+		return errors.New("cannot scan [%T] into a node", src)
+	}
+	return nil
+}
+
+// Relationship scans a relationship column. Valid is false when the column was null
+type Relationship struct {
+	graph.Relationship
+	Valid bool
+}
+
+// Scan implements sql.Scanner
+func (r *Relationship) Scan(src interface{}) error {
+	switch val := src.(type) {
+	case nil:
+		r.Relationship, r.Valid = graph.Relationship{}, false
+	case graph.Relationship:
+		r.Relationship, r.Valid = val, true
+	case *graph.Relationship:
+		r.Relationship, r.Valid = *val, true
+	default:
+		return errors.New("cannot scan [%T] into a relationship", src)
+	}
+	return nil
+}
+
+// Path scans a path column. Valid is false when the column was null
+type Path struct {
+	graph.Path
+	Valid bool
+}
+
+// Scan implements sql.Scanner
+func (p *Path) Scan(src interface{}) error {
+	switch val := src.(type) {
+	case nil:
+		p.Path, p.Valid = graph.Path{}, false
+	case graph.Path:
+		p.Path, p.Valid = val, true
+	case *graph.Path:
+		p.Path, p.Valid = *val, true
+	default:
+		return errors.New("cannot scan [%T] into a path", src)
+	}
+	return nil
+}
+
+// Map scans a map column, a null column leaves it nil
+type Map map[string]interface{}
+
+// Scan implements sql.Scanner
+func (m *Map) Scan(src interface{}) error {
+	switch val := src.(type) {
+	case nil:
+		*m = nil
+	case map[string]interface{}:
+		*m = val
+	default:
+		return errors.New("cannot scan [%T] into a map", src)
+	}
+	return nil
+}
+
+// List scans a list column, a null column leaves it nil
+type List []interface{}
+
+// Scan implements sql.Scanner
+func (l *List) Scan(src interface{}) error {
+	switch val := src.(type) {
+	case nil:
+		*l = nil
+	case []interface{}:
+		*l = val
+	default:
+		return errors.New("cannot scan [%T] into a list", src)
+	}
+	return nil
+}
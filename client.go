@@ -1,8 +1,12 @@
 package goBolt
 
 import (
+	"crypto/tls"
 	"fmt"
+	"github.com/mindstand/go-bolt/connection"
+	"github.com/mindstand/go-bolt/encoding"
 	"github.com/mindstand/go-bolt/errors"
+	"github.com/mindstand/go-bolt/routing"
 	"math"
 	"net/url"
 	"strconv"
@@ -14,7 +18,7 @@ type IClient interface {
 	// opens a new internalDriver to neo4j
 	NewDriver() (IDriver, error)
 
-	// opens a internalDriver pool to neo4j
+	// opens a internalDriver pool to neo4j. With routing size is the max connections to each cluster member
 	NewDriverPool(size int) (IDriverPool, error)
 }
 
@@ -40,6 +44,20 @@ type Client struct {
 	tlsNoVerify         bool
 	protocolGreaterThan int
 	protocolLessThan    int
+	acquireStrategy     routing.AcquireStrategy
+	acquireTimeout      time.Duration
+	maxOverflow         int
+	maxConnLifetime     time.Duration
+	idleTestThreshold   time.Duration
+	keepAlive           time.Duration
+	poolConfig          PoolConfig
+	database            string
+	authProvider        connection.AuthProvider
+	tlsConfig           *tls.Config
+	dialer              connection.DialFunc
+	capture             *connection.Capture
+	minorVersions       bool
+	decoderLimits       *encoding.Limits
 }
 
 func NewClient(opts ...Opt) (IClient, error) {
@@ -47,7 +65,11 @@ func NewClient(opts ...Opt) (IClient, error) {
 		return nil, errors.Wrap(errors.ErrConfiguration, "no options for client")
 	}
 
-	client := new(Client)
+	client := &Client{
+		maxConnLifetime:   connection.DefaultMaxConnLifetime,
+		idleTestThreshold: connection.DefaultIdleTestThreshold,
+		poolConfig:        DefaultPoolConfig(),
+	}
 
 	for _, opt := range opts {
 		if opt == nil {
@@ -65,6 +87,11 @@ func NewClient(opts ...Opt) (IClient, error) {
 		client.timeout = time.Second * time.Duration(60)
 	}
 
+	// acquire timeout not set
+	if client.acquireTimeout == 0 {
+		client.acquireTimeout = routing.DefaultAcquireTimeout
+	}
+
 	// check chunk size
 	if client.chunkSize == 0 {
 		// set default chunk size
@@ -91,11 +118,17 @@ func NewClient(opts ...Opt) (IClient, error) {
 			return nil, errors.Wrap(errors.ErrConfiguration, "invalid port [%v]", client.port)
 		}
 
-		if client.user == "" {
-			return nil, errors.Wrap(errors.ErrConfiguration, "user can not be empty")
+		// an auth provider replaces basic auth, so it needs no user and the connection string carries none
+		var userInfo string
+		if client.authProvider == nil {
+			if client.user == "" {
+				return nil, errors.Wrap(errors.ErrConfiguration, "user can not be empty")
+			}
+
+			userInfo = fmt.Sprintf("%s:%s@", client.user, client.password)
 		}
 
-		client.connStr = fmt.Sprintf("%s://%s:%s@%s:%v", protocol, client.user, client.password, client.host, client.port)
+		client.connStr = fmt.Sprintf("%s://%s%s:%v", protocol, userInfo, client.host, client.port)
 
 		// append tls portion if needed
 		if client.useTLS {
@@ -104,14 +137,23 @@ func NewClient(opts ...Opt) (IClient, error) {
 			client.connStr += tlsPortion
 		}
 	} else {
-		client.routing = strings.Contains(client.connStr, "+routing")
 		_url, err := url.Parse(client.connStr)
 		if err != nil {
 			return nil, fmt.Errorf("an error occurred parsing bolt URL, %w", err)
-		} else if strings.ToLower(_url.Scheme) != "bolt" && strings.ToLower(_url.Scheme) != "bolt+routing" {
-			return nil, fmt.Errorf("unsupported connection string scheme: %s. Driver only supports 'bolt' and 'bolt+routing' scheme", _url.Scheme)
 		}
 
+		scheme, err := connection.ParseScheme(_url.Scheme)
+		if err != nil {
+			return nil, err
+		}
+
+		// +s and +ssc schemes pick the certificate checks, a tls config would override them
+		if scheme.TLS && client.tlsConfig != nil {
+			return nil, errors.Wrap(errors.ErrConfiguration, "can not use WithTLSConfig with the [%s] scheme, use bolt or neo4j", _url.Scheme)
+		}
+
+		client.routing = scheme.Routing
+
 		hostPort := _url.Host
 		if strings.Contains(hostPort, ":") {
 			parts := strings.Split(hostPort, ":")
@@ -147,17 +189,21 @@ func NewClient(opts ...Opt) (IClient, error) {
 		}
 
 		useTLS := _url.Query().Get("tls")
-		client.useTLS = strings.HasPrefix(strings.ToLower(useTLS), "t") || useTLS == "1"
+		client.useTLS = scheme.TLS || strings.HasPrefix(strings.ToLower(useTLS), "t") || useTLS == "1"
 
 		if client.useTLS {
 			client.certFile = _url.Query().Get("tls_cert_file")
 			client.keyFile = _url.Query().Get("tls_key_file")
 			client.caCertFile = _url.Query().Get("tls_ca_cert_file")
 			noVerify := _url.Query().Get("tls_no_verify")
-			client.tlsNoVerify = strings.HasPrefix(strings.ToLower(noVerify), "t") || noVerify == "1"
+			client.tlsNoVerify = scheme.SkipVerify || strings.HasPrefix(strings.ToLower(noVerify), "t") || noVerify == "1"
 		}
 	}
 
+	if client.tlsConfig != nil && (client.certFile != "" || client.caCertFile != "") {
+		return nil, errors.Wrap(errors.ErrConfiguration, "can not use WithTLSConfig with tls cert files")
+	}
+
 	return client, nil
 }
 
@@ -204,6 +250,47 @@ func (c *Client) getUsernamePassword() (string, error) {
 	return fmt.Sprintf("%s:%s", u.User.Username(), pwd), nil
 }
 
+// connectionConfig is applied to every connection the client opens
+func (c *Client) connectionConfig() connection.Config {
+	return connection.Config{
+		KeepAlive:     c.keepAlive,
+		Database:      c.database,
+		AuthProvider:  c.authProvider,
+		TLSConfig:     c.tlsConfig,
+		Dialer:        c.dialer,
+		Capture:       c.capture,
+		MinorVersions: c.minorVersions,
+		DecoderLimits: c.decoderLimits,
+	}
+}
+
+// routingConfig is applied to the routing pool of the given per server size and every member connection it opens
+func (c *Client) routingConfig(size int) routing.Config {
+	config := routing.DefaultConfig(size)
+	config.AcquireStrategy = c.acquireStrategy
+	config.AcquireTimeout = c.acquireTimeout
+	config.MaxOverflowPerServer = c.maxOverflow
+	config.MaxConnLifetime = c.maxConnLifetime
+	config.IdleTestThreshold = c.idleTestThreshold
+	config.KeepAlive = c.keepAlive
+	config.Database = c.database
+	config.AuthProvider = c.authProvider
+	config.TLSConfig = c.tlsConfig
+	config.Dialer = c.dialer
+	config.Capture = c.capture
+	config.MinorVersions = c.minorVersions
+	config.DecoderLimits = c.decoderLimits
+	return config
+}
+
+// healthCheck is used by the pools to decide if a connection can be reused
+func (c *Client) healthCheck() connection.HealthCheck {
+	return connection.HealthCheck{
+		MaxLifetime:       c.maxConnLifetime,
+		IdleTestThreshold: c.idleTestThreshold,
+	}
+}
+
 func (c *Client) NewDriver() (IDriver, error) {
 	if c.routing {
 		return nil, errors.New("can not open non pooled driver with routing enabled")
@@ -220,7 +307,7 @@ func (c *Client) NewDriverPool(size int) (IDriverPool, error) {
 	if c.routing {
 		return newRoutingPool(c, size)
 	} else {
-		driverPool, err := newDriverPool(c.connStr, size)
+		driverPool, err := newDriverPool(c, size)
 		if err != nil {
 			return nil, err
 		}
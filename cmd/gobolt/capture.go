@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mindstand/go-bolt/connection"
+)
+
+// runCapture prints the messages in a capture recorded with --capture, a message a line
+func runCapture(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("gobolt capture", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: gobolt capture [file], reads stdin without a file")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	input := stdin
+	if flags.NArg() == 1 {
+		f, err := os.Open(flags.Arg(0))
+		if err != nil {
+			fmt.Fprintln(stderr, errorMessage(err))
+			return exitUsage
+		}
+		defer f.Close()
+		input = f
+	}
+
+	entries, err := connection.ReadCapture(input)
+	if err != nil {
+		fmt.Fprintln(stderr, errorMessage(err))
+		return exitDriverError
+	}
+
+	decoded, err := connection.DecodeCapture(entries)
+	if err != nil {
+		fmt.Fprintln(stderr, errorMessage(err))
+		return exitDriverError
+	}
+
+	for _, message := range decoded {
+		if _, err := fmt.Fprintln(stdout, formatCapturedMessage(message)); err != nil {
+			fmt.Fprintln(stderr, errorMessage(err))
+			return exitDriverError
+		}
+	}
+
+	return exitOK
+}
+
+// formatCapturedMessage writes a message as `time connection C: NAME fields`, C: for what the client sent
+// and S: for what the server did
+func formatCapturedMessage(message connection.CapturedMessage) string {
+	side := "C:"
+	if message.Direction == connection.CaptureReceived {
+		side = "S:"
+	}
+
+	parts := []string{message.Time.Format(time.RFC3339Nano), message.Connection, side, message.Name}
+	for _, field := range message.Fields {
+		parts = append(parts, formatValue(field))
+	}
+	return strings.Join(parts, " ")
+}
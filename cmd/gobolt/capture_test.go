@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mindstand/go-bolt/connection"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunCapture(t *testing.T) {
+	req := require.New(t)
+
+	var capture bytes.Buffer
+	at := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	for _, entry := range []connection.CaptureEntry{
+		{Time: at, Connection: "c1", Direction: connection.CaptureSent, Data: []byte{
+			0x60, 0x60, 0xb0, 0x17,
+			0x00, 0x03, 0x04, 0x04, 0x00, 0x00, 0x00, 0x04, 0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00, 0x02,
+		}},
+		{Time: at, Connection: "c1", Direction: connection.CaptureReceived, Data: []byte{0x00, 0x00, 0x04, 0x04}},
+		// RUN "RETURN 1" {} {}
+		{Time: at, Connection: "c1", Direction: connection.CaptureSent, Data: []byte{
+			0x00, 0x0d, 0xb3, 0x10, 0x88, 'R', 'E', 'T', 'U', 'R', 'N', ' ', '1', 0xa0, 0xa0, 0x00, 0x00,
+		}},
+		// SUCCESS {fields: ["1"]}
+		{Time: at, Connection: "c1", Direction: connection.CaptureReceived, Data: []byte{
+			0x00, 0x0d, 0xb1, 0x70, 0xa1, 0x86, 'f', 'i', 'e', 'l', 'd', 's', 0x91, 0x81, '1', 0x00, 0x00,
+		}},
+	} {
+		line, err := jsonLine(entry)
+		req.Nil(err)
+		capture.WriteString(line)
+	}
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	req.Equal(exitOK, run([]string{"capture"}, &capture, stdout, stderr), stderr.String())
+	req.Equal(strings.Join([]string{
+		`2021-03-04T05:06:07Z c1 C: HANDSHAKE "4.4-4.1" "4.0" "3.0" "2.0"`,
+		`2021-03-04T05:06:07Z c1 S: VERSION "4.4"`,
+		`2021-03-04T05:06:07Z c1 C: RUN "RETURN 1" {} {}`,
+		`2021-03-04T05:06:07Z c1 S: SUCCESS {fields: ["1"]}`,
+	}, "\n")+"\n", stdout.String())
+
+	req.Equal(exitDriverError, run([]string{"capture"}, strings.NewReader("not a capture\n"), stdout, stderr))
+	req.Equal(exitUsage, run([]string{"capture", "does-not-exist.capture"}, strings.NewReader(""), stdout, stderr))
+	req.Equal(exitUsage, run([]string{"capture", "a", "b"}, strings.NewReader(""), stdout, stderr))
+}
+
+func jsonLine(entry connection.CaptureEntry) (string, error) {
+	line, err := json.Marshal(entry)
+	return string(line) + "\n", err
+}
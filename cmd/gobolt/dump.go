@@ -0,0 +1,86 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/mindstand/go-bolt/encoding/packstream"
+)
+
+// runDump prints packstream as an annotated tree. The bytes are hex given as arguments, or read from
+// --file or stdin as hex, or as they are with --binary
+func runDump(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("gobolt dump", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: gobolt dump [--values] [--binary] [--file file] [hex...]")
+		flags.PrintDefaults()
+	}
+
+	var file string
+	var values, binary bool
+	flags.BoolVar(&values, "values", false, "read bare packstream values instead of chunked messages")
+	flags.BoolVar(&binary, "binary", false, "read raw bytes instead of hex from the file or stdin")
+	flags.StringVar(&file, "f", "", "file to read instead of stdin")
+	flags.StringVar(&file, "file", "", "file to read instead of stdin")
+
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if flags.NArg() > 0 && (file != "" || binary) {
+		fmt.Fprintln(stderr, "hex arguments can't be used with --file or --binary")
+		return exitUsage
+	}
+
+	var data []byte
+	if flags.NArg() > 0 {
+		parsed, err := packstream.ParseHex(strings.Join(flags.Args(), " "))
+		if err != nil {
+			fmt.Fprintln(stderr, errorMessage(err))
+			return exitUsage
+		}
+		data = parsed
+	} else {
+		input := stdin
+		if file != "" {
+			f, err := os.Open(file)
+			if err != nil {
+				fmt.Fprintln(stderr, errorMessage(err))
+				return exitUsage
+			}
+			defer f.Close()
+			input = f
+		}
+
+		read, err := ioutil.ReadAll(input)
+		if err != nil {
+			fmt.Fprintln(stderr, errorMessage(err))
+			return exitUsage
+		}
+
+		data = read
+		if !binary {
+			if data, err = packstream.ParseHex(string(read)); err != nil {
+				fmt.Fprintln(stderr, errorMessage(err))
+				return exitUsage
+			}
+		}
+	}
+
+	dump := packstream.Dump
+	if values {
+		dump = packstream.DumpValues
+	}
+
+	if err := dump(stdout, data); err != nil {
+		fmt.Fprintln(stderr, errorMessage(err))
+		return exitDriverError
+	}
+
+	return exitOK
+}
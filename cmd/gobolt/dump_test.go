@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunDump(t *testing.T) {
+	req := require.New(t)
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	req.Equal(exitOK, run([]string{"dump", "00", "03", "b1", "70", "a0", "00", "00"}, strings.NewReader(""), stdout, stderr), stderr.String())
+	req.Contains(stdout.String(), "TinyStructMarker size 1 signature 0x70 SUCCESS")
+	req.Contains(stdout.String(), "end of message")
+
+	stdout.Reset()
+	req.Equal(exitOK, run([]string{"dump", "--values"}, strings.NewReader("b1 4e 01\n"), stdout, stderr), stderr.String())
+	req.Contains(stdout.String(), "signature 0x4e Node")
+
+	stdout.Reset()
+	req.Equal(exitOK, run([]string{"dump", "--values", "--binary"}, bytes.NewReader([]byte{0x81, 'a'}), stdout, stderr), stderr.String())
+	req.Contains(stdout.String(), `TinyStringMarker size 1 "a"`)
+
+	// malformed input is flagged where it goes wrong
+	stdout.Reset()
+	stderr.Reset()
+	req.Equal(exitDriverError, run([]string{"dump", "--values", "92", "01", "c4"}, strings.NewReader(""), stdout, stderr))
+	req.Contains(stdout.String(), "0002  !! unknown marker 0xc4")
+	req.Contains(stderr.String(), "offset 0x0002")
+
+	req.Equal(exitUsage, run([]string{"dump", "zz"}, strings.NewReader(""), stdout, stderr))
+	req.Equal(exitUsage, run([]string{"dump", "--binary", "00"}, strings.NewReader(""), stdout, stderr))
+	req.Equal(exitUsage, run([]string{"dump", "--file", "does-not-exist.hex"}, strings.NewReader(""), stdout, stderr))
+}
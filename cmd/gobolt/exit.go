@@ -0,0 +1,79 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/mindstand/go-bolt/errors"
+	"github.com/mindstand/go-bolt/structures/messages"
+)
+
+// exit codes, failures neo4j reports are mapped from the classification in their code
+const (
+	exitOK = 0
+	// the driver failed, usually the server couldn't be reached
+	exitDriverError = 1
+	// bad flags, script file or parameters
+	exitUsage = 2
+	// Neo.ClientError, the statement or its parameters are wrong and retrying won't help
+	exitClientError = 3
+	// Neo.TransientError, the statement may succeed if it is retried
+	exitTransientError = 4
+	// Neo.DatabaseError, the server failed
+	exitDatabaseError = 5
+)
+
+// failureOf finds the failure neo4j sent in err
+func failureOf(err error) (messages.FailureMessage, bool) {
+	if wrapped, ok := err.(*errors.Error); ok {
+		err = wrapped.InnerMost()
+	}
+
+	failure, ok := err.(messages.FailureMessage)
+	return failure, ok
+}
+
+// exitCode maps err to the exit code it should end the shell with
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+
+	if _, ok := err.(usageError); ok {
+		return exitUsage
+	}
+
+	failure, ok := failureOf(err)
+	if !ok {
+		return exitDriverError
+	}
+
+	// codes look like Neo.ClientError.Statement.SyntaxError
+	parts := strings.Split(failure.GetCode(), ".")
+	if len(parts) < 2 {
+		return exitDriverError
+	}
+
+	switch parts[1] {
+	case "ClientError":
+		return exitClientError
+	case "TransientError":
+		return exitTransientError
+	case "DatabaseError":
+		return exitDatabaseError
+	default:
+		return exitDriverError
+	}
+}
+
+// errorMessage is what the shell prints for err, neo4j failures without the driver's stack trace
+func errorMessage(err error) string {
+	if failure, ok := failureOf(err); ok {
+		return failure.GetCode() + ": " + failure.GetMessage()
+	}
+
+	if wrapped, ok := err.(*errors.Error); ok {
+		return wrapped.InnerMost().Error()
+	}
+
+	return err.Error()
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mindstand/go-bolt/structures/graph"
+	"github.com/mindstand/go-bolt/structures/types"
+)
+
+// output formats
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatCSV   = "csv"
+	formatGraph = "graph"
+)
+
+func validFormat(format string) bool {
+	return format == formatTable || format == formatJSON || format == formatCSV || format == formatGraph
+}
+
+// writeRecords writes the records of a statement in format
+func writeRecords(w io.Writer, format string, columns []string, records [][]interface{}) error {
+	switch format {
+	case formatJSON:
+		return writeJSON(w, columns, records)
+	case formatCSV:
+		return writeCSV(w, columns, records)
+	case formatGraph:
+		return writeGraph(w, records)
+	default:
+		return writeTable(w, columns, records)
+	}
+}
+
+// writeJSON writes a json object per record, keyed by column
+func writeJSON(w io.Writer, columns []string, records [][]interface{}) error {
+	encoder := json.NewEncoder(w)
+	for _, record := range records {
+		row := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			if i < len(record) {
+				row[column] = types.JSONValue(record[i])
+			}
+		}
+
+		if err := encoder.Encode(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeGraph writes the nodes and relationships of all the records as one json graph
+func writeGraph(w io.Writer, records [][]interface{}) error {
+	g := &graph.Graph{}
+	for _, record := range records {
+		for _, val := range record {
+			g.Add(val)
+		}
+	}
+	return json.NewEncoder(w).Encode(g)
+}
+
+// writeCSV writes a header of the columns then a line per record. Strings are written as is,
+// everything else as a cypher literal
+func writeCSV(w io.Writer, columns []string, records [][]interface{}) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(columns); err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		line := make([]string, len(record))
+		for i, val := range record {
+			if str, ok := val.(string); ok {
+				line[i] = str
+			} else {
+				line[i] = formatValue(val)
+			}
+		}
+
+		if err := writer.Write(line); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// writeTable writes the records as a bordered table like cypher-shell
+func writeTable(w io.Writer, columns []string, records [][]interface{}) error {
+	if len(columns) == 0 {
+		return nil
+	}
+
+	cells := make([][]string, len(records))
+	widths := make([]int, len(columns))
+	for i, column := range columns {
+		widths[i] = utf8.RuneCountInString(column)
+	}
+
+	for i, record := range records {
+		cells[i] = make([]string, len(columns))
+		for j := range columns {
+			if j < len(record) {
+				cells[i][j] = formatValue(record[j])
+			}
+			if width := utf8.RuneCountInString(cells[i][j]); width > widths[j] {
+				widths[j] = width
+			}
+		}
+	}
+
+	var sb strings.Builder
+	border := func() {
+		sb.WriteString("+")
+		for _, width := range widths {
+			sb.WriteString(strings.Repeat("-", width+2))
+			sb.WriteString("+")
+		}
+		sb.WriteString("\n")
+	}
+	row := func(values []string) {
+		sb.WriteString("|")
+		for i, val := range values {
+			sb.WriteString(" ")
+			sb.WriteString(val)
+			sb.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(val)+1))
+			sb.WriteString("|")
+		}
+		sb.WriteString("\n")
+	}
+
+	border()
+	row(columns)
+	border()
+	for _, values := range cells {
+		row(values)
+	}
+	if len(cells) != 0 {
+		border()
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// formatValue writes val the way cypher would, nodes as (:Label {key: value}) and relationships as [:TYPE]
+func formatValue(val interface{}) string {
+	switch v := val.(type) {
+	case nil:
+		return "null"
+	case string:
+		return strconv.Quote(v)
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = formatValue(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case map[string]interface{}:
+		return formatMap(v)
+	case graph.Node:
+		return formatNode(v)
+	case graph.Relationship:
+		return "[:" + v.Type + formatProperties(v.Properties) + "]"
+	case graph.UnboundRelationship:
+		return "[:" + v.Type + formatProperties(v.Properties) + "]"
+	case graph.Path:
+		return formatPath(v)
+	case types.Point2D:
+		return fmt.Sprintf("point({srid: %d, x: %v, y: %v})", v.SRID, v.X, v.Y)
+	case *types.Point2D:
+		return formatValue(*v)
+	case types.Point3D:
+		return fmt.Sprintf("point({srid: %d, x: %v, y: %v, z: %v})", v.SRID, v.X, v.Y, v.Z)
+	case *types.Point3D:
+		return formatValue(*v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case types.DateTime:
+		if v.HasZoneId() {
+			return v.Time.Format(time.RFC3339Nano) + "[" + v.ZoneId + "]"
+		}
+		return v.Time.Format(time.RFC3339Nano)
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// formatMap writes m with its keys sorted so output is stable
+func formatMap(m map[string]interface{}) string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	items := make([]string, len(keys))
+	for i, key := range keys {
+		items[i] = key + ": " + formatValue(m[key])
+	}
+	return "{" + strings.Join(items, ", ") + "}"
+}
+
+func formatProperties(properties map[string]interface{}) string {
+	if len(properties) == 0 {
+		return ""
+	}
+	return " " + formatMap(properties)
+}
+
+func formatNode(node graph.Node) string {
+	var sb strings.Builder
+	sb.WriteString("(")
+	for _, label := range node.Labels {
+		sb.WriteString(":")
+		sb.WriteString(label)
+	}
+
+	properties := formatProperties(node.Properties)
+	if len(node.Labels) == 0 {
+		properties = strings.TrimPrefix(properties, " ")
+	}
+	sb.WriteString(properties)
+	sb.WriteString(")")
+	return sb.String()
+}
+
+// formatPath walks the path's sequence, pairs of a 1 based relationship index, negative when the
+// relationship points backwards, and the index of the next node
+func formatPath(path graph.Path) string {
+	if len(path.Nodes) == 0 {
+		return "<>"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(formatNode(path.Nodes[0]))
+	for i := 0; i+1 < len(path.Sequence); i += 2 {
+		relIndex, nodeIndex := path.Sequence[i], path.Sequence[i+1]
+
+		backwards := relIndex < 0
+		if backwards {
+			relIndex = -relIndex
+		}
+		if relIndex == 0 || relIndex > len(path.Relationships) || nodeIndex < 0 || nodeIndex >= len(path.Nodes) {
+			break
+		}
+
+		rel := formatValue(path.Relationships[relIndex-1])
+		if backwards {
+			sb.WriteString("<-" + rel + "-")
+		} else {
+			sb.WriteString("-" + rel + "->")
+		}
+		sb.WriteString(formatNode(path.Nodes[nodeIndex]))
+	}
+	return sb.String()
+}
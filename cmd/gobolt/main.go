@@ -0,0 +1,174 @@
+// Command gobolt is a cypher shell built on the go-bolt driver.
+//
+// Without --file it reads statements from stdin, prompting for them when stdin is a terminal.
+// Statements end with a semicolon and may span several lines. Lines starting with a colon are
+// shell commands, run :help to list them.
+//
+// Usage:
+//
+//	gobolt -a bolt://localhost:7687 -u neo4j -p secret [-d database] [--format table|json|csv|graph] [--file script.cypher] [--param 'name => value']... [--capture file]
+//
+// --capture records the bytes sent to and received from the server, credentials included, so a session
+// can be attached to a bug report. The capture subcommand prints the messages in a capture:
+//
+//	gobolt capture session.capture
+//
+// The dump subcommand prints packstream bytes as an annotated tree, from hex arguments or hex on stdin.
+// --values reads bare values instead of chunked messages and --binary reads raw bytes:
+//
+//	gobolt dump 00 03 b1 70 a0 00 00
+//
+// The exit code tells scripts why a run failed, see the exit constants
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	goBolt "github.com/mindstand/go-bolt"
+	"github.com/mindstand/go-bolt/bolt_mode"
+	"github.com/mindstand/go-bolt/connection"
+)
+
+// paramFlags collects repeated --param flags
+type paramFlags []string
+
+func (p *paramFlags) String() string {
+	return strings.Join(*p, ", ")
+}
+
+func (p *paramFlags) Set(value string) error {
+	*p = append(*p, value)
+	return nil
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run parses args, connects and runs the script or shell, returning the exit code
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "capture":
+			return runCapture(args[1:], stdin, stdout, stderr)
+		case "dump":
+			return runDump(args[1:], stdin, stdout, stderr)
+		}
+	}
+
+	flags := flag.NewFlagSet("gobolt", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	var address, username, password, database, format, file, captureFile string
+	var params paramFlags
+	flags.StringVar(&address, "a", envOr("NEO4J_ADDRESS", "bolt://localhost:7687"), "connection string of the server, defaults to $NEO4J_ADDRESS")
+	flags.StringVar(&address, "address", envOr("NEO4J_ADDRESS", "bolt://localhost:7687"), "connection string of the server, defaults to $NEO4J_ADDRESS")
+	flags.StringVar(&username, "u", os.Getenv("NEO4J_USERNAME"), "user to authenticate as, defaults to $NEO4J_USERNAME")
+	flags.StringVar(&username, "username", os.Getenv("NEO4J_USERNAME"), "user to authenticate as, defaults to $NEO4J_USERNAME")
+	flags.StringVar(&password, "p", os.Getenv("NEO4J_PASSWORD"), "password to authenticate with, defaults to $NEO4J_PASSWORD")
+	flags.StringVar(&password, "password", os.Getenv("NEO4J_PASSWORD"), "password to authenticate with, defaults to $NEO4J_PASSWORD")
+	flags.StringVar(&database, "d", "", "database to run statements against, defaults to the server's default database")
+	flags.StringVar(&database, "database", "", "database to run statements against, defaults to the server's default database")
+	flags.StringVar(&format, "format", formatTable, "output format, one of table, json, csv or graph")
+	flags.StringVar(&file, "f", "", "script to run instead of reading stdin, stops at the first failure")
+	flags.StringVar(&file, "file", "", "script to run instead of reading stdin, stops at the first failure")
+	flags.Var(&params, "P", "parameter as 'name => value', may be repeated")
+	flags.Var(&params, "param", "parameter as 'name => value', may be repeated")
+	flags.StringVar(&captureFile, "capture", "", "file to record the bytes sent and received in, credentials included")
+
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if !validFormat(format) {
+		fmt.Fprintf(stderr, "unknown format [%s], expected table, json, csv or graph\n", format)
+		return exitUsage
+	}
+
+	input := stdin
+	interactive := file == "" && isTerminal(stdin)
+	if file != "" {
+		f, err := os.Open(file)
+		if err != nil {
+			fmt.Fprintln(stderr, errorMessage(err))
+			return exitUsage
+		}
+		defer f.Close()
+		input = f
+	}
+
+	opts := []goBolt.Opt{goBolt.WithConnectionString(address)}
+	if username != "" {
+		opts = append(opts, goBolt.WithBasicAuth(username, password))
+	}
+
+	if captureFile != "" {
+		f, err := os.Create(captureFile)
+		if err != nil {
+			fmt.Fprintln(stderr, errorMessage(err))
+			return exitUsage
+		}
+		defer f.Close()
+		opts = append(opts, goBolt.WithCapture(connection.NewCapture(f)))
+	}
+
+	client, err := goBolt.NewClient(opts...)
+	if err != nil {
+		fmt.Fprintln(stderr, errorMessage(err))
+		return exitUsage
+	}
+
+	pool, err := client.NewDriverPool(1)
+	if err != nil {
+		fmt.Fprintln(stderr, errorMessage(err))
+		return exitCode(err)
+	}
+	defer pool.Close()
+
+	sh, err := newShell(pool, database, format, stdout, stderr)
+	if err != nil {
+		fmt.Fprintln(stderr, errorMessage(err))
+		return exitCode(err)
+	}
+	defer sh.close()
+
+	for _, param := range params {
+		if err := sh.setParam(param); err != nil {
+			fmt.Fprintln(stderr, errorMessage(err))
+			return exitUsage
+		}
+	}
+
+	return sh.run(input, interactive)
+}
+
+// isTerminal reports if r is a terminal, so prompts are only shown to people
+func isTerminal(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	if !ok {
+		return false
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func envOr(key, fallback string) string {
+	if val := os.Getenv(key); val != "" {
+		return val
+	}
+	return fallback
+}
+
+// openConnection opens a write connection to db, empty db uses the server's default database
+func openConnection(pool goBolt.IDriverPool, db string) (connection.IConnection, error) {
+	return pool.OpenSession(bolt_mode.WriteMode, goBolt.SessionConfig{Database: db})
+}
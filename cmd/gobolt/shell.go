@@ -0,0 +1,448 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	goBolt "github.com/mindstand/go-bolt"
+	"github.com/mindstand/go-bolt/connection"
+)
+
+const (
+	prompt         = "gobolt> "
+	continuePrompt = "      > "
+	// longest line the shell reads, scripts can hold large literals
+	maxLineSize = 16 * 1024 * 1024
+)
+
+const helpText = `statements end with a semicolon and may span several lines
+:begin                 open a transaction
+:commit                commit the open transaction
+:rollback              roll back the open transaction
+:use <database>        run statements against another database
+:param <name> => <value>  set a parameter, values are json or 'single quoted' strings
+:params                list the parameters
+:params clear          remove every parameter
+:format <format>       switch output to table, json, csv or graph
+:help                  show this help
+:exit                  leave the shell
+`
+
+// usageError is a mistake in a shell command rather than a failure of the statement
+type usageError string
+
+func (u usageError) Error() string {
+	return string(u)
+}
+
+// shell runs statements and commands on one connection from the pool
+type shell struct {
+	pool     goBolt.IDriverPool
+	conn     connection.IConnection
+	tx       connection.ITransaction
+	database string
+	params   connection.QueryParams
+	format   string
+
+	out    io.Writer
+	errOut io.Writer
+}
+
+func newShell(pool goBolt.IDriverPool, database, format string, out, errOut io.Writer) (*shell, error) {
+	conn, err := openConnection(pool, database)
+	if err != nil {
+		return nil, err
+	}
+
+	return &shell{
+		pool:     pool,
+		conn:     conn,
+		database: database,
+		params:   connection.QueryParams{},
+		format:   format,
+		out:      out,
+		errOut:   errOut,
+	}, nil
+}
+
+// close rolls back a transaction left open and gives the connection back
+func (s *shell) close() {
+	if s.tx != nil {
+		_ = s.tx.Rollback()
+		s.tx = nil
+	}
+
+	if s.conn != nil {
+		_ = s.pool.Reclaim(s.conn)
+		s.conn = nil
+	}
+}
+
+// session returns the shell's connection, opening one to the current database if a failed :use left it without
+func (s *shell) session() (connection.IConnection, error) {
+	if s.conn == nil {
+		conn, err := openConnection(s.pool, s.database)
+		if err != nil {
+			return nil, err
+		}
+		s.conn = conn
+	}
+	return s.conn, nil
+}
+
+// run reads statements and commands from input until it ends or :exit. Interactive shells report
+// failures and carry on, scripts stop at the first one. Returns the exit code
+func (s *shell) run(input io.Reader, interactive bool) int {
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+
+	// fail reports err, returning the exit code when the run should stop
+	fail := func(err error) (int, bool) {
+		fmt.Fprintln(s.errOut, errorMessage(err))
+		if interactive {
+			return exitOK, false
+		}
+		return exitCode(err), true
+	}
+
+	var pending string
+	if interactive {
+		fmt.Fprint(s.errOut, prompt)
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if isBlank(pending) && strings.HasPrefix(strings.TrimSpace(line), ":") {
+			pending = ""
+			quit, err := s.command(strings.TrimSpace(line))
+			if err != nil {
+				if code, stop := fail(err); stop {
+					return code
+				}
+			}
+			if quit {
+				return exitOK
+			}
+		} else {
+			statements, rest := splitStatements(pending + line + "\n")
+			pending = rest
+			for _, statement := range statements {
+				if err := s.execute(statement); err != nil {
+					if code, stop := fail(err); stop {
+						return code
+					}
+				}
+			}
+		}
+
+		if interactive {
+			if isBlank(pending) {
+				fmt.Fprint(s.errOut, prompt)
+			} else {
+				fmt.Fprint(s.errOut, continuePrompt)
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintln(s.errOut, err)
+		return exitUsage
+	}
+
+	// scripts don't need a semicolon after their last statement
+	if !isBlank(pending) {
+		if err := s.execute(strings.TrimSpace(pending)); err != nil {
+			if code, stop := fail(err); stop {
+				return code
+			}
+		}
+	}
+
+	if s.tx != nil && !interactive {
+		fmt.Fprintln(s.errOut, "script ended with an open transaction, rolling it back")
+		return exitUsage
+	}
+
+	return exitOK
+}
+
+// command runs a shell command, returning true when the shell should exit
+func (s *shell) command(line string) (bool, error) {
+	name, arg := line, ""
+	if i := strings.IndexAny(line, " \t"); i >= 0 {
+		name, arg = line[:i], strings.TrimSpace(line[i+1:])
+	}
+
+	switch strings.ToLower(name) {
+	case ":exit", ":quit":
+		return true, nil
+	case ":help":
+		fmt.Fprint(s.out, helpText)
+	case ":begin":
+		if s.tx != nil {
+			return false, usageError("a transaction is already open")
+		}
+
+		conn, err := s.session()
+		if err != nil {
+			return false, err
+		}
+
+		tx, err := conn.Begin()
+		if err != nil {
+			return false, err
+		}
+		s.tx = tx
+	case ":commit":
+		if s.tx == nil {
+			return false, usageError("there is no open transaction to commit")
+		}
+
+		tx := s.tx
+		s.tx = nil
+		return false, tx.Commit()
+	case ":rollback":
+		if s.tx == nil {
+			return false, usageError("there is no open transaction to roll back")
+		}
+
+		tx := s.tx
+		s.tx = nil
+		return false, tx.Rollback()
+	case ":use":
+		return false, s.use(arg)
+	case ":param":
+		return false, s.setParam(arg)
+	case ":params":
+		if arg == "clear" {
+			s.params = connection.QueryParams{}
+			return false, nil
+		}
+		if arg != "" {
+			return false, usageError("usage: :params [clear]")
+		}
+		s.listParams()
+	case ":format":
+		if !validFormat(arg) {
+			return false, usageError("usage: :format table|json|csv|graph")
+		}
+		s.format = arg
+	default:
+		return false, usageError(fmt.Sprintf("unknown command [%s], run :help to list commands", name))
+	}
+
+	return false, nil
+}
+
+// use switches to database db, opening a connection that serves it
+func (s *shell) use(db string) error {
+	if db == "" {
+		return usageError("usage: :use <database>")
+	}
+
+	if s.tx != nil {
+		return usageError("can not switch database with an open transaction")
+	}
+
+	if s.conn != nil {
+		if err := s.pool.Reclaim(s.conn); err != nil {
+			return err
+		}
+		// the pool has the connection back, it can't be used again
+		s.conn = nil
+	}
+
+	conn, err := openConnection(s.pool, db)
+	if err != nil {
+		// stay on the old database, a connection to it is opened again by the next statement if this fails
+		if conn, reopenErr := openConnection(s.pool, s.database); reopenErr == nil {
+			s.conn = conn
+		}
+		return err
+	}
+
+	s.conn = conn
+	s.database = db
+	return nil
+}
+
+// setParam parses name => value, the same as cypher-shell's :param
+func (s *shell) setParam(arg string) error {
+	parts := strings.SplitN(arg, "=>", 2)
+	if len(parts) != 2 {
+		return usageError("usage: :param <name> => <value>")
+	}
+
+	name := strings.Trim(strings.TrimSpace(parts[0]), "`")
+	if name == "" {
+		return usageError("usage: :param <name> => <value>")
+	}
+
+	val, err := parseParam(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return err
+	}
+
+	s.params[name] = val
+	return nil
+}
+
+func (s *shell) listParams() {
+	names := make([]string, 0, len(s.params))
+	for name := range s.params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(s.out, ":param %s => %s\n", name, formatValue(s.params[name]))
+	}
+}
+
+// execute runs statement in the open transaction, or on its own if there isn't one, and writes the records
+func (s *shell) execute(statement string) error {
+	var query connection.IQuery
+	if s.tx != nil {
+		query = s.tx
+	} else {
+		conn, err := s.session()
+		if err != nil {
+			return err
+		}
+		query = conn
+	}
+
+	records, result, err := query.Query(statement, s.params)
+	if err != nil {
+		// the failure reset the session, taking the transaction with it
+		if s.tx != nil && s.tx.IsClosed() {
+			s.tx = nil
+			fmt.Fprintln(s.errOut, "transaction rolled back")
+		}
+		return err
+	}
+
+	var columns []string
+	if result != nil {
+		fields, _ := result.Metadata()["fields"].([]interface{})
+		for _, field := range fields {
+			name, _ := field.(string)
+			columns = append(columns, name)
+		}
+	}
+
+	return writeRecords(s.out, s.format, columns, records)
+}
+
+// parseParam parses a parameter value. Values are json, whole numbers become int64 like neo4j's integers,
+// and 'single quoted' strings are accepted as cypher writes them
+func parseParam(raw string) (interface{}, error) {
+	if len(raw) >= 2 && raw[0] == '\'' && raw[len(raw)-1] == '\'' {
+		return strings.ReplaceAll(raw[1:len(raw)-1], `\'`, `'`), nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader([]byte(raw)))
+	decoder.UseNumber()
+
+	var val interface{}
+	if err := decoder.Decode(&val); err != nil {
+		return nil, usageError(fmt.Sprintf("parameter value [%s] is not json or a quoted string", raw))
+	}
+	if decoder.More() {
+		return nil, usageError(fmt.Sprintf("parameter value [%s] has more than one value", raw))
+	}
+
+	return convertNumbers(val), nil
+}
+
+// convertNumbers turns the json.Numbers in val into int64s, or float64s when they have a fraction
+func convertNumbers(val interface{}) interface{} {
+	switch v := val.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case []interface{}:
+		for i := range v {
+			v[i] = convertNumbers(v[i])
+		}
+	case map[string]interface{}:
+		for key := range v {
+			v[key] = convertNumbers(v[key])
+		}
+	}
+	return val
+}
+
+// splitStatements cuts the complete statements off text, those ending in a semicolon outside of strings,
+// quoted names and comments. rest is the text after the last semicolon
+func splitStatements(text string) (statements []string, rest string) {
+	start := 0
+	// quote is the character closing the string or name being read, 0 outside of one
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		ch := text[i]
+
+		switch {
+		case quote != 0:
+			if ch == '\\' && quote != '`' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"' || ch == '`':
+			quote = ch
+		case ch == '/' && i+1 < len(text) && text[i+1] == '/':
+			end := strings.IndexByte(text[i:], '\n')
+			if end < 0 {
+				return statements, text[start:]
+			}
+			i += end
+		case ch == '/' && i+1 < len(text) && text[i+1] == '*':
+			end := strings.Index(text[i+2:], "*/")
+			if end < 0 {
+				return statements, text[start:]
+			}
+			i += end + 3
+		case ch == ';':
+			if statement := strings.TrimSpace(text[start:i]); statement != "" {
+				statements = append(statements, statement)
+			}
+			start = i + 1
+		}
+	}
+
+	return statements, text[start:]
+}
+
+// isBlank reports if text holds nothing but whitespace and comments
+func isBlank(text string) bool {
+	for i := 0; i < len(text); i++ {
+		switch {
+		case text[i] == ' ' || text[i] == '\t' || text[i] == '\n' || text[i] == '\r':
+		case strings.HasPrefix(text[i:], "//"):
+			end := strings.IndexByte(text[i:], '\n')
+			if end < 0 {
+				return true
+			}
+			i += end
+		case strings.HasPrefix(text[i:], "/*"):
+			end := strings.Index(text[i+2:], "*/")
+			if end < 0 {
+				return true
+			}
+			i += end + 3
+		default:
+			return false
+		}
+	}
+	return true
+}
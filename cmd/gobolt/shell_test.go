@@ -0,0 +1,351 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	goBolt "github.com/mindstand/go-bolt"
+	"github.com/mindstand/go-bolt/bolt_mode"
+	"github.com/mindstand/go-bolt/connection"
+	"github.com/mindstand/go-bolt/errors"
+	"github.com/mindstand/go-bolt/structures/graph"
+	"github.com/mindstand/go-bolt/structures/messages"
+	"github.com/mindstand/go-bolt/structures/types"
+	"github.com/mindstand/gotime"
+	"github.com/stretchr/testify/require"
+)
+
+// ran records a statement the fake connection was given
+type ran struct {
+	statement string
+	params    connection.QueryParams
+	database  string
+	inTx      bool
+}
+
+type fakeResult struct {
+	connection.IResult
+	fields []interface{}
+}
+
+func (f *fakeResult) Metadata() map[string]interface{} {
+	return map[string]interface{}{"fields": f.fields}
+}
+
+// fakeConn answers every statement with one record holding the statement, FAIL statements fail with their code
+type fakeConn struct {
+	connection.IConnection
+	database string
+	ran      *[]ran
+	tx       *fakeTx
+}
+
+func (f *fakeConn) query(statement string, params connection.QueryParams, inTx bool) ([][]interface{}, connection.IResult, error) {
+	copied := connection.QueryParams{}
+	for key, val := range params {
+		copied[key] = val
+	}
+	*f.ran = append(*f.ran, ran{statement: statement, params: copied, database: f.database, inTx: inTx})
+
+	if strings.HasPrefix(statement, "FAIL ") {
+		failure := messages.NewFailureMessage(map[string]interface{}{
+			"code":    strings.TrimPrefix(statement, "FAIL "),
+			"message": "failed",
+		})
+		// like the driver, a failure resets the session and ends the transaction
+		if f.tx != nil {
+			f.tx.closed = true
+			f.tx = nil
+		}
+		return nil, nil, errors.Wrap(failure, "Neo4J reported a failure for the runQuery")
+	}
+
+	return [][]interface{}{{statement}}, &fakeResult{fields: []interface{}{"statement"}}, nil
+}
+
+func (f *fakeConn) Query(statement string, params connection.QueryParams) ([][]interface{}, connection.IResult, error) {
+	return f.query(statement, params, false)
+}
+
+func (f *fakeConn) Begin() (connection.ITransaction, error) {
+	f.tx = &fakeTx{conn: f}
+	return f.tx, nil
+}
+
+type fakeTx struct {
+	connection.ITransaction
+	conn      *fakeConn
+	closed    bool
+	committed bool
+}
+
+func (f *fakeTx) Query(statement string, params connection.QueryParams) ([][]interface{}, connection.IResult, error) {
+	return f.conn.query(statement, params, true)
+}
+
+func (f *fakeTx) Commit() error {
+	f.closed, f.committed = true, true
+	return nil
+}
+
+func (f *fakeTx) Rollback() error {
+	f.closed = true
+	return nil
+}
+
+func (f *fakeTx) IsClosed() bool {
+	return f.closed
+}
+
+type fakePool struct {
+	goBolt.IDriverPool
+	ran      []ran
+	borrowed int
+	// failOpens is how many of the next sessions fail to open
+	failOpens int
+}
+
+func (f *fakePool) OpenSession(mode bolt_mode.AccessMode, session goBolt.SessionConfig) (connection.IConnection, error) {
+	if f.failOpens > 0 {
+		f.failOpens--
+		return nil, errors.New("connection refused")
+	}
+	f.borrowed++
+	return &fakeConn{database: session.Database, ran: &f.ran}, nil
+}
+
+func (f *fakePool) Reclaim(conn connection.IConnection) error {
+	f.borrowed--
+	return nil
+}
+
+func newTestShell(t *testing.T, format string) (*shell, *fakePool, *bytes.Buffer, *bytes.Buffer) {
+	pool := &fakePool{}
+	out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+	sh, err := newShell(pool, "", format, out, errOut)
+	require.Nil(t, err)
+	return sh, pool, out, errOut
+}
+
+func TestShellScript(t *testing.T) {
+	req := require.New(t)
+	sh, pool, out, _ := newTestShell(t, formatCSV)
+
+	script := `:param name => 'Alice'
+:param age => 42
+CREATE (p:Person {name: $name, age: $age})
+  RETURN p; RETURN ';' // a comment with a ;
+;
+:begin
+RETURN 1;
+:commit
+:use other
+// the last statement doesn't need a semicolon
+RETURN 2
+`
+	code := sh.run(strings.NewReader(script), false)
+	req.Equal(exitOK, code)
+
+	req.Len(pool.ran, 4)
+	req.Equal("CREATE (p:Person {name: $name, age: $age})\n  RETURN p", pool.ran[0].statement)
+	req.Equal(connection.QueryParams{"name": "Alice", "age": int64(42)}, pool.ran[0].params)
+	req.Equal("RETURN ';' // a comment with a ;", pool.ran[1].statement)
+	req.False(pool.ran[1].inTx)
+	req.Equal("RETURN 1", pool.ran[2].statement)
+	req.True(pool.ran[2].inTx)
+	req.Equal("// the last statement doesn't need a semicolon\nRETURN 2", pool.ran[3].statement)
+	req.Equal("other", pool.ran[3].database)
+
+	// csv quotes the statement's newline
+	req.True(strings.HasPrefix(out.String(), "statement\n\"CREATE (p:Person {name: $name, age: $age})\n  RETURN p\"\n"))
+
+	sh.close()
+	req.Equal(0, pool.borrowed)
+}
+
+func TestShellUseFails(t *testing.T) {
+	req := require.New(t)
+	sh, pool, _, _ := newTestShell(t, formatCSV)
+
+	// neither the new database nor the old one can be reached, the reclaimed connection isn't used again
+	pool.failOpens = 2
+	req.NotNil(sh.use("other"))
+	req.Nil(sh.conn)
+	req.Equal(0, pool.borrowed)
+
+	// the next statement opens a connection to the old database
+	req.Nil(sh.execute("RETURN 1"))
+	req.Equal(1, pool.borrowed)
+	req.Len(pool.ran, 1)
+	req.Equal("", pool.ran[0].database)
+
+	// with the new database unreachable the shell stays on the old one
+	pool.failOpens = 1
+	req.NotNil(sh.use("other"))
+	req.NotNil(sh.conn)
+	req.Equal(1, pool.borrowed)
+
+	sh.close()
+	req.Equal(0, pool.borrowed)
+}
+
+func TestShellExitCodes(t *testing.T) {
+	req := require.New(t)
+
+	cases := []struct {
+		script string
+		code   int
+	}{
+		{"RETURN 1;", exitOK},
+		{"FAIL Neo.ClientError.Statement.SyntaxError;", exitClientError},
+		{"FAIL Neo.TransientError.Transaction.DeadlockDetected;", exitTransientError},
+		{"FAIL Neo.DatabaseError.General.UnknownError;", exitDatabaseError},
+		{":nope", exitUsage},
+		{":param broken", exitUsage},
+		{":commit", exitUsage},
+		{":begin\nRETURN 1;", exitUsage},
+	}
+
+	for _, c := range cases {
+		sh, _, _, _ := newTestShell(t, formatTable)
+		req.Equal(c.code, sh.run(strings.NewReader(c.script), false), c.script)
+	}
+
+	// scripts stop at the first failure
+	sh, pool, _, errOut := newTestShell(t, formatTable)
+	req.Equal(exitClientError, sh.run(strings.NewReader("FAIL Neo.ClientError.Schema.ConstraintValidationFailed;\nRETURN 1;"), false))
+	req.Len(pool.ran, 1)
+	req.Contains(errOut.String(), "Neo.ClientError.Schema.ConstraintValidationFailed: failed")
+	req.Equal(exitDriverError, exitCode(errors.New("connection refused")))
+}
+
+func TestShellInteractive(t *testing.T) {
+	req := require.New(t)
+	sh, pool, _, errOut := newTestShell(t, formatTable)
+
+	// failures are reported and the shell carries on, a failure in a transaction ends it
+	input := ":begin\nFAIL Neo.ClientError.Statement.SyntaxError;\nRETURN\n1;\n:exit\nRETURN 2;\n"
+	req.Equal(exitOK, sh.run(strings.NewReader(input), true))
+
+	req.Len(pool.ran, 2)
+	req.Equal("RETURN\n1", pool.ran[1].statement)
+	req.False(pool.ran[1].inTx)
+	req.Nil(sh.tx)
+	req.Contains(errOut.String(), "transaction rolled back")
+	req.Contains(errOut.String(), continuePrompt)
+}
+
+func TestSplitStatements(t *testing.T) {
+	req := require.New(t)
+
+	statements, rest := splitStatements("RETURN 1; RETURN \"a;\\\"b\";\nRETURN `x;y` /* ; */; RETURN")
+	req.Equal([]string{"RETURN 1", "RETURN \"a;\\\"b\"", "RETURN `x;y` /* ; */"}, statements)
+	req.Equal(" RETURN", rest)
+
+	// an unfinished string or comment keeps everything pending
+	statements, rest = splitStatements("RETURN 'a;\n")
+	req.Empty(statements)
+	req.Equal("RETURN 'a;\n", rest)
+
+	req.True(isBlank("  // comment\n /* block */ \n"))
+	req.False(isBlank("// comment\nRETURN 1"))
+}
+
+func TestParseParam(t *testing.T) {
+	req := require.New(t)
+
+	cases := map[string]interface{}{
+		"1":                   int64(1),
+		"1.5":                 1.5,
+		"'it\\'s'":            "it's",
+		`"json"`:              "json",
+		"true":                true,
+		"null":                nil,
+		`[1, "a"]`:            []interface{}{int64(1), "a"},
+		`{"a": {"b": [2.5]}}`: map[string]interface{}{"a": map[string]interface{}{"b": []interface{}{2.5}}},
+	}
+
+	for raw, expected := range cases {
+		val, err := parseParam(raw)
+		req.Nil(err, raw)
+		req.Equal(expected, val, raw)
+	}
+
+	_, err := parseParam("Alice")
+	req.NotNil(err)
+	_, err = parseParam("1 2")
+	req.NotNil(err)
+}
+
+func TestFormats(t *testing.T) {
+	req := require.New(t)
+
+	node := graph.Node{Labels: []string{"Person"}, Properties: map[string]interface{}{"name": "Alice", "age": int64(42)}}
+	path := graph.Path{
+		Nodes:         []graph.Node{node, {Labels: []string{"City"}}},
+		Relationships: []graph.UnboundRelationship{{Type: "LIVES_IN"}},
+		Sequence:      []int{1, 1},
+	}
+	columns := []string{"n", "p", "list"}
+	records := [][]interface{}{{node, path, []interface{}{int64(1), "a", nil}}}
+
+	out := &bytes.Buffer{}
+	req.Nil(writeRecords(out, formatTable, columns, records))
+	req.Equal(`+------------------------------------+---------------------------------------------------------+----------------+
+| n                                  | p                                                       | list           |
++------------------------------------+---------------------------------------------------------+----------------+
+| (:Person {age: 42, name: "Alice"}) | (:Person {age: 42, name: "Alice"})-[:LIVES_IN]->(:City) | [1, "a", null] |
++------------------------------------+---------------------------------------------------------+----------------+
+`, out.String())
+
+	out.Reset()
+	req.Nil(writeRecords(out, formatCSV, []string{"name", "n"}, [][]interface{}{{"Alice", node}}))
+	req.Equal("name,n\nAlice,\"(:Person {age: 42, name: \"\"Alice\"\"})\"\n", out.String())
+
+	out.Reset()
+	req.Nil(writeRecords(out, formatJSON, []string{"name", "age"}, [][]interface{}{{"Alice", int64(42)}, {"Bob", nil}}))
+	req.Equal("{\"age\":42,\"name\":\"Alice\"}\n{\"age\":null,\"name\":\"Bob\"}\n", out.String())
+
+	out.Reset()
+	req.Nil(writeRecords(out, formatJSON, []string{"n", "born"}, [][]interface{}{{node, gotime.NewDate(1980, 1, 2)}}))
+	req.Equal("{\"born\":\"1980-01-02\",\"n\":{\"id\":0,\"labels\":[\"Person\"],\"properties\":{\"age\":42,\"name\":\"Alice\"}}}\n", out.String())
+
+	// the graph view is every node and relationship once, over all the records
+	alice := graph.Node{NodeIdentity: 1, Labels: []string{"Person"}}
+	london := graph.Node{NodeIdentity: 2, Labels: []string{"City"}}
+	livesIn := graph.Relationship{RelIdentity: 3, StartNodeIdentity: 1, EndNodeIdentity: 2, Type: "LIVES_IN"}
+	out.Reset()
+	req.Nil(writeRecords(out, formatGraph, []string{"a", "r", "c"}, [][]interface{}{{alice, livesIn, london}, {alice, nil, "x"}}))
+	req.JSONEq(`{
+		"nodes": [
+			{"id": 1, "labels": ["Person"], "properties": {}},
+			{"id": 2, "labels": ["City"], "properties": {}}
+		],
+		"relationships": [
+			{"id": 3, "type": "LIVES_IN", "startNode": 1, "endNode": 2, "properties": {}}
+		]
+	}`, out.String())
+}
+
+func TestFormatDateTime(t *testing.T) {
+	req := require.New(t)
+	london, err := time.LoadLocation("Europe/London")
+	req.Nil(err)
+	val := time.Date(2021, 7, 1, 9, 15, 0, 0, london)
+
+	req.Equal("2021-07-01T09:15:00+01:00[Europe/London]", formatValue(types.NewDateTime(val)))
+	req.Equal("2021-07-01T09:15:00+01:00", formatValue(types.NewDateTimeWithOffset(val)))
+}
+
+func TestRunUsage(t *testing.T) {
+	req := require.New(t)
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	req.Equal(exitUsage, run([]string{"--format", "xml"}, strings.NewReader(""), stdout, stderr))
+	req.Contains(stderr.String(), "unknown format")
+
+	req.Equal(exitUsage, run([]string{"--no-such-flag"}, strings.NewReader(""), stdout, stderr))
+	req.Equal(exitUsage, run([]string{"--file", "does-not-exist.cypher"}, strings.NewReader(""), stdout, stderr))
+}
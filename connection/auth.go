@@ -0,0 +1,108 @@
+package connection
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/mindstand/go-bolt/errors"
+	"github.com/mindstand/go-bolt/structures/messages"
+	"sync"
+	"time"
+)
+
+// tokenExpiredCode is the failure neo4j sends once a connection's credentials have expired
+const tokenExpiredCode = "Neo.ClientError.Security.TokenExpired"
+
+// AuthProvider supplies the token new connections authenticate with
+type AuthProvider interface {
+	// AuthToken returns the token and when it expires, the zero time never expires.
+	// Bolt 4 can't re-authenticate a connection so pools replace connections once their token expires
+	AuthToken() (map[string]interface{}, time.Time, error)
+}
+
+// staticAuth always hands out the same token
+type staticAuth struct {
+	token map[string]interface{}
+}
+
+func (s staticAuth) AuthToken() (map[string]interface{}, time.Time, error) {
+	return s.token, time.Time{}, nil
+}
+
+// BasicAuth authenticates with a username and password, empty realm uses the server's default
+func BasicAuth(username, password, realm string) AuthProvider {
+	return staticAuth{token: messages.BuildAuthTokenBasicWithRealm(username, password, realm)}
+}
+
+// BearerAuth authenticates with a single sign on token
+func BearerAuth(token string) AuthProvider {
+	return staticAuth{token: messages.BuildAuthTokenBearer(token)}
+}
+
+// KerberosAuth authenticates with a base64 encoded kerberos ticket
+func KerberosAuth(base64EncodedTicket string) AuthProvider {
+	return staticAuth{token: messages.BuildAuthTokenKerberos(base64EncodedTicket)}
+}
+
+// CustomAuth authenticates against a custom auth plugin
+func CustomAuth(principal, credentials, realm, scheme string, parameters map[string]interface{}) AuthProvider {
+	return staticAuth{token: messages.BuildAuthTokenCustom(principal, credentials, realm, scheme, parameters)}
+}
+
+// NoAuth is for servers with auth disabled
+func NoAuth() AuthProvider {
+	return staticAuth{token: messages.BuildAuthTokenNone()}
+}
+
+// rotatingAuth caches the token from refresh until it expires
+type rotatingAuth struct {
+	refresh func() (map[string]interface{}, time.Time, error)
+
+	mutex   sync.Mutex
+	token   map[string]interface{}
+	expires time.Time
+}
+
+// RotatingAuth calls refresh for the first token and again whenever the last one has expired.
+// refresh returns the token and when it expires, the zero time never expires
+func RotatingAuth(refresh func() (map[string]interface{}, time.Time, error)) AuthProvider {
+	return &rotatingAuth{refresh: refresh}
+}
+
+func (r *rotatingAuth) AuthToken() (map[string]interface{}, time.Time, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.token != nil && (r.expires.IsZero() || time.Now().Before(r.expires)) {
+		return r.token, r.expires, nil
+	}
+
+	if r.refresh == nil {
+		return nil, time.Time{}, errors.New("rotating auth has no refresh function")
+	}
+
+	token, expires, err := r.refresh()
+	if err != nil {
+		return nil, time.Time{}, errors.Wrap(err, "failed to refresh auth token")
+	}
+
+	if len(token) == 0 {
+		return nil, time.Time{}, errors.New("refreshed auth token can not be empty")
+	}
+
+	r.token = token
+	r.expires = expires
+	return token, expires, nil
+}
+
+// AuthTokenKey identifies an auth token without holding on to its secrets, pools use it to keep
+// connections for different credentials apart. nil and empty tokens have an empty key
+func AuthTokenKey(token map[string]interface{}) string {
+	if len(token) == 0 {
+		return ""
+	}
+
+	// fmt prints maps in key order so equal tokens give equal keys
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%v", token)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,114 @@
+package connection
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mindstand/go-bolt/protocol/protocol_v4"
+	"github.com/mindstand/go-bolt/structures/messages"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthProviders(t *testing.T) {
+	cases := []struct {
+		name     string
+		provider AuthProvider
+		token    map[string]interface{}
+	}{
+		{"basic", BasicAuth("neo4j", "secret", ""), map[string]interface{}{
+			"scheme": "basic", "principal": "neo4j", "credentials": "secret",
+		}},
+		{"basic with realm", BasicAuth("neo4j", "secret", "ldap"), map[string]interface{}{
+			"scheme": "basic", "principal": "neo4j", "credentials": "secret", "realm": "ldap",
+		}},
+		{"bearer", BearerAuth("jwt"), map[string]interface{}{
+			"scheme": "bearer", "credentials": "jwt",
+		}},
+		{"kerberos", KerberosAuth("ticket"), map[string]interface{}{
+			"scheme": "kerberos", "principal": "", "credentials": "ticket",
+		}},
+		{"custom", CustomAuth("neo4j", "secret", "", "plugin", map[string]interface{}{"otp": "123"}), map[string]interface{}{
+			"scheme": "plugin", "principal": "neo4j", "credentials": "secret", "parameters": map[string]interface{}{"otp": "123"},
+		}},
+		{"none", NoAuth(), map[string]interface{}{
+			"scheme": "none",
+		}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := require.New(t)
+
+			token, expires, err := c.provider.AuthToken()
+			req.Nil(err)
+			req.Equal(c.token, token)
+			req.True(expires.IsZero())
+		})
+	}
+}
+
+func TestRotatingAuth(t *testing.T) {
+	req := require.New(t)
+
+	calls := 0
+	expires := time.Now().Add(time.Hour)
+	provider := RotatingAuth(func() (map[string]interface{}, time.Time, error) {
+		calls++
+		return messages.BuildAuthTokenBearer(fmt.Sprintf("jwt-%d", calls)), expires, nil
+	})
+
+	token, tokenExpires, err := provider.AuthToken()
+	req.Nil(err)
+	req.Equal("jwt-1", token[messages.CredentialsKey])
+	req.Equal(expires, tokenExpires)
+
+	// the token is reused until it expires
+	token, _, err = provider.AuthToken()
+	req.Nil(err)
+	req.Equal("jwt-1", token[messages.CredentialsKey])
+	req.Equal(1, calls)
+
+	expires = time.Now().Add(-time.Second)
+	provider.(*rotatingAuth).expires = expires
+	token, _, err = provider.AuthToken()
+	req.Nil(err)
+	req.Equal("jwt-2", token[messages.CredentialsKey])
+
+	// expired tokens are refreshed on every call
+	_, _, err = provider.AuthToken()
+	req.Nil(err)
+	req.Equal(3, calls)
+
+	failing := RotatingAuth(func() (map[string]interface{}, time.Time, error) {
+		return nil, time.Time{}, fmt.Errorf("identity provider down")
+	})
+	_, _, err = failing.AuthToken()
+	req.NotNil(err)
+
+	empty := RotatingAuth(func() (map[string]interface{}, time.Time, error) {
+		return nil, time.Time{}, nil
+	})
+	_, _, err = empty.AuthToken()
+	req.NotNil(err)
+}
+
+func TestCredentialsExpired(t *testing.T) {
+	req := require.New(t)
+	conn, server := newTestConnection(t, protocol_v4.ProtocolVersionBytes, streamResults)
+	req.True(conn.ValidateOpen())
+
+	conn.authExpires = time.Now().Add(-time.Second)
+	req.False(conn.ValidateOpen())
+	req.False(DefaultHealthCheck().Healthy(conn, time.Now(), 0))
+
+	conn.authExpires = time.Time{}
+	req.True(conn.ValidateOpen())
+
+	// neo4j can expire the credentials before the provider said they would
+	server.failOn = "expired"
+	server.failCode = tokenExpiredCode
+	_, _, err := conn.Query("expired", nil)
+	req.NotNil(err)
+	req.False(conn.ValidateOpen())
+}
@@ -0,0 +1,299 @@
+package connection
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/mindstand/go-bolt/encoding/packstream"
+	"github.com/mindstand/go-bolt/errors"
+	"github.com/mindstand/go-bolt/protocol"
+	"io"
+	"math"
+	"sync"
+	"time"
+)
+
+// directions of captured bytes
+const (
+	CaptureSent     = "sent"
+	CaptureReceived = "received"
+)
+
+// longest capture line, a single write can hold many chunks
+const maxCaptureLine = 1 << 30
+
+// CaptureEntry is one read or write on a captured connection
+type CaptureEntry struct {
+	Time time.Time `json:"time"`
+	// Connection is assigned when the connection opens, it tells the connections sharing a capture apart
+	Connection string `json:"connection"`
+	// Direction is CaptureSent or CaptureReceived
+	Direction string `json:"direction"`
+	Data      []byte `json:"data"`
+}
+
+// Capture records the bytes connections send and receive as json lines, for attaching to bug reports
+// and replaying with ReplayDialer. Credentials in HELLO messages are recorded too.
+// Connections sharing a capture can write to it at the same time
+type Capture struct {
+	mutex   sync.Mutex
+	encoder *json.Encoder
+	// first error writing the capture, connections carry on without recording
+	err error
+}
+
+// NewCapture returns a capture writing to w
+func NewCapture(w io.Writer) *Capture {
+	return &Capture{encoder: json.NewEncoder(w)}
+}
+
+// Err returns the first error writing the capture
+func (c *Capture) Err() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.err
+}
+
+func (c *Capture) record(connection, direction string, data []byte) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.err != nil {
+		return
+	}
+
+	c.err = c.encoder.Encode(CaptureEntry{
+		Time:       time.Now(),
+		Connection: connection,
+		Direction:  direction,
+		Data:       append([]byte{}, data...),
+	})
+}
+
+// newCaptureId names a captured connection
+func newCaptureId() string {
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(id)
+}
+
+// ReadCapture reads the entries of a capture
+func ReadCapture(r io.Reader) ([]CaptureEntry, error) {
+	var entries []CaptureEntry
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxCaptureLine)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var entry CaptureEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, errors.Wrap(err, "failed to read capture line [%v]", line)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read capture")
+	}
+
+	return entries, nil
+}
+
+// CapturedMessage is a message decoded from a capture
+type CapturedMessage struct {
+	// Time the last byte of the message was sent or received
+	Time       time.Time
+	Connection string
+	Direction  string
+	// Name is the message type, like RUN or RECORD. The handshake is HANDSHAKE and the server's answer VERSION
+	Name   string
+	Fields []interface{}
+}
+
+// captureStream is the bytes one connection sent or received so far that haven't made a whole message
+type captureStream struct {
+	pending []byte
+	// the handshake comes before any chunks
+	handshakeDone bool
+}
+
+// DecodeCapture decodes the messages in entries, picking the encoding each connection agreed in its handshake
+func DecodeCapture(entries []CaptureEntry) ([]CapturedMessage, error) {
+	type streamKey struct{ connection, direction string }
+	streams := map[streamKey]*captureStream{}
+	protocols := map[string]protocol.IBoltProtocol{}
+
+	var decoded []CapturedMessage
+	for _, entry := range entries {
+		key := streamKey{entry.Connection, entry.Direction}
+		stream, ok := streams[key]
+		if !ok {
+			stream = &captureStream{}
+			streams[key] = stream
+		}
+		stream.pending = append(stream.pending, entry.Data...)
+
+		message := func(name string, fields []interface{}) {
+			decoded = append(decoded, CapturedMessage{
+				Time:       entry.Time,
+				Connection: entry.Connection,
+				Direction:  entry.Direction,
+				Name:       name,
+				Fields:     fields,
+			})
+		}
+
+		if !stream.handshakeDone {
+			size := len(handShake)
+			if entry.Direction == CaptureReceived {
+				size = 4
+			}
+			if len(stream.pending) < size {
+				continue
+			}
+
+			data := stream.pending[:size]
+			stream.pending = stream.pending[size:]
+			stream.handshakeDone = true
+
+			if entry.Direction == CaptureSent {
+				message("HANDSHAKE", handshakeVersions(data))
+			} else {
+				boltProtocol, _, err := protocol.GetProtocol(data)
+				if err != nil {
+					return nil, errors.Wrap(err, "connection [%s] agreed on an unknown version", entry.Connection)
+				}
+				protocols[entry.Connection] = boltProtocol
+				message("VERSION", []interface{}{versionString(data)})
+			}
+		}
+
+		for {
+			payload, rest, ok := nextMessage(stream.pending)
+			if !ok {
+				break
+			}
+			stream.pending = rest
+
+			// bolt 4.1+ keep alives
+			if len(payload) == 0 {
+				message("NOOP", nil)
+				continue
+			}
+
+			boltProtocol, ok := protocols[entry.Connection]
+			if !ok {
+				return nil, errors.New("connection [%s] sent messages before agreeing on a version", entry.Connection)
+			}
+
+			name, fields, err := decodeCapturedMessage(boltProtocol, payload)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to decode a message connection [%s] %s", entry.Connection, entry.Direction)
+			}
+			message(name, fields)
+		}
+	}
+
+	return decoded, nil
+}
+
+// nextMessage dechunks the first whole message in data, returning it and the bytes after it
+func nextMessage(data []byte) ([]byte, []byte, bool) {
+	var payload []byte
+	for offset := 0; ; {
+		if len(data)-offset < 2 {
+			return nil, data, false
+		}
+
+		size := int(binary.BigEndian.Uint16(data[offset:]))
+		offset += 2
+		if size == 0 {
+			return payload, data[offset:], true
+		}
+
+		if len(data)-offset < size {
+			return nil, data, false
+		}
+		payload = append(payload, data[offset:offset+size]...)
+		offset += size
+	}
+}
+
+// decodeCapturedMessage decodes a dechunked message. The decoders only know the messages servers send,
+// so the message struct is decoded as a list of its fields
+func decodeCapturedMessage(boltProtocol protocol.IBoltProtocol, payload []byte) (string, []interface{}, error) {
+	// messages are tiny structs, a marker holding the field count then the signature
+	if len(payload) < 2 || payload[0]&0xf0 != 0xb0 {
+		return "", nil, fmt.Errorf("message starts with [%#x], not a struct marker", payload[0])
+	}
+
+	signature := payload[1]
+	name, ok := packstream.MessageName(signature)
+	if !ok {
+		name = fmt.Sprintf("UNKNOWN(%#x)", signature)
+	}
+
+	// swap the struct header for a tiny list header of the same size and rechunk
+	list := append([]byte{0x90 | payload[0]&0x0f}, payload[2:]...)
+	decoder := boltProtocol.NewDecoder(bytes.NewReader(chunk(list)))
+	defer decoder.Release()
+
+	fieldsInt, err := decoder.Decode()
+	if err != nil {
+		return "", nil, err
+	}
+
+	fields, ok := fieldsInt.([]interface{})
+	if !ok {
+		return "", nil, fmt.Errorf("expected the message fields to decode to a list, got [%T]", fieldsInt)
+	}
+
+	return name, fields, nil
+}
+
+// chunk splits a message into chunks and ends it
+func chunk(message []byte) []byte {
+	var chunked []byte
+	for len(message) > 0 {
+		size := len(message)
+		if size > math.MaxUint16 {
+			size = math.MaxUint16
+		}
+
+		chunked = append(chunked, byte(size>>8), byte(size))
+		chunked = append(chunked, message[:size]...)
+		message = message[size:]
+	}
+	return append(chunked, 0x00, 0x00)
+}
+
+// handshakeVersions lists the versions a client offered in its handshake
+func handshakeVersions(handshake []byte) []interface{} {
+	var versions []interface{}
+	for offset := len(magicPreamble); offset+4 <= len(handshake); offset += 4 {
+		version := handshake[offset : offset+4]
+		if bytes.Equal(version, noVersionSupported) {
+			continue
+		}
+		versions = append(versions, versionString(version))
+	}
+	return versions
+}
+
+// versionString writes handshake version bytes as major.minor, with the range 4.3+ servers accept
+func versionString(version []byte) string {
+	if version[1] != 0 {
+		return fmt.Sprintf("%d.%d-%d.%d", version[3], version[2], version[3], int(version[2])-int(version[1]))
+	}
+	return fmt.Sprintf("%d.%d", version[3], version[2])
+}
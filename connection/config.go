@@ -0,0 +1,30 @@
+package connection
+
+import (
+	"crypto/tls"
+	"github.com/mindstand/go-bolt/encoding"
+	"time"
+)
+
+// Config holds settings applied when a connection is opened
+type Config struct {
+	// KeepAlive is the tcp keep alive period. 0 uses the go default, negative disables keep alives
+	KeepAlive time.Duration
+	// Database is used when queries and transactions don't name one, empty uses neo4j's default
+	Database string
+	// AuthToken replaces the basic auth from the connection string when set
+	AuthToken map[string]interface{}
+	// AuthProvider supplies the token when AuthToken isn't set
+	AuthProvider AuthProvider
+	// TLSConfig turns on TLS with this config, replacing the cert files from the connection string
+	TLSConfig *tls.Config
+	// Dialer opens the network connection instead of a tcp dial, KeepAlive is left to the dialer
+	Dialer DialFunc
+	// Capture records the bytes the connection sends and receives, see ReplayDialer
+	Capture *Capture
+	// MinorVersions offers bolt 4.1 to 4.4 in the handshake in place of bolt v1, so neo4j 3.0 - 3.3 can't
+	// connect. Impersonation needs bolt 4.4
+	MinorVersions bool
+	// DecoderLimits bounds the messages read from the server, nil and limits left at zero keep encoding.DefaultLimits
+	DecoderLimits *encoding.Limits
+}
@@ -2,11 +2,13 @@ package connection
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"database/sql/driver"
 	"fmt"
 	"github.com/mindstand/go-bolt/bolt_mode"
+	"github.com/mindstand/go-bolt/encoding"
 	"github.com/mindstand/go-bolt/errors"
 	"github.com/mindstand/go-bolt/log"
 	"github.com/mindstand/go-bolt/protocol"
@@ -30,12 +32,17 @@ type readWrite struct {
 func (r *readWrite) Write(p []byte) (n int, err error) {
 	if err := r.connection.conn.SetWriteDeadline(time.Now().Add(r.connection.timeout)); err != nil {
 		//c.connErr = errors.Wrap(err, "An error occurred setting write deadline")
+		r.connection.broken = true
 		return 0, driver.ErrBadConn
 	}
 
 	n, err = r.connection.conn.Write(p)
+	if r.connection.capture != nil && n > 0 {
+		r.connection.capture.record(r.connection.captureId, CaptureSent, p[:n])
+	}
 
 	if err != nil {
+		r.connection.broken = true
 		err = driver.ErrBadConn
 	}
 	return n, err
@@ -43,12 +50,21 @@ func (r *readWrite) Write(p []byte) (n int, err error) {
 
 func (r *readWrite) Read(p []byte) (n int, err error) {
 	if err := r.connection.conn.SetReadDeadline(time.Now().Add(r.connection.timeout)); err != nil {
+		r.connection.broken = true
 		return 0, driver.ErrBadConn
 	}
 
 	n, err = r.connection.conn.Read(p)
-	if err != nil && err != io.EOF {
-		err = driver.ErrBadConn
+	if r.connection.capture != nil && n > 0 {
+		r.connection.capture.record(r.connection.captureId, CaptureReceived, p[:n])
+	}
+
+	if err != nil {
+		// the stream is out of sync after a failed read, the connection can't be reused
+		r.connection.broken = true
+		if err != io.EOF {
+			err = driver.ErrBadConn
+		}
 	}
 	return n, err
 }
@@ -69,37 +85,103 @@ type Connection struct {
 	caCertFile  string
 	keyFile     string
 	tlsNoVerify bool
+	// replaces the config built from the cert files when set
+	customTLS *tls.Config
+	// replaces the tcp dialer when set
+	dialer DialFunc
+	// carry bolt over websocket frames, upgrading on path
+	webSocket     bool
+	webSocketPath string
+	// records everything sent and received when set
+	capture   *Capture
+	captureId string
 
 	// connection config
 	accessMode bolt_mode.AccessMode
+	// database used when a query or transaction doesn't name one
+	database string
+	// user queries and transactions run as, needs bolt 4.4
+	impersonatedUser string
+	// offers bolt 4.1 - 4.4 in the handshake instead of bolt v1
+	minorVersions bool
+	// decoderLimits replaces the decoder's default limits when set
+	decoderLimits *encoding.Limits
+	// replaces basic auth from the connection string when set
+	authToken map[string]interface{}
+	// when authToken expires, zero never expires
+	authExpires time.Time
+	// set once neo4j reports the credentials have expired
+	authExpired bool
 
 	// handlers
-	readWrite   *readWrite
+	readWrite *readWrite
+	// decoder reads every message the server sends, it buffers ahead so there is one for the connection
+	decoder encoding.IDecoder
+	// encoder queues the messages sent to the server until the next read flushes them in one write
+	encoder     encoding.IEncoder
 	transaction ITransaction
 
 	// connection stuff
-	timeout   time.Duration
-	chunkSize uint16
-	conn      net.Conn
-	closed    bool
-	openQuery bool
-	mutex     sync.Mutex
+	timeout     time.Duration
+	keepAlive   time.Duration
+	chunkSize   uint16
+	fetchSize   int64
+	conn        net.Conn
+	closed      bool
+	broken      bool
+	openQuery   bool
+	openStreams map[int64]*boltResultStream
+	mutex       sync.Mutex
 
 	// for pool tracking
 	id string
 }
 
 func CreateBoltConn(connStr string) (IConnection, error) {
+	return CreateBoltConnWithConfig(connStr, Config{})
+}
+
+// CreateBoltConnWithConfig opens a connection, applying config on top of the connection string settings
+func CreateBoltConnWithConfig(connStr string, config Config) (IConnection, error) {
 	conn, err := newConnectionFromConnectionString(connStr)
 	if err != nil {
 		return nil, err
 	}
 
+	conn.keepAlive = config.KeepAlive
+	conn.database = config.Database
+	conn.authToken = config.AuthToken
+	conn.dialer = config.Dialer
+	conn.minorVersions = config.MinorVersions
+	conn.decoderLimits = config.DecoderLimits
+
+	if config.Capture != nil {
+		conn.capture = config.Capture
+		conn.captureId = newCaptureId()
+	}
+
+	if config.TLSConfig != nil {
+		conn.useTLS = true
+		conn.customTLS = config.TLSConfig
+	}
+
+	if conn.authToken == nil && config.AuthProvider != nil {
+		conn.authToken, conn.authExpires, err = config.AuthProvider.AuthToken()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get auth token")
+		}
+	}
+
 	err = conn.initialize()
 	if err != nil {
 		return nil, err
 	}
 
+	if conn.database != "" && !conn.boltProtocol.SupportsMultiDatabase() {
+		_ = conn.Close()
+		return nil, fmt.Errorf("bolt protocol version [%v] does not have multi database support, can not default to database [%s]", conn.protocolVersion, conn.database)
+	}
+
 	return conn, nil
 }
 
@@ -107,17 +189,24 @@ func newConnectionFromConnectionString(connStr string) (*Connection, error) {
 	_url, err := url.Parse(connStr)
 	if err != nil {
 		return nil, fmt.Errorf("an error occurred parsing bolt URL, %w", err)
-	} else if strings.ToLower(_url.Scheme) != "bolt" && strings.ToLower(_url.Scheme) != "bolt+routing" {
-		return nil, fmt.Errorf("unsupported connection string scheme: %s. Driver only supports 'bolt' and 'bolt+routing' scheme", _url.Scheme)
+	}
+
+	scheme, err := ParseScheme(_url.Scheme)
+	if err != nil {
+		return nil, err
 	}
 
 	connection := Connection{
-		timeout:   time.Second * time.Duration(60),
-		chunkSize: math.MaxUint16,
-		mutex:     sync.Mutex{},
+		timeout:     time.Second * time.Duration(60),
+		chunkSize:   math.MaxUint16,
+		fetchSize:   DefaultFetchSize,
+		openStreams: map[int64]*boltResultStream{},
+		mutex:       sync.Mutex{},
 	}
 
 	connection.hostPort = _url.Host
+	connection.webSocket = scheme.WebSocket
+	connection.webSocketPath = _url.Path
 
 	if _url.User != nil {
 		connection.user = _url.User.Username()
@@ -139,14 +228,14 @@ func newConnectionFromConnectionString(connStr string) (*Connection, error) {
 	}
 
 	useTLS := _url.Query().Get("tls")
-	connection.useTLS = strings.HasPrefix(strings.ToLower(useTLS), "t") || useTLS == "1"
+	connection.useTLS = scheme.TLS || strings.HasPrefix(strings.ToLower(useTLS), "t") || useTLS == "1"
 
 	if connection.useTLS {
 		connection.certFile = _url.Query().Get("tls_cert_file")
 		connection.keyFile = _url.Query().Get("tls_key_file")
 		connection.caCertFile = _url.Query().Get("tls_ca_cert_file")
 		noVerify := _url.Query().Get("tls_no_verify")
-		connection.tlsNoVerify = strings.HasPrefix(strings.ToLower(noVerify), "t") || noVerify == "1"
+		connection.tlsNoVerify = scheme.SkipVerify || strings.HasPrefix(strings.ToLower(noVerify), "t") || noVerify == "1"
 	}
 
 	return &connection, nil
@@ -160,6 +249,37 @@ func (c *Connection) SetConnectionId(id string) {
 	c.id = id
 }
 
+// Sets the database used when queries and transactions don't name one, empty uses neo4j's default
+func (c *Connection) SetDatabase(db string) {
+	c.database = db
+}
+
+func (c *Connection) GetDatabase() string {
+	return c.database
+}
+
+// Sets the user queries and transactions run as, empty runs as the authenticated user
+func (c *Connection) SetImpersonatedUser(user string) {
+	c.impersonatedUser = user
+}
+
+func (c *Connection) GetImpersonatedUser() string {
+	return c.impersonatedUser
+}
+
+// checkSession makes sure the protocol can run outside the default database or as another user
+func (c *Connection) checkSession(db string) error {
+	if !c.boltProtocol.SupportsMultiDatabase() && db != "" {
+		return fmt.Errorf("bolt protocol version [%v] does not have multi database support", c.protocolVersion)
+	}
+
+	if !c.boltProtocol.SupportsImpersonation() && c.impersonatedUser != "" {
+		return fmt.Errorf("bolt protocol version [%v] does not support impersonation, requires 4.4 which is only offered with Config.MinorVersions", c.protocolVersion)
+	}
+
+	return nil
+}
+
 func (c *Connection) GetProtocolVersionNumber() int {
 	return c.protocolVersion
 }
@@ -172,33 +292,109 @@ func (c *Connection) GetProtocolVersionBytes() []byte {
 func (c *Connection) createConnection() error {
 	var conn net.Conn
 	var err error
+
+	if c.dialer != nil {
+		return c.createConnectionWithDialer()
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   c.timeout,
+		KeepAlive: c.keepAlive,
+	}
+
 	if c.useTLS {
 		config, err := c.tlsConfig()
 		if err != nil {
 			return err
 		}
 
-		conn, err = tls.Dial("tcp", c.hostPort, config)
+		conn, err = tls.DialWithDialer(dialer, "tcp", c.hostPort, config)
 		if err != nil {
 			return err
 		}
 	} else {
-		conn, err = net.DialTimeout("tcp", c.hostPort, c.timeout)
+		conn, err = dialer.Dial("tcp", c.hostPort)
 		if err != nil {
 			return err
 
 		}
 	}
 
+	return c.useConnection(conn)
+}
+
+// useConnection switches over to conn once it is open, upgrading to a websocket if the scheme asked for one
+func (c *Connection) useConnection(conn net.Conn) error {
+	if c.webSocket {
+		wsConn, err := upgradeWebSocket(conn, c.hostPort, c.webSocketPath, c.timeout)
+		if err != nil {
+			_ = conn.Close()
+			return err
+		}
+		conn = wsConn
+	}
+
 	c.conn = conn
 	c.closed = false
+	c.broken = false
 	return nil
 }
 
+// createConnectionWithDialer opens the connection with the configured dialer, running the tls
+// handshake on top of it. The timeout covers both the dial and the handshake
+func (c *Connection) createConnectionWithDialer() error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	conn, err := c.dialer(ctx, "tcp", c.hostPort)
+	if err != nil {
+		return err
+	}
+
+	if conn == nil {
+		return errors.New("dialer returned a nil connection")
+	}
+
+	if c.useTLS {
+		config, err := c.tlsConfig()
+		if err != nil {
+			_ = conn.Close()
+			return err
+		}
+
+		// tls.DialWithDialer fills in the server name, do the same here
+		if config.ServerName == "" && !config.InsecureSkipVerify {
+			host, _, err := net.SplitHostPort(c.hostPort)
+			if err != nil {
+				host = c.hostPort
+			}
+			config.ServerName = host
+		}
+
+		tlsConn := tls.Client(conn, config)
+		deadline, _ := ctx.Deadline()
+		_ = tlsConn.SetDeadline(deadline)
+		if err := tlsConn.Handshake(); err != nil {
+			_ = conn.Close()
+			return err
+		}
+		_ = tlsConn.SetDeadline(time.Time{})
+		conn = tlsConn
+	}
+
+	return c.useConnection(conn)
+}
+
+// tlsConfig builds the config to dial with. TLS 1.3 is negotiated when neo4j supports it,
+// the server name is filled in from the host by tls.DialWithDialer
 func (c *Connection) tlsConfig() (*tls.Config, error) {
+	if c.customTLS != nil {
+		// dialing can fill in the server name so the caller's config is left alone
+		return c.customTLS.Clone(), nil
+	}
+
 	config := &tls.Config{
-		MinVersion: tls.VersionTLS10,
-		MaxVersion: tls.VersionTLS12,
+		MinVersion: tls.VersionTLS12,
 	}
 
 	if c.caCertFile != "" {
@@ -234,7 +430,12 @@ func (c *Connection) tlsConfig() (*tls.Config, error) {
 }
 
 func (c *Connection) handshake() ([]byte, error) {
-	numWritten, err := c.readWrite.Write(handShake)
+	handshake := handShake
+	if c.minorVersions {
+		handshake = minorHandShake
+	}
+
+	numWritten, err := c.readWrite.Write(handshake)
 	if err != nil {
 		return nil, err
 	} else if numWritten != 20 {
@@ -279,8 +480,27 @@ func (c *Connection) initialize() error {
 	c.protocolVersion = version
 	c.protocolVersionBytes = versionBytes
 	c.boltProtocol = boltProtocol
+	c.decoder = boltProtocol.NewDecoder(c.readWrite)
+	if c.decoderLimits != nil {
+		c.decoder.SetLimits(*c.decoderLimits)
+	}
+	c.encoder = boltProtocol.NewEncoder(c.readWrite, c.chunkSize)
+
+	return c.sendInit(c.boltProtocol.GetInitMessage(ClientID, c.buildAuthToken()))
+}
+
+// buildAuthToken copies the configured token since the init message adds to it,
+// falling back to basic auth from the connection string
+func (c *Connection) buildAuthToken() map[string]interface{} {
+	if c.authToken == nil {
+		return messages.BuildAuthTokenBasic(c.user, c.password)
+	}
 
-	return c.sendInit(c.boltProtocol.GetInitMessage(ClientID, messages.BuildAuthTokenBasic(c.user, c.password)))
+	token := make(map[string]interface{}, len(c.authToken)+1)
+	for key, val := range c.authToken {
+		token[key] = val
+	}
+	return token
 }
 
 func (c *Connection) sendInit(message structures.Structure) error {
@@ -304,26 +524,32 @@ func (c *Connection) sendInit(message structures.Structure) error {
 	}
 }
 
+// ValidateOpen checks the connection hasn't been closed, hit an io error or outlived its credentials.
+// It doesn't touch the network, use Ping to check neo4j is still answering
 func (c *Connection) ValidateOpen() bool {
-	//log.Tracef("checking validate open")
-	if c.closed {
-		return false
-	}
+	return !c.closed && !c.broken && c.conn != nil && !c.credentialsExpired()
+}
 
-	if c.conn == nil {
-		return false
-	}
+// credentialsExpired checks if the token the connection authenticated with has expired,
+// bolt 4 can't re-authenticate so the connection has to be replaced
+func (c *Connection) credentialsExpired() bool {
+	return c.authExpired || (!c.authExpires.IsZero() && !time.Now().Before(c.authExpires))
+}
 
-	one := make([]byte, 1)
-	err := c.conn.SetReadDeadline(time.Now())
-	if err != nil {
-		return false
+// Ping round trips a RESET to make sure neo4j is still answering on the connection
+func (c *Connection) Ping() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if !c.ValidateOpen() {
+		return errors.Wrap(errors.ErrClosed, "can not ping closed connection")
 	}
-	if _, err := c.conn.Read(one); err == io.EOF {
-		return false
-	} else {
-		return true
+
+	if c.transaction != nil {
+		return errors.New("can not ping connection with an open transaction")
 	}
+
+	return c.reset()
 }
 
 // Sets the size of the chunks to write to the stream
@@ -331,6 +557,15 @@ func (c *Connection) SetChunkSize(chunkSize uint16) {
 	c.chunkSize = chunkSize
 }
 
+// Sets the number of records result streams pull at a time, -1 pulls everything. Servers reject a PULL of
+// 0 or less than -1, so those pull everything too
+func (c *Connection) SetFetchSize(fetchSize int64) {
+	if fetchSize < 1 {
+		fetchSize = -1
+	}
+	c.fetchSize = fetchSize
+}
+
 // Sets the timeout for reading and writing to the stream
 func (c *Connection) SetTimeout(timeout time.Duration) {
 	c.timeout = timeout
@@ -341,8 +576,12 @@ func (c *Connection) Exec(query string, params QueryParams) (IResult, error) {
 }
 
 func (c *Connection) ExecWithDb(query string, params QueryParams, db string) (IResult, error) {
-	if !c.boltProtocol.SupportsMultiDatabase() && db != "" {
-		return nil, fmt.Errorf("bolt protocol version [%v] does not have multi database support", c.protocolVersion)
+	if db == "" {
+		db = c.database
+	}
+
+	if err := c.checkSession(db); err != nil {
+		return nil, err
 	}
 
 	_, metadata, err := c.runQuery(query, params, db, false)
@@ -358,8 +597,12 @@ func (c *Connection) Query(query string, params QueryParams) ([][]interface{}, I
 }
 
 func (c *Connection) QueryWithDb(query string, params QueryParams, db string) ([][]interface{}, IResult, error) {
-	if !c.boltProtocol.SupportsMultiDatabase() && db != "" {
-		return nil, nil, fmt.Errorf("bolt protocol version [%v] does not have multi database support", c.protocolVersion)
+	if db == "" {
+		db = c.database
+	}
+
+	if err := c.checkSession(db); err != nil {
+		return nil, nil, err
 	}
 
 	rows, metadata, err := c.runQuery(query, params, db, false)
@@ -382,7 +625,7 @@ func (c *Connection) runQuery(query string, params QueryParams, dbName string, i
 	}
 
 	log.Tracef("running query")
-	err := c.sendMessage(c.boltProtocol.GetRunMessage(query, params, dbName, c.accessMode, !inTx))
+	err := c.sendMessage(c.boltProtocol.GetRunMessage(query, params, dbName, c.impersonatedUser, c.accessMode, !inTx))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -400,7 +643,7 @@ func (c *Connection) runQuery(query string, params QueryParams, dbName string, i
 
 	log.Tracef("pull all response [%#v]", resp)
 
-	_, ok := resp.(messages.SuccessMessage)
+	runSuccess, ok := resp.(messages.SuccessMessage)
 	if !ok {
 		return nil, nil, fmt.Errorf("unexpected response of type [%T], should be [messages.SuccessMessage]", resp)
 	}
@@ -415,7 +658,7 @@ func (c *Connection) runQuery(query string, params QueryParams, dbName string, i
 		switch resp := _resp.(type) {
 		case messages.SuccessMessage:
 			log.Tracef("Got success message: %#v", resp)
-			return output, resp.Metadata, nil
+			return output, mergeRunMetadata(runSuccess.Metadata, resp.Metadata), nil
 		case messages.RecordMessage:
 			log.Tracef("Got record message: %#v", resp)
 			output = append(output, resp.Fields)
@@ -430,20 +673,39 @@ func (c *Connection) Close() error {
 		return errors.ErrClosed
 	}
 
+	if c.conn == nil {
+		return errors.New("can not close nil transaction")
+	}
+
+	// nothing is read after closing, the decoder's buffers can go to the next connection
+	if c.decoder != nil {
+		defer c.decoder.Release()
+	}
+	if c.encoder != nil {
+		defer c.encoder.Release()
+	}
+
+	// a broken connection can't talk to neo4j anymore, just drop the socket
+	if c.broken {
+		c.closed = true
+		return c.conn.Close()
+	}
+
 	if c.transaction != nil {
 		err := c.transaction.Rollback()
 		if err != nil {
+			c.closed = true
+			_ = c.conn.Close()
 			return err
 		}
 	}
 
-	if c.conn == nil {
-		return errors.New("can not close nil transaction")
-	}
-
 	if msg, ok := c.boltProtocol.GetCloseMessage(); ok {
-		err := c.sendMessage(msg)
+		err := c.encoder.Encode(msg)
 		if err != nil {
+			// the encoder and decoder are released, the connection can't be used again
+			c.closed = true
+			_ = c.conn.Close()
 			return err
 		}
 
@@ -476,7 +738,15 @@ func (c *Connection) BeginWithDatabase(db string) (ITransaction, error) {
 		return nil, errors.New("can not open transaction on closed connection")
 	}
 
-	msg := c.boltProtocol.GetTxBeginMessage(db, c.accessMode)
+	if db == "" {
+		db = c.database
+	}
+
+	if err := c.checkSession(db); err != nil {
+		return nil, err
+	}
+
+	msg := c.boltProtocol.GetTxBeginMessage(db, c.impersonatedUser, c.accessMode)
 
 	_, isBeginMsg := msg.(messages.BeginMessage)
 
@@ -534,7 +804,9 @@ func (c *Connection) sendMessage(message structures.Structure) error {
 	if message == nil {
 		return errors.New("message can not be nil")
 	}
-	return c.boltProtocol.NewEncoder(c.readWrite, c.chunkSize).Encode(message)
+
+	// sent with the next read, so the messages of a pipeline go out together
+	return c.encoder.Buffer(message)
 }
 
 func (c *Connection) sendMessageConsume(message structures.Structure) (interface{}, error) {
@@ -549,7 +821,11 @@ func (c *Connection) sendMessageConsume(message structures.Structure) (interface
 func (c *Connection) consume() (interface{}, error) {
 	log.Trace("Consuming response from bolt stream")
 
-	respInt, err := c.boltProtocol.NewDecoder(c.readWrite).Decode()
+	if err := c.encoder.Flush(); err != nil {
+		return nil, errors.Wrap(err, "An error occurred sending messages")
+	}
+
+	respInt, err := c.decoder.Decode()
 	if err != nil {
 		return respInt, err
 	}
@@ -560,6 +836,9 @@ func (c *Connection) consume() (interface{}, error) {
 
 	if failure, isFail := respInt.(messages.FailureMessage); isFail {
 		log.Errorf("Got failure message: %#v", failure)
+		if failure.GetCode() == tokenExpiredCode {
+			c.authExpired = true
+		}
 		err := c.reset()
 		if err != nil {
 			return nil, errors.Wrap(failure, err.Error())
@@ -573,14 +852,23 @@ func (c *Connection) consume() (interface{}, error) {
 func (c *Connection) reset() error {
 	log.Trace("Resetting session")
 
+	// reset tears down any open results on the server
+	c.dropOpenStreams()
+
+	// and the open transaction, so a new one can begin
+	if tx, ok := c.transaction.(*boltTransaction); ok {
+		tx.closed = true
+	}
+	c.transaction = nil
+
 	reset := messages.NewResetMessage()
-	err := c.boltProtocol.NewEncoder(c.readWrite, c.chunkSize).Encode(reset)
+	err := c.encoder.Encode(reset)
 	if err != nil {
 		return errors.Wrap(err, "An error occurred encoding reset message")
 	}
 
 	for {
-		respInt, err := c.boltProtocol.NewDecoder(c.readWrite).Decode()
+		respInt, err := c.decoder.Decode()
 		if err != nil {
 			return errors.Wrap(err, "An error occurred decoding reset message response")
 		}
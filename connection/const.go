@@ -1,14 +1,28 @@
 package connection
 
 var (
-	magicPreamble     = []byte{0x60, 0x60, 0xb0, 0x17}
+	magicPreamble = []byte{0x60, 0x60, 0xb0, 0x17}
+
+	// the handshake only has room for four versions, by default they are bolt 4.0 down to 1 so every
+	// server from neo4j 3.0 can connect
 	supportedVersions = []byte{
 		0x00, 0x00, 0x00, 0x04, // bolt v4
 		0x00, 0x00, 0x00, 0x03, // bolt v3
 		0x00, 0x00, 0x00, 0x02, // bolt v2
 		0x00, 0x00, 0x00, 0x01, // bolt v1
 	}
+
+	// minorVersions trades bolt v1 (neo4j 3.0 - 3.3) for 4.4 down to 4.1, see Config.MinorVersions.
+	// Servers from 4.3 read the second byte as a range, older 4.x servers fall through to 4.0
+	minorVersions = []byte{
+		0x00, 0x03, 0x04, 0x04, // bolt v4.4 - v4.1
+		0x00, 0x00, 0x00, 0x04, // bolt v4
+		0x00, 0x00, 0x00, 0x03, // bolt v3
+		0x00, 0x00, 0x00, 0x02, // bolt v2
+	}
+
 	handShake          = append(magicPreamble, supportedVersions...)
+	minorHandShake     = append(append([]byte{}, magicPreamble...), minorVersions...)
 	noVersionSupported = []byte{0x00, 0x00, 0x00, 0x00}
 	// Version is the current version of this driver
 	Version = "0.1"
@@ -0,0 +1,159 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+	"github.com/mindstand/go-bolt/errors"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// DialFunc opens the network connection to neo4j. addr is the host and port from the connection string,
+// dialers that go through a proxy or a unix socket are free to ignore it
+type DialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+const (
+	socks5Version      = 0x05
+	socks5NoAuth       = 0x00
+	socks5UserPass     = 0x02
+	socks5NoAcceptable = 0xff
+	socks5CmdConnect   = 0x01
+	socks5IPv4         = 0x01
+	socks5Domain       = 0x03
+	socks5IPv6         = 0x04
+	socks5Succeeded    = 0x00
+)
+
+// SOCKS5Dialer connects through the SOCKS5 proxy at proxyAddr. Empty username skips authentication,
+// the host name is resolved by the proxy
+func SOCKS5Dialer(proxyAddr, username, password string) DialFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, network, proxyAddr)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to reach socks5 proxy [%s]", proxyAddr)
+		}
+
+		// the handshake is bounded by the dial's deadline
+		if deadline, ok := ctx.Deadline(); ok {
+			_ = conn.SetDeadline(deadline)
+		}
+
+		err = socks5Connect(conn, addr, username, password)
+		if err != nil {
+			_ = conn.Close()
+			return nil, errors.Wrap(err, "socks5 proxy [%s] failed to connect to [%s]", proxyAddr, addr)
+		}
+
+		_ = conn.SetDeadline(time.Time{})
+		return conn, nil
+	}
+}
+
+// socks5Connect runs the greeting, optional username/password auth (rfc 1929) and CONNECT on conn
+func socks5Connect(conn net.Conn, addr, username, password string) error {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return fmt.Errorf("invalid port [%s]", portStr)
+	}
+
+	if len(host) > 255 || len(username) > 255 || len(password) > 255 {
+		return errors.New("host, username and password must be at most 255 bytes")
+	}
+
+	method := byte(socks5NoAuth)
+	if username != "" {
+		method = socks5UserPass
+	}
+
+	if _, err = conn.Write([]byte{socks5Version, 1, method}); err != nil {
+		return err
+	}
+
+	reply := make([]byte, 2)
+	if _, err = io.ReadFull(conn, reply); err != nil {
+		return err
+	}
+
+	if reply[0] != socks5Version {
+		return fmt.Errorf("unexpected socks version [%v]", reply[0])
+	}
+
+	if reply[1] == socks5NoAcceptable || reply[1] != method {
+		return errors.New("proxy did not accept the authentication method")
+	}
+
+	if method == socks5UserPass {
+		auth := []byte{0x01, byte(len(username))}
+		auth = append(auth, username...)
+		auth = append(auth, byte(len(password)))
+		auth = append(auth, password...)
+		if _, err = conn.Write(auth); err != nil {
+			return err
+		}
+
+		if _, err = io.ReadFull(conn, reply); err != nil {
+			return err
+		}
+
+		if reply[1] != socks5Succeeded {
+			return errors.New("proxy rejected the username and password")
+		}
+	}
+
+	request := []byte{socks5Version, socks5CmdConnect, 0x00}
+	if ip := net.ParseIP(host); ip != nil {
+		if ip4 := ip.To4(); ip4 != nil {
+			request = append(request, socks5IPv4)
+			request = append(request, ip4...)
+		} else {
+			request = append(request, socks5IPv6)
+			request = append(request, ip.To16()...)
+		}
+	} else {
+		request = append(request, socks5Domain, byte(len(host)))
+		request = append(request, host...)
+	}
+	request = append(request, byte(port>>8), byte(port))
+
+	if _, err = conn.Write(request); err != nil {
+		return err
+	}
+
+	// version, status, reserved and the bound address type
+	header := make([]byte, 4)
+	if _, err = io.ReadFull(conn, header); err != nil {
+		return err
+	}
+
+	if header[1] != socks5Succeeded {
+		return fmt.Errorf("connect failed with status [%v]", header[1])
+	}
+
+	// the bound address isn't needed but has to be read off the stream
+	var boundLen int
+	switch header[3] {
+	case socks5IPv4:
+		boundLen = net.IPv4len
+	case socks5IPv6:
+		boundLen = net.IPv6len
+	case socks5Domain:
+		length := make([]byte, 1)
+		if _, err = io.ReadFull(conn, length); err != nil {
+			return err
+		}
+		boundLen = int(length[0])
+	default:
+		return fmt.Errorf("unexpected bound address type [%v]", header[3])
+	}
+
+	_, err = io.ReadFull(conn, make([]byte, boundLen+2))
+	return err
+}
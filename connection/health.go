@@ -0,0 +1,53 @@
+package connection
+
+import (
+	"github.com/mindstand/go-bolt/log"
+	"time"
+)
+
+const (
+	// DefaultMaxConnLifetime is how long pooled connections are used before being closed
+	DefaultMaxConnLifetime = time.Hour
+	// DefaultIdleTestThreshold is how long a pooled connection can sit idle before it is pinged on borrow
+	DefaultIdleTestThreshold = 30 * time.Second
+)
+
+// HealthCheck decides if a pooled connection is still fit to be handed out
+type HealthCheck struct {
+	// MaxLifetime is how long a connection is used before it is closed, 0 keeps connections forever
+	MaxLifetime time.Duration
+	// IdleTestThreshold is how long a connection can sit idle before it is pinged on borrow.
+	// 0 pings on every borrow, negative never pings
+	IdleTestThreshold time.Duration
+}
+
+// DefaultHealthCheck returns the health check pools use unless configured otherwise
+func DefaultHealthCheck() HealthCheck {
+	return HealthCheck{
+		MaxLifetime:       DefaultMaxConnLifetime,
+		IdleTestThreshold: DefaultIdleTestThreshold,
+	}
+}
+
+// Expired determines if a connection created at created has outlived MaxLifetime
+func (h HealthCheck) Expired(created time.Time) bool {
+	return h.MaxLifetime > 0 && time.Since(created) >= h.MaxLifetime
+}
+
+// Healthy determines if a connection can be borrowed. Connections idle past the threshold are pinged
+func (h HealthCheck) Healthy(conn IConnection, created time.Time, idle time.Duration) bool {
+	if conn == nil || !conn.ValidateOpen() || h.Expired(created) {
+		return false
+	}
+
+	if h.IdleTestThreshold < 0 || idle < h.IdleTestThreshold {
+		return true
+	}
+
+	if err := conn.Ping(); err != nil {
+		log.Errorf("connection [%s] failed liveness check, %s", conn.GetConnectionId(), err.Error())
+		return false
+	}
+
+	return true
+}
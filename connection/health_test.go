@@ -0,0 +1,79 @@
+package connection
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mindstand/go-bolt/protocol/protocol_v3"
+	"github.com/mindstand/go-bolt/structures/messages"
+	"github.com/stretchr/testify/require"
+)
+
+func countSignature(server *testServer, signature byte) int {
+	count := 0
+	for _, sig := range server.signatures() {
+		if sig == signature {
+			count++
+		}
+	}
+	return count
+}
+
+func TestPing(t *testing.T) {
+	req := require.New(t)
+	conn, server := newTestConnection(t, protocol_v3.ProtocolVersionBytes, streamResults)
+
+	req.True(conn.ValidateOpen())
+	req.Nil(conn.Ping())
+	req.Equal(1, countSignature(server, messages.ResetMessageSignature))
+
+	// the stream is still in sync after the ping
+	rows, _, err := conn.Query("second", nil)
+	req.Nil(err)
+	req.Len(rows, 2)
+
+	tx, err := conn.Begin()
+	req.Nil(err)
+	req.NotNil(conn.Ping())
+	req.Nil(tx.Rollback())
+
+	// a dead server breaks the connection
+	req.Nil(server.conn.Close())
+	req.NotNil(conn.Ping())
+	req.False(conn.ValidateOpen())
+	req.Nil(conn.Close())
+	req.NotNil(conn.Ping())
+}
+
+func TestHealthCheck(t *testing.T) {
+	req := require.New(t)
+	conn, server := newTestConnection(t, protocol_v3.ProtocolVersionBytes, streamResults)
+
+	check := HealthCheck{
+		MaxLifetime:       time.Hour,
+		IdleTestThreshold: time.Minute,
+	}
+
+	req.False(check.Expired(time.Now()))
+	req.True(check.Expired(time.Now().Add(-2 * time.Hour)))
+	req.False(HealthCheck{}.Expired(time.Now().Add(-24 * time.Hour)))
+
+	// recently used connections aren't pinged
+	req.True(check.Healthy(conn, time.Now(), time.Second))
+	req.Equal(0, countSignature(server, messages.ResetMessageSignature))
+
+	req.True(check.Healthy(conn, time.Now(), 2*time.Minute))
+	req.Equal(1, countSignature(server, messages.ResetMessageSignature))
+
+	req.False(check.Healthy(conn, time.Now().Add(-2*time.Hour), 0))
+
+	// negative thresholds never ping
+	check.IdleTestThreshold = -1
+	req.True(check.Healthy(conn, time.Now(), 24*time.Hour))
+	req.Equal(1, countSignature(server, messages.ResetMessageSignature))
+
+	req.Nil(server.conn.Close())
+	check.IdleTestThreshold = 0
+	req.False(check.Healthy(conn, time.Now(), 0))
+	req.False(check.Healthy(nil, time.Now(), 0))
+}
@@ -29,10 +29,30 @@ type IQuery interface {
 	QueryWithDb(query string, params QueryParams, db string) ([][]interface{}, IResult, error)
 }
 
+// IResultStream is an open result cursor inside an explicit transaction.
+// Records are pulled from neo4j in batches as Next is called
+type IResultStream interface {
+	// Next returns the next record. ok is false once the stream is exhausted
+	Next() (record []interface{}, ok bool, err error)
+	// All pulls every remaining record and returns them with the result summary
+	All() ([][]interface{}, IResult, error)
+	// Discard throws away any remaining records and returns the result summary
+	Discard() (IResult, error)
+	// Fields returns the column names of the stream
+	Fields() []string
+	// QueryId returns the id neo4j assigned to the stream, or messages.AbsentQueryId if the protocol has none
+	QueryId() int64
+	// IsOpen determines if records may still be read from the stream
+	IsOpen() bool
+}
+
 // ITransaction controls a transaction
 type ITransaction interface {
 	// Query
 	IQuery
+	// QueryStream runs a query and returns a cursor over its records without pulling them.
+	// On bolt v4 several streams can be open at once and read in any order
+	QueryStream(query string, params QueryParams) (IResultStream, error)
 	// Commit commits the transaction
 	Commit() error
 	// Rollback rolls back the transaction
@@ -59,17 +79,29 @@ type IConnection interface {
 	GetProtocolVersionNumber() int
 	GetProtocolVersionBytes() []byte
 
-	// returns true if open, returns false if not
+	// returns true if open, returns false if not or once the connection's credentials have expired
 	ValidateOpen() bool
+	// Ping checks neo4j still answers on the connection with a RESET round trip
+	Ping() error
 
 	MakeIdle() error
 
 	Begin() (ITransaction, error)
 	BeginWithDatabase(db string) (ITransaction, error)
+	// SetDatabase sets the database used when queries and transactions don't name one.
+	// Empty uses neo4j's default database
+	SetDatabase(db string)
+	GetDatabase() string
+	// SetImpersonatedUser runs queries and transactions as user, requires bolt 4.4.
+	// Empty runs as the authenticated user
+	SetImpersonatedUser(user string)
+	GetImpersonatedUser() string
 	// SetTimeout sets the read/write timeouts for the
 	// connection to Neo4j
 	SetTimeout(time.Duration)
 	SetChunkSize(uint16)
+	// SetFetchSize sets how many records result streams pull at a time, less than 1 pulls everything
+	SetFetchSize(int64)
 
 	// connection id's are for the routing driver to keep track of connections
 	GetConnectionId() string
@@ -0,0 +1,121 @@
+package connection
+
+import (
+	"context"
+	"github.com/mindstand/go-bolt/errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// replayAddr is the address of both ends of a replayed connection
+type replayAddr string
+
+func (r replayAddr) Network() string {
+	return "replay"
+}
+
+func (r replayAddr) String() string {
+	return string(r)
+}
+
+// ReplayDialer returns a dialer that plays the connections in a capture back as if they were a server.
+// Each dial gets the next connection in the order they opened. What the client sends is not compared,
+// maps encode in a different order every time, so queries have to match the capture for the replies to
+// make sense. Use it with bolt:// connection strings, tls and websockets aren't captured
+func ReplayDialer(entries []CaptureEntry) DialFunc {
+	var order []string
+	received := map[string][][]byte{}
+	for _, entry := range entries {
+		if _, ok := received[entry.Connection]; !ok {
+			order = append(order, entry.Connection)
+			received[entry.Connection] = nil
+		}
+
+		if entry.Direction == CaptureReceived {
+			received[entry.Connection] = append(received[entry.Connection], entry.Data)
+		}
+	}
+
+	var mutex sync.Mutex
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if len(order) == 0 {
+			return nil, errors.New("no captured connections left to replay")
+		}
+
+		id := order[0]
+		order = order[1:]
+		return &replayConn{id: id, received: received[id]}, nil
+	}
+}
+
+// replayConn reads back the bytes a captured connection received and throws away what is written
+type replayConn struct {
+	id       string
+	mutex    sync.Mutex
+	received [][]byte
+	closed   bool
+}
+
+func (r *replayConn) Read(b []byte) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.closed {
+		return 0, errors.ErrClosed
+	}
+
+	if len(r.received) == 0 {
+		return 0, io.EOF
+	}
+
+	n := copy(b, r.received[0])
+	if n == len(r.received[0]) {
+		r.received = r.received[1:]
+	} else {
+		r.received[0] = r.received[0][n:]
+	}
+	return n, nil
+}
+
+func (r *replayConn) Write(b []byte) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.closed {
+		return 0, errors.ErrClosed
+	}
+	return len(b), nil
+}
+
+func (r *replayConn) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.closed = true
+	return nil
+}
+
+func (r *replayConn) LocalAddr() net.Addr {
+	return replayAddr(r.id)
+}
+
+func (r *replayConn) RemoteAddr() net.Addr {
+	return replayAddr(r.id)
+}
+
+func (r *replayConn) SetDeadline(t time.Time) error {
+	return nil
+}
+
+func (r *replayConn) SetReadDeadline(t time.Time) error {
+	return nil
+}
+
+func (r *replayConn) SetWriteDeadline(t time.Time) error {
+	return nil
+}
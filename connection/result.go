@@ -70,3 +70,21 @@ func (b *boltResult) GetRelationshipsDeleted() (int64, bool) {
 func (b *boltResult) Metadata() map[string]interface{} {
 	return b.metadata
 }
+
+// mergeRunMetadata adds what neo4j sent when the query was run, such as the field names,
+// to the summary metadata. Summary values win when both have a key
+func mergeRunMetadata(run, summary map[string]interface{}) map[string]interface{} {
+	if len(run) == 0 {
+		return summary
+	}
+
+	merged := make(map[string]interface{}, len(run)+len(summary))
+	for key, val := range run {
+		merged[key] = val
+	}
+	for key, val := range summary {
+		merged[key] = val
+	}
+
+	return merged
+}
@@ -0,0 +1,58 @@
+package connection
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Scheme describes what a connection string scheme asks for
+type Scheme struct {
+	// Routing is set for schemes that go through a cluster's routing table
+	Routing bool
+	// TLS is set for schemes that encrypt the connection
+	TLS bool
+	// SkipVerify is set for schemes that trust any certificate, meant for self signed certificates
+	SkipVerify bool
+	// WebSocket is set for schemes that carry bolt over websocket frames, for servers behind http load balancers
+	WebSocket bool
+}
+
+// schemes are the connection string schemes the driver understands.
+// bolt+routing is kept for connection strings written before the neo4j scheme
+var schemes = map[string]Scheme{
+	"bolt":         {},
+	"bolt+s":       {TLS: true},
+	"bolt+ssc":     {TLS: true, SkipVerify: true},
+	"bolt+routing": {Routing: true},
+	"neo4j":        {Routing: true},
+	"neo4j+s":      {Routing: true, TLS: true},
+	"neo4j+ssc":    {Routing: true, TLS: true, SkipVerify: true},
+	"ws":           {WebSocket: true},
+	"wss":          {WebSocket: true, TLS: true},
+}
+
+// ParseScheme looks up a connection string scheme, ignoring case
+func ParseScheme(scheme string) (Scheme, error) {
+	parsed, ok := schemes[strings.ToLower(scheme)]
+	if !ok {
+		return Scheme{}, fmt.Errorf("unsupported connection string scheme: %s. Driver supports bolt, bolt+s, bolt+ssc, neo4j, neo4j+s, neo4j+ssc, bolt+routing, ws and wss", scheme)
+	}
+
+	return parsed, nil
+}
+
+// Direct returns the scheme for connecting straight to one server with the same encryption
+func (s Scheme) Direct() string {
+	switch {
+	case s.WebSocket && s.TLS:
+		return "wss"
+	case s.WebSocket:
+		return "ws"
+	case s.SkipVerify:
+		return "bolt+ssc"
+	case s.TLS:
+		return "bolt+s"
+	default:
+		return "bolt"
+	}
+}
@@ -0,0 +1,255 @@
+package connection
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mindstand/go-bolt/encoding/encode_consts"
+	"github.com/mindstand/go-bolt/encoding/encoding_v2"
+	"github.com/mindstand/go-bolt/protocol"
+	"github.com/mindstand/go-bolt/structures"
+	"github.com/mindstand/go-bolt/structures/messages"
+)
+
+// receivedMessage is a message the test server got from the client
+type receivedMessage struct {
+	Signature byte
+	Fields    []interface{}
+}
+
+// testServer is a minimal in process bolt server. Queries are answered from results,
+// keyed by the query text, and every message it receives is recorded
+type testServer struct {
+	conn    net.Conn
+	results map[string][][]interface{}
+	multi   bool
+
+	mutex    sync.Mutex
+	received []receivedMessage
+
+	// open streams by qid, in order of the runs
+	streams map[int64][][]interface{}
+	nextQid int64
+	lastQid int64
+	inTx    bool
+
+	// failOn makes the server answer a query with a FAILURE
+	failOn string
+	// failCode is the code of that FAILURE, empty sends a syntax error
+	failCode string
+}
+
+// newTestConnection returns a connection talking to a test server over a pipe
+func newTestConnection(t *testing.T, versionBytes []byte, results map[string][][]interface{}) (*Connection, *testServer) {
+	boltProtocol, version, err := protocol.GetProtocol(versionBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, server := net.Pipe()
+
+	s := &testServer{
+		conn:    server,
+		results: results,
+		multi:   boltProtocol.SupportsMultipleResultStreams(),
+		streams: map[int64][][]interface{}{},
+	}
+
+	c := &Connection{
+		boltProtocol:         boltProtocol,
+		protocolVersion:      version,
+		protocolVersionBytes: versionBytes,
+		timeout:              5 * time.Second,
+		chunkSize:            math.MaxUint16,
+		fetchSize:            DefaultFetchSize,
+		openStreams:          map[int64]*boltResultStream{},
+		conn:                 client,
+	}
+	c.readWrite = &readWrite{connection: c}
+	c.decoder = boltProtocol.NewDecoder(c.readWrite)
+	c.encoder = boltProtocol.NewEncoder(c.readWrite, c.chunkSize)
+
+	go s.serve()
+	t.Cleanup(func() {
+		_ = client.Close()
+		_ = server.Close()
+	})
+
+	return c, s
+}
+
+func (s *testServer) messages() []receivedMessage {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]receivedMessage{}, s.received...)
+}
+
+// signatures returns the signatures of the received messages in order
+func (s *testServer) signatures() []byte {
+	var sigs []byte
+	for _, msg := range s.messages() {
+		sigs = append(sigs, msg.Signature)
+	}
+	return sigs
+}
+
+func (s *testServer) serve() {
+	// writes happen on their own routine so pipelined client messages don't deadlock the pipe
+	out := make(chan []byte, 1024)
+	defer close(out)
+	go func() {
+		for data := range out {
+			if _, err := s.conn.Write(data); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		msg, err := readTestMessage(s.conn)
+		if err != nil {
+			return
+		}
+
+		s.mutex.Lock()
+		s.received = append(s.received, msg)
+		responses := s.handle(msg)
+		s.mutex.Unlock()
+
+		for _, resp := range responses {
+			buf := &bytes.Buffer{}
+			if err := encoding_v2.NewEncoder(buf, math.MaxUint16).Encode(resp); err != nil {
+				return
+			}
+			out <- buf.Bytes()
+		}
+	}
+}
+
+func (s *testServer) handle(msg receivedMessage) []structures.Structure {
+	success := func(metadata map[string]interface{}) []structures.Structure {
+		if metadata == nil {
+			metadata = map[string]interface{}{}
+		}
+		return []structures.Structure{messages.NewSuccessMessage(metadata)}
+	}
+
+	switch msg.Signature {
+	case messages.BeginMessageSignature:
+		s.inTx = true
+		return success(nil)
+	case messages.CommitMessageSignature, messages.RollbackMessageSignature:
+		s.inTx = false
+		s.streams = map[int64][][]interface{}{}
+		return success(nil)
+	case messages.ResetMessageSignature:
+		s.inTx = false
+		s.streams = map[int64][][]interface{}{}
+		return success(nil)
+	case messages.RunMessageSignature:
+		query, _ := msg.Fields[0].(string)
+		if query == s.failOn {
+			code := s.failCode
+			if code == "" {
+				code = "Neo.ClientError.Statement.SyntaxError"
+			}
+			return []structures.Structure{messages.NewFailureMessage(map[string]interface{}{
+				"code":    code,
+				"message": "invalid query",
+			})}
+		}
+
+		qid := s.nextQid
+		s.nextQid++
+		s.lastQid = qid
+		s.streams[qid] = s.results[query]
+
+		metadata := map[string]interface{}{
+			"fields": []interface{}{"value"},
+		}
+		if s.multi && s.inTx {
+			metadata["qid"] = qid
+		}
+		return success(metadata)
+	case messages.PullMessageSignature, messages.DiscardMessageSignature:
+		n, qid := int64(-1), s.lastQid
+		if len(msg.Fields) == 1 {
+			metadata, _ := msg.Fields[0].(map[string]interface{})
+			if val, ok := metadata["n"].(int64); ok {
+				n = val
+			}
+			if val, ok := metadata["qid"].(int64); ok && val != -1 {
+				qid = val
+			}
+		}
+
+		rows := s.streams[qid]
+		if n == -1 || n > int64(len(rows)) {
+			n = int64(len(rows))
+		}
+
+		var responses []structures.Structure
+		if msg.Signature == messages.PullMessageSignature {
+			for _, row := range rows[:n] {
+				responses = append(responses, messages.NewRecordMessage(row))
+			}
+		}
+
+		s.streams[qid] = rows[n:]
+		if len(s.streams[qid]) != 0 {
+			return append(responses, success(map[string]interface{}{"has_more": true})...)
+		}
+
+		delete(s.streams, qid)
+		return append(responses, success(map[string]interface{}{"type": "r"})...)
+	default:
+		return success(nil)
+	}
+}
+
+// readTestMessage reads a chunked message off the wire and decodes the structure fields
+func readTestMessage(r io.Reader) (receivedMessage, error) {
+	var raw []byte
+	for {
+		var size uint16
+		if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+			return receivedMessage{}, err
+		}
+
+		if size == 0 {
+			break
+		}
+
+		chunk := make([]byte, size)
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			return receivedMessage{}, err
+		}
+		raw = append(raw, chunk...)
+	}
+
+	// decode the struct fields by rewriting the struct header as a list header
+	numFields := raw[0] - encode_consts.TinyStructMarker
+	list := append([]byte{encode_consts.TinySliceMarker + numFields}, raw[2:]...)
+
+	buf := &bytes.Buffer{}
+	_ = binary.Write(buf, binary.BigEndian, uint16(len(list)))
+	buf.Write(list)
+	buf.Write(encode_consts.EndMessage)
+
+	fields, err := encoding_v2.Unmarshal(buf.Bytes())
+	if err != nil {
+		return receivedMessage{}, err
+	}
+
+	return receivedMessage{
+		Signature: raw[1],
+		Fields:    fields.([]interface{}),
+	}, nil
+}
@@ -0,0 +1,289 @@
+package connection
+
+import (
+	"fmt"
+	"github.com/mindstand/go-bolt/errors"
+	"github.com/mindstand/go-bolt/log"
+	"github.com/mindstand/go-bolt/structures/messages"
+)
+
+const (
+	// DefaultFetchSize is the number of records a result stream pulls at a time
+	DefaultFetchSize int64 = 1000
+
+	qidKey     = "qid"
+	fieldsKey  = "fields"
+	hasMoreKey = "has_more"
+)
+
+// boltResultStream is a cursor over the records of a single RUN.
+// On protocols that support query ids the stream pulls batches with PULL {n, qid},
+// otherwise PULL_ALL is sent with the RUN and records are read straight off the wire
+type boltResultStream struct {
+	conn   *Connection
+	qid    int64
+	fields []string
+
+	buffer  [][]interface{}
+	summary map[string]interface{}
+	done    bool
+	err     error
+}
+
+// openStream sends RUN for the query and registers the resulting stream on the connection.
+// Caller must hold the connection mutex
+func (c *Connection) openStream(query string, params QueryParams) (*boltResultStream, error) {
+	multi := c.boltProtocol.SupportsMultipleResultStreams()
+	if c.openQuery || (!multi && len(c.openStreams) != 0) {
+		return nil, errors.New("runQuery already open")
+	}
+
+	if c.closed {
+		return nil, errors.New("connection already closed")
+	}
+
+	log.Tracef("opening result stream")
+	err := c.sendMessage(c.boltProtocol.GetRunMessage(query, params, "", "", c.accessMode, false))
+	if err != nil {
+		return nil, err
+	}
+
+	if !multi {
+		// no qid support, so records have to be requested with the run
+		err = c.sendMessage(c.boltProtocol.GetPullAllMessage())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := c.consume()
+	if err != nil {
+		return nil, err
+	}
+
+	success, ok := resp.(messages.SuccessMessage)
+	if !ok {
+		return nil, fmt.Errorf("unexpected response of type [%T], should be [messages.SuccessMessage]", resp)
+	}
+
+	stream := &boltResultStream{
+		conn: c,
+		qid:  messages.AbsentQueryId,
+	}
+
+	if fields, ok := success.Metadata[fieldsKey].([]interface{}); ok {
+		stream.fields = make([]string, 0, len(fields))
+		for _, field := range fields {
+			if str, ok := field.(string); ok {
+				stream.fields = append(stream.fields, str)
+			}
+		}
+	}
+
+	if multi {
+		if qid, ok := success.Metadata[qidKey].(int64); ok {
+			stream.qid = qid
+		}
+	} else {
+		c.openQuery = true
+	}
+
+	c.openStreams[stream.qid] = stream
+	return stream, nil
+}
+
+// fetch reads the next batch of records into the buffer. Caller must hold the connection mutex
+func (s *boltResultStream) fetch() error {
+	c := s.conn
+	multi := c.boltProtocol.SupportsMultipleResultStreams()
+
+	if multi {
+		err := c.sendMessage(c.boltProtocol.GetPullMessage(c.fetchSize, s.qid))
+		if err != nil {
+			return s.fail(err)
+		}
+	}
+
+	var read int64
+	for {
+		// without qids there is nothing to batch on, so stop reading once a batch worth is buffered
+		if !multi && c.fetchSize > 0 && read >= c.fetchSize {
+			return nil
+		}
+
+		_resp, err := c.consume()
+		if err != nil {
+			return s.fail(err)
+		}
+
+		switch resp := _resp.(type) {
+		case messages.RecordMessage:
+			s.buffer = append(s.buffer, resp.Fields)
+			read++
+		case messages.SuccessMessage:
+			if hasMore, _ := resp.Metadata[hasMoreKey].(bool); hasMore {
+				return nil
+			}
+
+			s.finish(resp.Metadata)
+			return nil
+		default:
+			return s.fail(errors.New("Unrecognized response type getting next stream row: %#v", resp))
+		}
+	}
+}
+
+// finish marks the stream as consumed and removes it from the connection
+func (s *boltResultStream) finish(summary map[string]interface{}) {
+	s.summary = summary
+	s.done = true
+	s.conn.closeStream(s)
+}
+
+// fail closes the stream and records the error that broke it
+func (s *boltResultStream) fail(err error) error {
+	s.err = err
+	s.done = true
+	s.conn.closeStream(s)
+	return err
+}
+
+func (s *boltResultStream) Next() ([]interface{}, bool, error) {
+	s.conn.mutex.Lock()
+	defer s.conn.mutex.Unlock()
+
+	if len(s.buffer) == 0 {
+		if s.done {
+			return nil, false, s.err
+		}
+
+		if err := s.fetch(); err != nil {
+			return nil, false, err
+		}
+
+		if len(s.buffer) == 0 {
+			return nil, false, nil
+		}
+	}
+
+	record := s.buffer[0]
+	s.buffer = s.buffer[1:]
+	return record, true, nil
+}
+
+func (s *boltResultStream) All() ([][]interface{}, IResult, error) {
+	s.conn.mutex.Lock()
+	defer s.conn.mutex.Unlock()
+
+	for !s.done {
+		if err := s.fetch(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if s.err != nil {
+		return nil, nil, s.err
+	}
+
+	rows := s.buffer
+	if rows == nil {
+		rows = [][]interface{}{}
+	}
+	s.buffer = nil
+
+	return rows, newBoltResult(s.summary), nil
+}
+
+func (s *boltResultStream) Discard() (IResult, error) {
+	s.conn.mutex.Lock()
+	defer s.conn.mutex.Unlock()
+
+	if err := s.discard(); err != nil {
+		return nil, err
+	}
+
+	return newBoltResult(s.summary), nil
+}
+
+// discard throws away what is left of the stream. Caller must hold the connection mutex
+func (s *boltResultStream) discard() error {
+	s.buffer = nil
+	if s.done {
+		return s.err
+	}
+
+	c := s.conn
+	if !c.boltProtocol.SupportsMultipleResultStreams() {
+		// PULL_ALL has already been sent, so the remaining records have to be drained
+		for !s.done {
+			if err := s.fetch(); err != nil {
+				return err
+			}
+			s.buffer = nil
+		}
+		return nil
+	}
+
+	resp, err := c.sendMessageConsume(c.boltProtocol.GetDiscardMessage(s.qid))
+	if err != nil {
+		return s.fail(err)
+	}
+
+	success, ok := resp.(messages.SuccessMessage)
+	if !ok {
+		return s.fail(fmt.Errorf("unexpected response of type [%T], should be [messages.SuccessMessage]", resp))
+	}
+
+	s.finish(success.Metadata)
+	return nil
+}
+
+func (s *boltResultStream) Fields() []string {
+	return s.fields
+}
+
+func (s *boltResultStream) QueryId() int64 {
+	return s.qid
+}
+
+func (s *boltResultStream) IsOpen() bool {
+	s.conn.mutex.Lock()
+	defer s.conn.mutex.Unlock()
+
+	return !s.done || len(s.buffer) != 0
+}
+
+// closeStream removes the stream from the connection's open streams
+func (c *Connection) closeStream(s *boltResultStream) {
+	if open, ok := c.openStreams[s.qid]; ok && open == s {
+		delete(c.openStreams, s.qid)
+	}
+
+	if !c.boltProtocol.SupportsMultipleResultStreams() {
+		c.openQuery = false
+	}
+}
+
+// discardOpenStreams discards every stream still open on the connection. Caller must hold the connection mutex
+func (c *Connection) discardOpenStreams() error {
+	for _, stream := range c.openStreams {
+		if err := stream.discard(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dropOpenStreams forgets every open stream without talking to the server, used once the server state is gone
+func (c *Connection) dropOpenStreams() {
+	for qid, stream := range c.openStreams {
+		stream.done = true
+		if stream.err == nil {
+			stream.err = errors.New("result stream closed by connection reset")
+		}
+		delete(c.openStreams, qid)
+	}
+
+	c.openQuery = false
+}
@@ -0,0 +1,205 @@
+package connection
+
+import (
+	"testing"
+
+	"github.com/mindstand/go-bolt/protocol/protocol_v3"
+	"github.com/mindstand/go-bolt/protocol/protocol_v4"
+	"github.com/mindstand/go-bolt/structures/messages"
+	"github.com/stretchr/testify/require"
+)
+
+var streamResults = map[string][][]interface{}{
+	"first":  {{int64(1)}, {int64(2)}, {int64(3)}},
+	"second": {{"a"}, {"b"}},
+	"third":  {{true}, {false}},
+}
+
+func TestInterleavedStreamsV4(t *testing.T) {
+	req := require.New(t)
+	conn, server := newTestConnection(t, protocol_v4.ProtocolVersionBytes, streamResults)
+	conn.SetFetchSize(2)
+
+	tx, err := conn.Begin()
+	req.Nil(err)
+
+	first, err := tx.QueryStream("first", nil)
+	req.Nil(err)
+	req.EqualValues(0, first.QueryId())
+	req.Equal([]string{"value"}, first.Fields())
+
+	second, err := tx.QueryStream("second", nil)
+	req.Nil(err)
+	req.EqualValues(1, second.QueryId())
+
+	record, ok, err := first.Next()
+	req.Nil(err)
+	req.True(ok)
+	req.Equal([]interface{}{int64(1)}, record)
+
+	record, ok, err = second.Next()
+	req.Nil(err)
+	req.True(ok)
+	req.Equal([]interface{}{"a"}, record)
+
+	record, ok, err = first.Next()
+	req.Nil(err)
+	req.True(ok)
+	req.Equal([]interface{}{int64(2)}, record)
+
+	// first batch is drained, this has to pull the rest of qid 0
+	record, ok, err = first.Next()
+	req.Nil(err)
+	req.True(ok)
+	req.Equal([]interface{}{int64(3)}, record)
+
+	_, ok, err = first.Next()
+	req.Nil(err)
+	req.False(ok)
+	req.False(first.IsOpen())
+
+	rows, result, err := second.All()
+	req.Nil(err)
+	req.NotNil(result)
+	req.Equal([][]interface{}{{"b"}}, rows)
+
+	// queries still work alongside open streams
+	third, err := tx.QueryStream("third", nil)
+	req.Nil(err)
+	rows, _, err = tx.Query("second", nil)
+	req.Nil(err)
+	req.Len(rows, 2)
+
+	// commit has to discard the stream left open
+	req.True(third.IsOpen())
+	req.Nil(tx.Commit())
+	req.False(third.IsOpen())
+
+	var pulls []map[string]interface{}
+	var discards []map[string]interface{}
+	for _, msg := range server.messages() {
+		switch msg.Signature {
+		case messages.PullMessageSignature:
+			pulls = append(pulls, msg.Fields[0].(map[string]interface{}))
+		case messages.DiscardMessageSignature:
+			discards = append(discards, msg.Fields[0].(map[string]interface{}))
+		}
+	}
+
+	req.Equal([]map[string]interface{}{
+		{"n": int64(2), "qid": int64(0)},
+		{"n": int64(2), "qid": int64(1)},
+		{"n": int64(2), "qid": int64(0)},
+		{"n": int64(-1)},
+	}, pulls)
+	req.Equal([]map[string]interface{}{{"n": int64(-1), "qid": int64(2)}}, discards)
+	req.Equal(byte(messages.CommitMessageSignature), server.signatures()[len(server.signatures())-1])
+}
+
+func TestStreamDiscardV4(t *testing.T) {
+	req := require.New(t)
+	conn, _ := newTestConnection(t, protocol_v4.ProtocolVersionBytes, streamResults)
+
+	tx, err := conn.Begin()
+	req.Nil(err)
+
+	stream, err := tx.QueryStream("first", nil)
+	req.Nil(err)
+
+	result, err := stream.Discard()
+	req.Nil(err)
+	req.NotNil(result)
+	req.False(stream.IsOpen())
+
+	_, ok, err := stream.Next()
+	req.Nil(err)
+	req.False(ok)
+
+	req.Nil(tx.Rollback())
+}
+
+func TestStreamFailureClosesStreams(t *testing.T) {
+	req := require.New(t)
+	conn, server := newTestConnection(t, protocol_v4.ProtocolVersionBytes, streamResults)
+	server.failOn = "broken"
+
+	tx, err := conn.Begin()
+	req.Nil(err)
+
+	stream, err := tx.QueryStream("first", nil)
+	req.Nil(err)
+
+	_, err = tx.QueryStream("broken", nil)
+	req.NotNil(err)
+
+	// the failure reset the session, so the open stream is gone
+	req.False(stream.IsOpen())
+	_, _, err = stream.Next()
+	req.NotNil(err)
+	req.Len(conn.openStreams, 0)
+
+	// and ended the transaction
+	req.True(tx.IsClosed())
+	tx, err = conn.Begin()
+	req.Nil(err)
+	req.Nil(tx.Commit())
+}
+
+func TestSingleStreamV3(t *testing.T) {
+	req := require.New(t)
+	conn, _ := newTestConnection(t, protocol_v3.ProtocolVersionBytes, streamResults)
+	conn.SetFetchSize(1)
+
+	tx, err := conn.Begin()
+	req.Nil(err)
+
+	stream, err := tx.QueryStream("first", nil)
+	req.Nil(err)
+	req.Equal(messages.AbsentQueryId, stream.QueryId())
+
+	// bolt v3 has no qid, so only one stream may be open
+	_, err = tx.QueryStream("second", nil)
+	req.NotNil(err)
+	_, _, err = tx.Query("second", nil)
+	req.NotNil(err)
+
+	record, ok, err := stream.Next()
+	req.Nil(err)
+	req.True(ok)
+	req.Equal([]interface{}{int64(1)}, record)
+
+	// commit drains the rest of the stream off the wire
+	req.Nil(tx.Commit())
+	req.False(stream.IsOpen())
+
+	rows, _, err := conn.Query("second", nil)
+	req.Nil(err)
+	req.Len(rows, 2)
+}
+
+func TestSetFetchSizeOutOfRange(t *testing.T) {
+	req := require.New(t)
+	conn, server := newTestConnection(t, protocol_v4.ProtocolVersionBytes, streamResults)
+
+	for _, size := range []int64{0, -2, -1000} {
+		conn.SetFetchSize(size)
+		req.EqualValues(-1, conn.fetchSize)
+	}
+	conn.SetFetchSize(5)
+	req.EqualValues(5, conn.fetchSize)
+
+	conn.SetFetchSize(0)
+	rows, _, err := conn.Query("first", nil)
+	req.Nil(err)
+	req.Len(rows, 3)
+
+	pulls := 0
+	for _, msg := range server.messages() {
+		if msg.Signature == messages.PullMessageSignature {
+			metadata, _ := msg.Fields[0].(map[string]interface{})
+			req.EqualValues(-1, metadata["n"])
+			pulls++
+		}
+	}
+	req.Equal(1, pulls)
+}
@@ -13,7 +13,7 @@ type boltTransaction struct {
 }
 
 func (t *boltTransaction) Exec(query string, params QueryParams) (IResult, error) {
-	return t.conn.Exec(query, params)
+	return t.ExecWithDb(query, params, "")
 }
 
 func (t *boltTransaction) ExecWithDb(query string, params QueryParams, db string) (IResult, error) {
@@ -46,13 +46,28 @@ func (t *boltTransaction) QueryWithDb(query string, params QueryParams, db strin
 	return rows, newBoltResult(metadata), nil
 }
 
+func (t *boltTransaction) QueryStream(query string, params QueryParams) (IResultStream, error) {
+	if t.closed {
+		return nil, errors.New("Transaction already closed")
+	}
+
+	t.conn.mutex.Lock()
+	defer t.conn.mutex.Unlock()
+
+	return t.conn.openStream(query, params)
+}
+
 func (t *boltTransaction) Commit() error {
 	if t.closed {
 		return errors.New("Transaction already closed")
 	}
 
-	if t.conn.openQuery {
-		//?
+	// results that are still streaming have to be closed before neo4j accepts the commit
+	t.conn.mutex.Lock()
+	err := t.conn.discardOpenStreams()
+	t.conn.mutex.Unlock()
+	if err != nil {
+		return err
 	}
 
 	msg := t.conn.boltProtocol.GetTxCommitMessage()
@@ -60,7 +75,7 @@ func (t *boltTransaction) Commit() error {
 	_, isCommitType := msg.(messages.CommitMessage)
 
 	// send commit
-	err := t.conn.sendMessage(t.conn.boltProtocol.GetTxCommitMessage())
+	err = t.conn.sendMessage(t.conn.boltProtocol.GetTxCommitMessage())
 	if err != nil {
 		return err
 	}
@@ -112,8 +127,11 @@ func (t *boltTransaction) Rollback() error {
 		return errors.New("Transaction already closed")
 	}
 
-	if t.conn.openQuery {
-		//?
+	t.conn.mutex.Lock()
+	err := t.conn.discardOpenStreams()
+	t.conn.mutex.Unlock()
+	if err != nil {
+		return err
 	}
 
 	msg := t.conn.boltProtocol.GetTxRollbackMessage()
@@ -121,7 +139,7 @@ func (t *boltTransaction) Rollback() error {
 	_, isRollbackType := msg.(messages.RollbackMessage)
 
 	// send rollback
-	err := t.conn.sendMessage(msg)
+	err = t.conn.sendMessage(msg)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,245 @@
+package connection
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"github.com/mindstand/go-bolt/errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// webSocketGUID is appended to the client key to build the accept header, rfc 6455 section 1.3
+const webSocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xa
+
+	wsFinBit  = 0x80
+	wsMaskBit = 0x80
+)
+
+// webSocketConn carries bolt over binary websocket frames. Bolt's chunking is unchanged,
+// every write goes out as one frame and reads return frame payloads as a stream
+type webSocketConn struct {
+	net.Conn
+	reader *bufio.Reader
+
+	// frames are written whole, reads answer pings so writes can come from two routines
+	writeMutex sync.Mutex
+	// unread payload of the current frame
+	remaining uint64
+	closed    bool
+}
+
+// upgradeWebSocket runs the websocket handshake over conn, host and path go in the upgrade request
+func upgradeWebSocket(conn net.Conn, host, path string, timeout time.Duration) (net.Conn, error) {
+	if path == "" {
+		path = "/"
+	}
+
+	keyBytes := make([]byte, 16)
+	if _, err := rand.Read(keyBytes); err != nil {
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(keyBytes)
+
+	if timeout > 0 {
+		_ = conn.SetDeadline(time.Now().Add(timeout))
+		defer conn.SetDeadline(time.Time{})
+	}
+
+	request := fmt.Sprintf("GET %s HTTP/1.1\r\nHost: %s\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: %s\r\nSec-WebSocket-Version: 13\r\n\r\n", path, host, key)
+	if _, err := io.WriteString(conn, request); err != nil {
+		return nil, errors.Wrap(err, "failed to send websocket upgrade")
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read websocket upgrade response")
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return nil, fmt.Errorf("websocket upgrade failed with status [%s]", resp.Status)
+	}
+
+	if accept := resp.Header.Get("Sec-WebSocket-Accept"); accept != webSocketAccept(key) {
+		return nil, fmt.Errorf("websocket upgrade returned the wrong accept key [%s]", accept)
+	}
+
+	// the reader keeps anything the server sent after the upgrade
+	return &webSocketConn{Conn: conn, reader: reader}, nil
+}
+
+// webSocketAccept computes the accept header the server must answer key with
+func webSocketAccept(key string) string {
+	sum := sha1.Sum([]byte(key + webSocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func (w *webSocketConn) Read(b []byte) (int, error) {
+	for w.remaining == 0 {
+		if err := w.nextFrame(); err != nil {
+			return 0, err
+		}
+	}
+
+	if uint64(len(b)) > w.remaining {
+		b = b[:w.remaining]
+	}
+
+	n, err := w.reader.Read(b)
+	w.remaining -= uint64(n)
+	return n, err
+}
+
+// nextFrame reads frame headers until a data frame starts, handling control frames on the way
+func (w *webSocketConn) nextFrame() error {
+	for {
+		opcode, length, err := w.readHeader()
+		if err != nil {
+			return err
+		}
+
+		switch opcode {
+		case wsOpBinary, wsOpContinuation:
+			w.remaining = length
+			return nil
+		case wsOpPing:
+			payload, err := w.readControl(length)
+			if err != nil {
+				return err
+			}
+			if err := w.writeFrame(wsOpPong, payload); err != nil {
+				return err
+			}
+		case wsOpPong:
+			if _, err := w.readControl(length); err != nil {
+				return err
+			}
+		case wsOpClose:
+			payload, _ := w.readControl(length)
+			// echo the close back, the server closes the tcp connection after
+			_ = w.writeFrame(wsOpClose, payload)
+			return io.EOF
+		case wsOpText:
+			return errors.New("got a text websocket frame, bolt is only sent in binary frames")
+		default:
+			return fmt.Errorf("unknown websocket opcode [%v]", opcode)
+		}
+	}
+}
+
+// readHeader reads a frame header, returning the opcode and payload length. Servers don't mask their frames
+func (w *webSocketConn) readHeader() (byte, uint64, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(w.reader, header); err != nil {
+		return 0, 0, err
+	}
+
+	if header[1]&wsMaskBit != 0 {
+		return 0, 0, errors.New("got a masked websocket frame from the server")
+	}
+
+	opcode := header[0] & 0x0f
+	length := uint64(header[1] &^ wsMaskBit)
+
+	switch length {
+	case 126:
+		ext := make([]byte, 2)
+		if _, err := io.ReadFull(w.reader, ext); err != nil {
+			return 0, 0, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext))
+	case 127:
+		ext := make([]byte, 8)
+		if _, err := io.ReadFull(w.reader, ext); err != nil {
+			return 0, 0, err
+		}
+		length = binary.BigEndian.Uint64(ext)
+	}
+
+	return opcode, length, nil
+}
+
+// readControl reads the payload of a control frame, which are at most 125 bytes
+func (w *webSocketConn) readControl(length uint64) ([]byte, error) {
+	if length > 125 {
+		return nil, fmt.Errorf("websocket control frame of [%v] bytes is too long", length)
+	}
+
+	payload := make([]byte, length)
+	_, err := io.ReadFull(w.reader, payload)
+	return payload, err
+}
+
+func (w *webSocketConn) Write(b []byte) (int, error) {
+	if err := w.writeFrame(wsOpBinary, b); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// writeFrame writes payload as a single masked frame, clients have to mask everything they send
+func (w *webSocketConn) writeFrame(opcode byte, payload []byte) error {
+	w.writeMutex.Lock()
+	defer w.writeMutex.Unlock()
+
+	if w.closed {
+		return errors.New("websocket is closed")
+	}
+
+	frame := make([]byte, 0, len(payload)+14)
+	frame = append(frame, wsFinBit|opcode)
+
+	length := len(payload)
+	switch {
+	case length <= 125:
+		frame = append(frame, wsMaskBit|byte(length))
+	case length <= 0xffff:
+		frame = append(frame, wsMaskBit|126, byte(length>>8), byte(length))
+	default:
+		ext := make([]byte, 8)
+		binary.BigEndian.PutUint64(ext, uint64(length))
+		frame = append(frame, wsMaskBit|127)
+		frame = append(frame, ext...)
+	}
+
+	mask := make([]byte, 4)
+	if _, err := rand.Read(mask); err != nil {
+		return err
+	}
+	frame = append(frame, mask...)
+
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+
+	_, err := w.Conn.Write(frame)
+	if opcode == wsOpClose {
+		w.closed = true
+	}
+	return err
+}
+
+// Close sends a normal closure before closing the underlying connection
+func (w *webSocketConn) Close() error {
+	// a peer that stopped reading shouldn't hold up the close
+	_ = w.Conn.SetWriteDeadline(time.Now().Add(time.Second))
+	_ = w.writeFrame(wsOpClose, []byte{0x03, 0xe8})
+	return w.Conn.Close()
+}
@@ -0,0 +1,228 @@
+package connection
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mindstand/go-bolt/protocol/protocol_v4"
+	"github.com/stretchr/testify/require"
+)
+
+// testWebSocketServer is the server end of a websocket, it unmasks client frames and sends unmasked ones
+type testWebSocketServer struct {
+	net.Conn
+	reader  *bufio.Reader
+	pending []byte
+	// path the client asked to upgrade
+	path string
+	// pongs the client answered pings with
+	pongs chan []byte
+}
+
+// acceptWebSocket answers the client's upgrade request on conn. badAccept sends the wrong accept key
+func acceptWebSocket(conn net.Conn, badAccept bool) (*testWebSocketServer, error) {
+	reader := bufio.NewReader(conn)
+	req, err := http.ReadRequest(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Header.Get("Upgrade") != "websocket" || req.Header.Get("Sec-WebSocket-Version") != "13" {
+		return nil, errors.New("not a websocket upgrade")
+	}
+
+	accept := webSocketAccept(req.Header.Get("Sec-WebSocket-Key"))
+	if badAccept {
+		accept = webSocketAccept("wrong")
+	}
+
+	resp := "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + accept + "\r\n\r\n"
+	if _, err := io.WriteString(conn, resp); err != nil {
+		return nil, err
+	}
+
+	return &testWebSocketServer{Conn: conn, reader: reader, path: req.URL.Path, pongs: make(chan []byte, 10)}, nil
+}
+
+// readFrame reads and unmasks the next client frame
+func (s *testWebSocketServer) readFrame() (byte, []byte, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(s.reader, header); err != nil {
+		return 0, nil, err
+	}
+
+	if header[1]&wsMaskBit == 0 {
+		return 0, nil, errors.New("client frames must be masked")
+	}
+
+	length := uint64(header[1] &^ wsMaskBit)
+	switch length {
+	case 126:
+		ext := make([]byte, 2)
+		if _, err := io.ReadFull(s.reader, ext); err != nil {
+			return 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext))
+	case 127:
+		ext := make([]byte, 8)
+		if _, err := io.ReadFull(s.reader, ext); err != nil {
+			return 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext)
+	}
+
+	mask := make([]byte, 4)
+	if _, err := io.ReadFull(s.reader, mask); err != nil {
+		return 0, nil, err
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(s.reader, payload); err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+
+	return header[0] & 0x0f, payload, nil
+}
+
+// writeFrame sends an unmasked frame
+func (s *testWebSocketServer) writeFrame(opcode byte, payload []byte) error {
+	frame := []byte{wsFinBit | opcode}
+	switch length := len(payload); {
+	case length <= 125:
+		frame = append(frame, byte(length))
+	case length <= 0xffff:
+		frame = append(frame, 126, byte(length>>8), byte(length))
+	default:
+		ext := make([]byte, 8)
+		binary.BigEndian.PutUint64(ext, uint64(length))
+		frame = append(append(frame, 127), ext...)
+	}
+
+	_, err := s.Conn.Write(append(frame, payload...))
+	return err
+}
+
+func (s *testWebSocketServer) Read(b []byte) (int, error) {
+	for len(s.pending) == 0 {
+		opcode, payload, err := s.readFrame()
+		if err != nil {
+			return 0, err
+		}
+
+		switch opcode {
+		case wsOpBinary:
+			s.pending = payload
+		case wsOpPong:
+			s.pongs <- payload
+		case wsOpClose:
+			return 0, io.EOF
+		}
+	}
+
+	n := copy(b, s.pending)
+	s.pending = s.pending[n:]
+	return n, nil
+}
+
+func (s *testWebSocketServer) Write(b []byte) (int, error) {
+	if err := s.writeFrame(wsOpBinary, b); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func TestWebSocketEcho(t *testing.T) {
+	req := require.New(t)
+	client, server := tcpPipe(t)
+	defer server.Close()
+
+	servers := make(chan *testWebSocketServer, 1)
+	go func() {
+		ws, err := acceptWebSocket(server, false)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		servers <- ws
+
+		// a ping before every echo, the client has to answer it while reading
+		buf := make([]byte, 100000)
+		for {
+			n, err := ws.Read(buf)
+			if err != nil {
+				return
+			}
+			_ = ws.writeFrame(wsOpPing, []byte("ping"))
+			_ = ws.writeFrame(wsOpBinary, buf[:n])
+		}
+	}()
+
+	conn, err := upgradeWebSocket(client, "neo4j:7687", "/bolt", 5*time.Second)
+	req.Nil(err)
+	defer conn.Close()
+
+	ws := <-servers
+	req.Equal("/bolt", ws.path)
+
+	// sizes cover the 7 bit, 16 bit and 64 bit payload lengths
+	for _, size := range []int{5, 300, 70000} {
+		payload := bytes.Repeat([]byte{byte(size)}, size)
+		_, err = conn.Write(payload)
+		req.Nil(err)
+
+		echo := make([]byte, size)
+		_, err = io.ReadFull(conn, echo)
+		req.Nil(err)
+		req.Equal(payload, echo)
+		req.Equal([]byte("ping"), <-ws.pongs)
+	}
+}
+
+func TestWebSocketBadAccept(t *testing.T) {
+	req := require.New(t)
+	client, server := net.Pipe()
+	defer server.Close()
+
+	go func() {
+		_, _ = acceptWebSocket(server, true)
+	}()
+
+	_, err := upgradeWebSocket(client, "neo4j:7687", "", 5*time.Second)
+	req.NotNil(err)
+}
+
+func TestWebSocketBolt(t *testing.T) {
+	req := require.New(t)
+
+	paths := make(chan string, 1)
+	dialer, addrs := pipeDialer(t, net.Pipe, protocol_v4.ProtocolVersion44Bytes, func(conn net.Conn) net.Conn {
+		ws, err := acceptWebSocket(conn, false)
+		if err != nil {
+			t.Error(err)
+			return conn
+		}
+		paths <- ws.path
+		return ws
+	})
+
+	conn, err := CreateBoltConnWithConfig("ws://neo4j:secret@neo4j.example.com:443/bolt", Config{Dialer: dialer})
+	req.Nil(err)
+	req.Equal("neo4j.example.com:443", <-addrs)
+	req.Equal("/bolt", <-paths)
+
+	rows, _, err := conn.Query("first", nil)
+	req.Nil(err)
+	req.Len(rows, 3)
+	req.Nil(conn.Close())
+}
@@ -8,12 +8,17 @@ import (
 	"github.com/mindstand/go-bolt/errors"
 )
 
+// createBoltConn opens connections for the pool, swapped out in tests
+var createBoltConn = connection.CreateBoltConnWithConfig
+
 type ConnectionPooledObjectFactory struct {
 	connectionString string
+	config           connection.Config
+	health           connection.HealthCheck
 }
 
 func (c *ConnectionPooledObjectFactory) MakeObject(ctx context.Context) (*pool.PooledObject, error) {
-	conn, err := connection.CreateBoltConn(c.connectionString)
+	conn, err := createBoltConn(c.connectionString, c.config)
 	if err != nil {
 		return nil, err
 	}
@@ -35,11 +40,12 @@ func (c *ConnectionPooledObjectFactory) DestroyObject(ctx context.Context, objec
 		return fmt.Errorf("unable to cast [%T] to [connection.IConnection]", object.Object)
 	}
 
-	if conn.ValidateOpen() {
-		return conn.Close()
-	} else {
-		return nil
+	// broken connections still need their socket closed
+	if err := conn.Close(); err != nil && err != errors.ErrClosed {
+		return err
 	}
+
+	return nil
 }
 
 func (c *ConnectionPooledObjectFactory) ValidateObject(ctx context.Context, object *pool.PooledObject) bool {
@@ -56,7 +62,18 @@ func (c *ConnectionPooledObjectFactory) ValidateObject(ctx context.Context, obje
 		return false
 	}
 
-	return conn.ValidateOpen()
+	// connections being returned were just used, they only need checking for expiry
+	if object.GetState() == pool.StateReturning {
+		return conn.ValidateOpen() && !c.health.Expired(object.CreateTime)
+	}
+
+	// only the time spent idle before this borrow counts towards the liveness check
+	idle := object.GetIdleTime()
+	if object.GetState() == pool.StateAllocated {
+		idle = object.LastBorrowTime.Sub(object.LastReturnTime)
+	}
+
+	return c.health.Healthy(conn, object.CreateTime, idle)
 }
 
 func (c *ConnectionPooledObjectFactory) ActivateObject(ctx context.Context, object *pool.PooledObject) error {
@@ -73,15 +90,10 @@ func (c *ConnectionPooledObjectFactory) ActivateObject(ctx context.Context, obje
 		return fmt.Errorf("unable to cast [%T] to [connection.IConnection]", object.Object)
 	}
 
-	var err error
-
+	// the pool tracks objects by identity so a dead connection can't be swapped in place,
+	// failing here has the pool destroy it and open a new one
 	if !conn.ValidateOpen() {
-		conn, err = connection.CreateBoltConn(c.connectionString)
-		if err != nil {
-			return err
-		}
-
-		object.Object = conn
+		return errors.Wrap(errors.ErrConnection, "pooled connection is closed")
 	}
 
 	return nil
@@ -0,0 +1,172 @@
+package cypher
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/mindstand/go-bolt/connection"
+)
+
+// Builder builds a statement a clause at a time. Clauses are written in the order they are added
+type Builder struct {
+	clauses []string
+	params  connection.QueryParams
+}
+
+// New returns an empty builder
+func New() *Builder {
+	return &Builder{params: connection.QueryParams{}}
+}
+
+// Match starts a statement with MATCH patterns
+func Match(patterns ...Pattern) *Builder {
+	return New().Match(patterns...)
+}
+
+// Create starts a statement with CREATE patterns
+func Create(patterns ...Pattern) *Builder {
+	return New().Create(patterns...)
+}
+
+// Merge starts a statement with MERGE pattern
+func Merge(pattern Pattern) *Builder {
+	return New().Merge(pattern)
+}
+
+// Match adds a MATCH of patterns
+func (b *Builder) Match(patterns ...Pattern) *Builder {
+	return b.clause("MATCH", b.patterns(patterns))
+}
+
+// OptionalMatch adds an OPTIONAL MATCH of patterns
+func (b *Builder) OptionalMatch(patterns ...Pattern) *Builder {
+	return b.clause("OPTIONAL MATCH", b.patterns(patterns))
+}
+
+// Where adds a WHERE of conditions, all of which have to hold
+func (b *Builder) Where(conditions ...Expr) *Builder {
+	if len(conditions) == 0 {
+		return b
+	}
+
+	written := make([]string, len(conditions))
+	for i, condition := range conditions {
+		written[i] = condition.cypher(b)
+	}
+	return b.clause("WHERE", strings.Join(written, " AND "))
+}
+
+// Create adds a CREATE of patterns
+func (b *Builder) Create(patterns ...Pattern) *Builder {
+	return b.clause("CREATE", b.patterns(patterns))
+}
+
+// Merge adds a MERGE of pattern
+func (b *Builder) Merge(pattern Pattern) *Builder {
+	return b.clause("MERGE", pattern.cypher(b))
+}
+
+// OnCreateSet adds an ON CREATE SET to the MERGE before it
+func (b *Builder) OnCreateSet(assignments ...Assignment) *Builder {
+	return b.clause("ON CREATE SET", b.assignments(assignments))
+}
+
+// OnMatchSet adds an ON MATCH SET to the MERGE before it
+func (b *Builder) OnMatchSet(assignments ...Assignment) *Builder {
+	return b.clause("ON MATCH SET", b.assignments(assignments))
+}
+
+// Set adds a SET of assignments
+func (b *Builder) Set(assignments ...Assignment) *Builder {
+	return b.clause("SET", b.assignments(assignments))
+}
+
+// Delete adds a DELETE of exprs, DetachDelete removes their relationships too
+func (b *Builder) Delete(exprs ...Expr) *Builder {
+	return b.clause("DELETE", b.exprs(exprs))
+}
+
+// DetachDelete adds a DETACH DELETE of exprs
+func (b *Builder) DetachDelete(exprs ...Expr) *Builder {
+	return b.clause("DETACH DELETE", b.exprs(exprs))
+}
+
+// With adds a WITH of exprs, name them with As
+func (b *Builder) With(exprs ...Expr) *Builder {
+	return b.clause("WITH", b.exprs(exprs))
+}
+
+// Return adds a RETURN of exprs, name them with As
+func (b *Builder) Return(exprs ...Expr) *Builder {
+	return b.clause("RETURN", b.exprs(exprs))
+}
+
+// ReturnDistinct adds a RETURN DISTINCT of exprs
+func (b *Builder) ReturnDistinct(exprs ...Expr) *Builder {
+	return b.clause("RETURN DISTINCT", b.exprs(exprs))
+}
+
+// OrderBy adds an ORDER BY of exprs, wrap them in Desc to sort descending
+func (b *Builder) OrderBy(exprs ...Expr) *Builder {
+	return b.clause("ORDER BY", b.exprs(exprs))
+}
+
+// Skip adds a SKIP of n rows
+func (b *Builder) Skip(n int64) *Builder {
+	return b.clause("SKIP", strconv.FormatInt(n, 10))
+}
+
+// Limit adds a LIMIT of n rows
+func (b *Builder) Limit(n int64) *Builder {
+	return b.clause("LIMIT", strconv.FormatInt(n, 10))
+}
+
+// Build returns the statement and its parameters
+func (b *Builder) Build() (string, connection.QueryParams) {
+	return strings.Join(b.clauses, " "), b.params
+}
+
+// String returns the statement without its parameters
+func (b *Builder) String() string {
+	return strings.Join(b.clauses, " ")
+}
+
+func (b *Builder) clause(keyword, body string) *Builder {
+	b.clauses = append(b.clauses, keyword+" "+body)
+	return b
+}
+
+// param adds val as the next parameter and returns its reference
+func (b *Builder) param(val interface{}) string {
+	if b.params == nil {
+		b.params = connection.QueryParams{}
+	}
+
+	name := "p" + strconv.Itoa(len(b.params))
+	b.params[name] = val
+	return "$" + name
+}
+
+func (b *Builder) patterns(patterns []Pattern) string {
+	written := make([]string, len(patterns))
+	for i, pattern := range patterns {
+		written[i] = pattern.cypher(b)
+	}
+	return strings.Join(written, ", ")
+}
+
+func (b *Builder) exprs(exprs []Expr) string {
+	written := make([]string, len(exprs))
+	for i, expr := range exprs {
+		written[i] = expr.cypher(b)
+	}
+	return strings.Join(written, ", ")
+}
+
+func (b *Builder) assignments(assignments []Assignment) string {
+	written := make([]string, len(assignments))
+	for i, assignment := range assignments {
+		written[i] = assignment.cypher(b)
+	}
+	return strings.Join(written, ", ")
+}
@@ -0,0 +1,121 @@
+package cypher
+
+import (
+	"testing"
+
+	"github.com/mindstand/go-bolt/connection"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuilderMatch(t *testing.T) {
+	req := require.New(t)
+
+	query, params := Match(Node("p", "Person").To(Rel("", "LIVES_IN"), Node("c", "City"))).
+		Where(Prop("c", "name").Eq("London"), Or(Prop("p", "age").Gte(18), Prop("p", "guardian").IsNotNull())).
+		Return(Var("p"), As(Prop("c", "name"), "city")).
+		OrderBy(Desc(Prop("p", "age")), Prop("p", "name")).
+		Skip(5).
+		Limit(10).
+		Build()
+
+	req.Equal("MATCH (p:`Person`)-[:`LIVES_IN`]->(c:`City`) "+
+		"WHERE c.`name` = $p0 AND (p.`age` >= $p1 OR p.`guardian` IS NOT NULL) "+
+		"RETURN p, c.`name` AS city "+
+		"ORDER BY p.`age` DESC, p.`name` "+
+		"SKIP 5 LIMIT 10", query)
+	req.Equal(connection.QueryParams{"p0": "London", "p1": 18}, params)
+}
+
+func TestBuilderCreateAndMerge(t *testing.T) {
+	req := require.New(t)
+
+	query, params := Create(Node("a", "Person").Props(map[string]interface{}{"name": "Alice", "age": int64(42)})).
+		Return(Count(Var("a"))).
+		Build()
+	req.Equal("CREATE (a:`Person` {`age`: $p0, `name`: $p1}) RETURN count(a)", query)
+	req.Equal(connection.QueryParams{"p0": int64(42), "p1": "Alice"}, params)
+
+	query, params = Match(Node("a", "Person").Props(map[string]interface{}{"id": 1}), Node("b", "Person").Props(map[string]interface{}{"id": 2})).
+		Merge(Node("a").To(Rel("r", "KNOWS"), Node("b")).Named("path")).
+		OnCreateSet(Prop("r", "since").To(Fn("timestamp"))).
+		OnMatchSet(Prop("r", "seen").To(true)).
+		Set(MergeProps("a", map[string]interface{}{"active": true}), AddLabels("b", "Friend")).
+		Return(Var("path")).
+		Build()
+	req.Equal("MATCH (a:`Person` {`id`: $p0}), (b:`Person` {`id`: $p1}) "+
+		"MERGE path = (a)-[r:`KNOWS`]->(b) "+
+		"ON CREATE SET r.`since` = timestamp() "+
+		"ON MATCH SET r.`seen` = $p2 "+
+		"SET a += $p3, b:`Friend` "+
+		"RETURN path", query)
+	req.Equal(connection.QueryParams{"p0": 1, "p1": 2, "p2": true, "p3": map[string]interface{}{"active": true}}, params)
+}
+
+func TestBuilderPatterns(t *testing.T) {
+	req := require.New(t)
+
+	query, _ := Match(Node("a").From(Rel("r", "KNOWS", "LIKES"), Node("")).Related(Rel(""), Node("c", "A", "B"))).
+		DetachDelete(Var("a")).
+		Build()
+	req.Equal("MATCH (a)<-[r:`KNOWS`|`LIKES`]-()-[]-(c:`A`:`B`) DETACH DELETE a", query)
+
+	// a path carries on without changing the one it came from
+	start := Node("a").To(Rel(""), Node("b"))
+	longer := start.To(Rel(""), Node("c"))
+	req.Equal("MATCH (a)-[]->(b)", Match(start).String())
+	req.Equal("MATCH (a)-[]->(b)-[]->(c)", Match(longer).String())
+
+	query, params := Match(Node("").Props(map[string]interface{}{"k": 1})).Build()
+	req.Equal("MATCH ({`k`: $p0})", query)
+	req.Equal(connection.QueryParams{"p0": 1}, params)
+}
+
+func TestBuilderConditions(t *testing.T) {
+	req := require.New(t)
+
+	query, params := Match(Node("n")).
+		Where(
+			Not(Prop("n", "name").StartsWith("a")),
+			Prop("n", "name").EndsWith("z"),
+			Prop("n", "name").Contains("m"),
+			Prop("n", "id").In([]interface{}{1, 2}),
+			And(Prop("n", "x").Lt(1), Prop("n", "y").Lte(2), Prop("n", "z").Gt(Prop("n", "x"))),
+			Prop("n", "w").Ne(Param("mine")),
+			Prop("n", "v").IsNull(),
+		).
+		ReturnDistinct(Fn("apoc.text.join", Prop("n", "tags"), ",")).
+		Build()
+
+	req.Equal("MATCH (n) WHERE NOT (n.`name` STARTS WITH $p0) AND n.`name` ENDS WITH $p1 AND n.`name` CONTAINS $p2 "+
+		"AND n.`id` IN $p3 AND (n.`x` < $p4 AND n.`y` <= $p5 AND n.`z` > n.`x`) AND n.`w` <> $mine AND n.`v` IS NULL "+
+		"RETURN DISTINCT apoc.text.join(n.`tags`, $p6)", query)
+	req.Len(params, 7)
+
+	// no conditions is no WHERE
+	req.Equal("MATCH (n) RETURN n", Match(Node("n")).Where().Return(Var("n")).String())
+}
+
+func TestBuilderEscaping(t *testing.T) {
+	req := require.New(t)
+
+	req.Equal("`Person`", Escape("Person"))
+	req.Equal("`a``b`", Escape("a`b"))
+
+	// names from outside can't break out of their quotes
+	label := "Person`) DETACH DELETE (x"
+	key := "name` = 1 OR 1=1 //"
+	query, params := Match(Node("my node", label).Props(map[string]interface{}{key: "x"})).
+		Where(Prop("my node", key).Eq("x; DROP")).
+		With(As(Var("my node"), "1st")).
+		Return(Var("1st")).
+		Build()
+
+	req.Equal("MATCH (`my node`:`Person``) DETACH DELETE (x` {`name`` = 1 OR 1=1 //`: $p0}) "+
+		"WHERE `my node`.`name`` = 1 OR 1=1 //` = $p1 "+
+		"WITH `my node` AS `1st` RETURN `1st`", query)
+	req.Equal(connection.QueryParams{"p0": "x", "p1": "x; DROP"}, params)
+
+	req.Equal("f_1", escapeVariable("f_1"))
+	req.Equal("`a.b`", escapeVariable("a.b"))
+	req.Equal("MATCH (n) RETURN `my fn`(n)", Match(Node("n")).Return(Fn("my fn", Var("n"))).String())
+}
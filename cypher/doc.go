@@ -0,0 +1,19 @@
+/*
+Package cypher builds parameterized cypher statements.
+
+A statement is built up a clause at a time and Build returns it with its parameters, ready for IQuery.Query
+or Exec:
+
+	query := cypher.Match(cypher.Node("p", "Person").To(cypher.Rel("", "LIVES_IN"), cypher.Node("c", "City"))).
+		Where(cypher.Prop("c", "name").Eq(city), cypher.Prop("p", "age").Gte(18)).
+		Return(cypher.Var("p")).
+		OrderBy(cypher.Desc(cypher.Prop("p", "age"))).
+		Limit(10)
+
+	rows, _, err := conn.Query(query.Build())
+
+Values are never written into the statement, they are sent as parameters named p0, p1 and so on. Labels,
+relationship types and property keys are always quoted with backticks, variables and function names are
+quoted when they aren't plain identifiers, so names from user input can't change the statement.
+*/
+package cypher
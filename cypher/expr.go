@@ -0,0 +1,261 @@
+package cypher
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Expr is an expression in a statement. Anywhere an Expr is compared to or assigned a value, a value that
+// isn't an Expr is sent as a parameter
+type Expr interface {
+	cypher(b *Builder) string
+}
+
+// exprFunc writes an expression with the builder it is part of, so values can be added as parameters
+type exprFunc func(b *Builder) string
+
+func (f exprFunc) cypher(b *Builder) string {
+	return f(b)
+}
+
+// Var is a variable bound by a pattern or WITH
+type Var string
+
+func (v Var) cypher(*Builder) string {
+	return escapeVariable(string(v))
+}
+
+// Property is the property of a variable, like p.name
+type Property struct {
+	variable string
+	key      string
+}
+
+// Prop returns the property key of variable
+func Prop(variable, key string) Property {
+	return Property{variable: variable, key: key}
+}
+
+func (p Property) cypher(*Builder) string {
+	return escapeVariable(p.variable) + "." + Escape(p.key)
+}
+
+// Eq returns p = val
+func (p Property) Eq(val interface{}) Expr {
+	return compare(p, "=", val)
+}
+
+// Ne returns p <> val
+func (p Property) Ne(val interface{}) Expr {
+	return compare(p, "<>", val)
+}
+
+// Gt returns p > val
+func (p Property) Gt(val interface{}) Expr {
+	return compare(p, ">", val)
+}
+
+// Gte returns p >= val
+func (p Property) Gte(val interface{}) Expr {
+	return compare(p, ">=", val)
+}
+
+// Lt returns p < val
+func (p Property) Lt(val interface{}) Expr {
+	return compare(p, "<", val)
+}
+
+// Lte returns p <= val
+func (p Property) Lte(val interface{}) Expr {
+	return compare(p, "<=", val)
+}
+
+// In returns p IN list
+func (p Property) In(list interface{}) Expr {
+	return compare(p, "IN", list)
+}
+
+// StartsWith returns p STARTS WITH prefix
+func (p Property) StartsWith(prefix interface{}) Expr {
+	return compare(p, "STARTS WITH", prefix)
+}
+
+// EndsWith returns p ENDS WITH suffix
+func (p Property) EndsWith(suffix interface{}) Expr {
+	return compare(p, "ENDS WITH", suffix)
+}
+
+// Contains returns p CONTAINS substring
+func (p Property) Contains(substring interface{}) Expr {
+	return compare(p, "CONTAINS", substring)
+}
+
+// IsNull returns p IS NULL
+func (p Property) IsNull() Expr {
+	return exprFunc(func(b *Builder) string {
+		return p.cypher(b) + " IS NULL"
+	})
+}
+
+// IsNotNull returns p IS NOT NULL
+func (p Property) IsNotNull() Expr {
+	return exprFunc(func(b *Builder) string {
+		return p.cypher(b) + " IS NOT NULL"
+	})
+}
+
+// To returns the assignment of val to p, for SET
+func (p Property) To(val interface{}) Assignment {
+	return Assignment{target: p, operator: "=", val: val}
+}
+
+// Assignment is an item of a SET
+type Assignment struct {
+	target   Expr
+	operator string
+	val      interface{}
+}
+
+// SetProps returns the assignment of properties to variable, replacing the ones it has
+func SetProps(variable string, properties map[string]interface{}) Assignment {
+	return Assignment{target: Var(variable), operator: "=", val: properties}
+}
+
+// MergeProps returns the assignment adding properties to variable, keeping the ones it has
+func MergeProps(variable string, properties map[string]interface{}) Assignment {
+	return Assignment{target: Var(variable), operator: "+=", val: properties}
+}
+
+// AddLabels returns the assignment of labels to variable
+func AddLabels(variable string, labels ...string) Assignment {
+	return Assignment{target: exprFunc(func(*Builder) string {
+		var sb strings.Builder
+		sb.WriteString(escapeVariable(variable))
+		for _, label := range labels {
+			sb.WriteString(":")
+			sb.WriteString(Escape(label))
+		}
+		return sb.String()
+	})}
+}
+
+func (a Assignment) cypher(b *Builder) string {
+	if a.operator == "" {
+		return a.target.cypher(b)
+	}
+	return a.target.cypher(b) + " " + a.operator + " " + value(b, a.val)
+}
+
+// Param is a parameter the caller adds to the parameters Build returns, the names p0, p1 and so on are the
+// builder's own
+type Param string
+
+func (p Param) cypher(*Builder) string {
+	return "$" + escapeVariable(string(p))
+}
+
+// Fn returns a call of the function name with args, values are sent as parameters. Namespaced functions
+// like apoc.text.join are written with their dots
+func Fn(name string, args ...interface{}) Expr {
+	return exprFunc(func(b *Builder) string {
+		parts := strings.Split(name, ".")
+		for i, part := range parts {
+			parts[i] = escapeVariable(part)
+		}
+
+		written := make([]string, len(args))
+		for i, arg := range args {
+			written[i] = value(b, arg)
+		}
+		return strings.Join(parts, ".") + "(" + strings.Join(written, ", ") + ")"
+	})
+}
+
+// Count returns count(expr)
+func Count(expr Expr) Expr {
+	return Fn("count", expr)
+}
+
+// As returns expr named alias, for RETURN and WITH
+func As(expr Expr, alias string) Expr {
+	return exprFunc(func(b *Builder) string {
+		return expr.cypher(b) + " AS " + escapeVariable(alias)
+	})
+}
+
+// Desc returns expr sorted descending, for ORDER BY
+func Desc(expr Expr) Expr {
+	return exprFunc(func(b *Builder) string {
+		return expr.cypher(b) + " DESC"
+	})
+}
+
+// And returns conditions joined with AND
+func And(conditions ...Expr) Expr {
+	return join(" AND ", conditions)
+}
+
+// Or returns conditions joined with OR
+func Or(conditions ...Expr) Expr {
+	return join(" OR ", conditions)
+}
+
+// Not returns NOT condition
+func Not(condition Expr) Expr {
+	return exprFunc(func(b *Builder) string {
+		return "NOT (" + condition.cypher(b) + ")"
+	})
+}
+
+// join writes conditions joined by operator, in brackets when there is more than one so they nest
+func join(operator string, conditions []Expr) Expr {
+	return exprFunc(func(b *Builder) string {
+		if len(conditions) == 1 {
+			return conditions[0].cypher(b)
+		}
+
+		written := make([]string, len(conditions))
+		for i, condition := range conditions {
+			written[i] = condition.cypher(b)
+		}
+		return "(" + strings.Join(written, operator) + ")"
+	})
+}
+
+func compare(left Expr, operator string, val interface{}) Expr {
+	return exprFunc(func(b *Builder) string {
+		return left.cypher(b) + " " + operator + " " + value(b, val)
+	})
+}
+
+// value writes val, an Expr as itself and anything else as a parameter
+func value(b *Builder, val interface{}) string {
+	if expr, ok := val.(Expr); ok {
+		return expr.cypher(b)
+	}
+	return b.param(val)
+}
+
+// Escape quotes name with backticks, doubling any backticks in it, so it can be used as a label,
+// relationship type or property key whatever it holds
+func Escape(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// escapeVariable quotes name with Escape unless it is a plain identifier
+func escapeVariable(name string) string {
+	if name == "" || isIdentifier(name) {
+		return name
+	}
+	return Escape(name)
+}
+
+func isIdentifier(name string) bool {
+	for i, r := range name {
+		if r == '_' || unicode.IsLetter(r) || (i > 0 && unicode.IsDigit(r)) {
+			continue
+		}
+		return false
+	}
+	return true
+}
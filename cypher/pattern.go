@@ -0,0 +1,192 @@
+package cypher
+
+import (
+	"sort"
+	"strings"
+)
+
+// Pattern is a node or a path of nodes and relationships, for MATCH, CREATE and MERGE
+type Pattern interface {
+	cypher(b *Builder) string
+}
+
+// NodePattern matches a node, like (p:Person {name: $p0})
+type NodePattern struct {
+	variable   string
+	labels     []string
+	properties map[string]interface{}
+}
+
+// Node returns the pattern of a node with labels, variable can be empty
+func Node(variable string, labels ...string) NodePattern {
+	return NodePattern{variable: variable, labels: labels}
+}
+
+// Props returns the pattern with properties to match or create, values are sent as parameters
+func (n NodePattern) Props(properties map[string]interface{}) NodePattern {
+	n.properties = properties
+	return n
+}
+
+// To returns the path from the node over rel to node, (n)-[rel]->(node)
+func (n NodePattern) To(rel RelPattern, node NodePattern) PathPattern {
+	return PathPattern{start: n}.To(rel, node)
+}
+
+// From returns the path from the node back over rel to node, (n)<-[rel]-(node)
+func (n NodePattern) From(rel RelPattern, node NodePattern) PathPattern {
+	return PathPattern{start: n}.From(rel, node)
+}
+
+// Related returns the path from the node over rel to node in either direction, (n)-[rel]-(node)
+func (n NodePattern) Related(rel RelPattern, node NodePattern) PathPattern {
+	return PathPattern{start: n}.Related(rel, node)
+}
+
+func (n NodePattern) cypher(b *Builder) string {
+	var sb strings.Builder
+	sb.WriteString("(")
+	sb.WriteString(escapeVariable(n.variable))
+	for _, label := range n.labels {
+		sb.WriteString(":")
+		sb.WriteString(Escape(label))
+	}
+	writeProperties(&sb, b, n.properties, n.variable != "" || len(n.labels) != 0)
+	sb.WriteString(")")
+	return sb.String()
+}
+
+// RelPattern matches a relationship, like [r:KNOWS {since: $p0}]
+type RelPattern struct {
+	variable   string
+	types      []string
+	properties map[string]interface{}
+}
+
+// Rel returns the pattern of a relationship of any of types, variable can be empty
+func Rel(variable string, types ...string) RelPattern {
+	return RelPattern{variable: variable, types: types}
+}
+
+// Props returns the pattern with properties to match or create, values are sent as parameters
+func (r RelPattern) Props(properties map[string]interface{}) RelPattern {
+	r.properties = properties
+	return r
+}
+
+func (r RelPattern) cypher(b *Builder) string {
+	var sb strings.Builder
+	sb.WriteString("[")
+	sb.WriteString(escapeVariable(r.variable))
+	for i, typ := range r.types {
+		if i == 0 {
+			sb.WriteString(":")
+		} else {
+			sb.WriteString("|")
+		}
+		sb.WriteString(Escape(typ))
+	}
+	writeProperties(&sb, b, r.properties, r.variable != "" || len(r.types) != 0)
+	sb.WriteString("]")
+	return sb.String()
+}
+
+// direction of a step of a path
+const (
+	outgoing = iota
+	incoming
+	either
+)
+
+type step struct {
+	direction int
+	rel       RelPattern
+	node      NodePattern
+}
+
+// PathPattern matches a path of nodes joined by relationships
+type PathPattern struct {
+	variable string
+	start    NodePattern
+	steps    []step
+}
+
+// To returns the path carried on over rel to node, ...-[rel]->(node)
+func (p PathPattern) To(rel RelPattern, node NodePattern) PathPattern {
+	return p.step(outgoing, rel, node)
+}
+
+// From returns the path carried on back over rel to node, ...<-[rel]-(node)
+func (p PathPattern) From(rel RelPattern, node NodePattern) PathPattern {
+	return p.step(incoming, rel, node)
+}
+
+// Related returns the path carried on over rel to node in either direction, ...-[rel]-(node)
+func (p PathPattern) Related(rel RelPattern, node NodePattern) PathPattern {
+	return p.step(either, rel, node)
+}
+
+// Named returns the path assigned to variable, p = (a)-[r]->(b)
+func (p PathPattern) Named(variable string) PathPattern {
+	p.variable = variable
+	return p
+}
+
+func (p PathPattern) step(direction int, rel RelPattern, node NodePattern) PathPattern {
+	steps := make([]step, len(p.steps), len(p.steps)+1)
+	copy(steps, p.steps)
+	p.steps = append(steps, step{direction: direction, rel: rel, node: node})
+	return p
+}
+
+func (p PathPattern) cypher(b *Builder) string {
+	var sb strings.Builder
+	if p.variable != "" {
+		sb.WriteString(escapeVariable(p.variable))
+		sb.WriteString(" = ")
+	}
+
+	sb.WriteString(p.start.cypher(b))
+	for _, s := range p.steps {
+		if s.direction == incoming {
+			sb.WriteString("<-")
+		} else {
+			sb.WriteString("-")
+		}
+		sb.WriteString(s.rel.cypher(b))
+		if s.direction == outgoing {
+			sb.WriteString("->")
+		} else {
+			sb.WriteString("-")
+		}
+		sb.WriteString(s.node.cypher(b))
+	}
+	return sb.String()
+}
+
+// writeProperties writes {key: $param, ...} with the keys sorted so statements come out the same every time
+func writeProperties(sb *strings.Builder, b *Builder, properties map[string]interface{}, space bool) {
+	if len(properties) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	if space {
+		sb.WriteString(" ")
+	}
+	sb.WriteString("{")
+	for i, key := range keys {
+		if i != 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(Escape(key))
+		sb.WriteString(": ")
+		sb.WriteString(value(b, properties[key]))
+	}
+	sb.WriteString("}")
+}
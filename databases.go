@@ -0,0 +1,113 @@
+package goBolt
+
+import (
+	"github.com/mindstand/go-bolt/connection"
+	"github.com/mindstand/go-bolt/errors"
+)
+
+const (
+	systemDatabase = "system"
+	showDatabases  = "SHOW DATABASES"
+)
+
+// DatabaseInfo is a row of SHOW DATABASES. Clusters list every database once per member
+type DatabaseInfo struct {
+	Name            string
+	Address         string
+	Role            string
+	RequestedStatus string
+	CurrentStatus   string
+	Error           string
+	// Default is set on neo4j's default database
+	Default bool
+	// Home is set on the user's home database. Neo4j before 4.3 has no home databases so it follows Default
+	Home bool
+}
+
+// ListDatabases runs SHOW DATABASES against the system database, requires bolt v4
+func ListDatabases(conn connection.IConnection) ([]DatabaseInfo, error) {
+	if conn == nil {
+		return nil, errors.New("connection can not be nil")
+	}
+
+	rows, result, err := conn.QueryWithDb(showDatabases, nil, systemDatabase)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list databases")
+	}
+
+	columns := map[string]int{}
+	if result != nil {
+		fields, _ := result.Metadata()["fields"].([]interface{})
+		for i, field := range fields {
+			if name, ok := field.(string); ok {
+				columns[name] = i
+			}
+		}
+	}
+
+	if _, ok := columns["name"]; !ok {
+		return nil, errors.New("unexpected columns from [%s], missing name", showDatabases)
+	}
+
+	_, hasHome := columns["home"]
+
+	dbs := make([]DatabaseInfo, 0, len(rows))
+	for _, row := range rows {
+		info := DatabaseInfo{
+			Name:            columnString(row, columns, "name"),
+			Address:         columnString(row, columns, "address"),
+			Role:            columnString(row, columns, "role"),
+			RequestedStatus: columnString(row, columns, "requestedStatus"),
+			CurrentStatus:   columnString(row, columns, "currentStatus"),
+			Error:           columnString(row, columns, "error"),
+			Default:         columnBool(row, columns, "default"),
+			Home:            columnBool(row, columns, "home"),
+		}
+
+		if !hasHome {
+			info.Home = info.Default
+		}
+
+		dbs = append(dbs, info)
+	}
+
+	return dbs, nil
+}
+
+// HomeDatabase resolves the database neo4j uses for queries that don't name one
+func HomeDatabase(conn connection.IConnection) (string, error) {
+	dbs, err := ListDatabases(conn)
+	if err != nil {
+		return "", err
+	}
+
+	for _, db := range dbs {
+		if db.Home {
+			return db.Name, nil
+		}
+	}
+
+	return "", errors.New("no home database found")
+}
+
+// columnString reads a string column, missing columns and nulls are empty
+func columnString(row []interface{}, columns map[string]int, name string) string {
+	idx, ok := columns[name]
+	if !ok || idx >= len(row) {
+		return ""
+	}
+
+	str, _ := row[idx].(string)
+	return str
+}
+
+// columnBool reads a bool column, missing columns and nulls are false
+func columnBool(row []interface{}, columns map[string]int, name string) bool {
+	idx, ok := columns[name]
+	if !ok || idx >= len(row) {
+		return false
+	}
+
+	b, _ := row[idx].(bool)
+	return b
+}
@@ -0,0 +1,77 @@
+package goBolt
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mindstand/go-bolt/connection"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeResult only carries metadata
+type fakeResult struct {
+	connection.IResult
+	metadata map[string]interface{}
+}
+
+func (f *fakeResult) Metadata() map[string]interface{} {
+	return f.metadata
+}
+
+// fakeSystemConn answers SHOW DATABASES
+type fakeSystemConn struct {
+	connection.IConnection
+	fields []interface{}
+	rows   [][]interface{}
+}
+
+func (f *fakeSystemConn) QueryWithDb(query string, params connection.QueryParams, db string) ([][]interface{}, connection.IResult, error) {
+	if query != showDatabases || db != systemDatabase {
+		return nil, nil, errors.New("unexpected query")
+	}
+	return f.rows, &fakeResult{metadata: map[string]interface{}{"fields": f.fields}}, nil
+}
+
+func TestListDatabases(t *testing.T) {
+	req := require.New(t)
+
+	conn := &fakeSystemConn{
+		fields: []interface{}{"name", "address", "role", "requestedStatus", "currentStatus", "error", "default", "home"},
+		rows: [][]interface{}{
+			{"movies", "a:7687", "leader", "online", "online", "", false, true},
+			{"neo4j", "a:7687", "follower", "online", "online", "", true, false},
+			{"system", "a:7687", "leader", "online", "online", nil, false, false},
+		},
+	}
+
+	dbs, err := ListDatabases(conn)
+	req.Nil(err)
+	req.Len(dbs, 3)
+	req.Equal(DatabaseInfo{
+		Name:            "movies",
+		Address:         "a:7687",
+		Role:            "leader",
+		RequestedStatus: "online",
+		CurrentStatus:   "online",
+		Home:            true,
+	}, dbs[0])
+	req.True(dbs[1].Default)
+
+	home, err := HomeDatabase(conn)
+	req.Nil(err)
+	req.Equal("movies", home)
+
+	// without a home column the default database is home
+	conn.fields = conn.fields[:7]
+	for i := range conn.rows {
+		conn.rows[i] = conn.rows[i][:7]
+	}
+
+	home, err = HomeDatabase(conn)
+	req.Nil(err)
+	req.Equal("neo4j", home)
+
+	conn.fields = []interface{}{"value"}
+	_, err = ListDatabases(conn)
+	req.NotNil(err)
+}
@@ -7,9 +7,19 @@ the Bolt protocol supports uint64 either, so the biggest number it can send
 right now is the int64 max.
 
 The URL format is: `bolt://(user):(password)@(host):(port)`
-Schema must be `bolt`. User and password is only necessary if you are authenticating.
-TLS is supported by using query parameters on the connection string, like so:
+User and password is only necessary if you are authenticating. The supported schemes are:
+
+* bolt - a single server
+* bolt+s - a single server over TLS, verifying the certificate
+* bolt+ssc - a single server over TLS, accepting self signed certificates
+* neo4j, neo4j+s and neo4j+ssc - the same through a cluster's routing table
+* bolt+routing - the same as neo4j, kept for older connection strings
+* ws and wss - a single server over websockets, for neo4j behind an http load balancer.
+  The path of the connection string is used for the upgrade request
+
+TLS can also be turned on with query parameters on the connection string, like so:
 `bolt://host:port?tls=true&tls_no_verify=false`
+or with WithTLSConfig for in memory certificates, server names and TLS versions.
 
 The supported query params are:
 
@@ -18,5 +18,29 @@ type Driver struct {
 
 // mode doesn't matter since its not a pooled or routing driver
 func (d *Driver) Open(mode bolt_mode.AccessMode) (connection.IConnection, error) {
-	return connection.CreateBoltConn(d.internalDriver.client.connStr)
+	return connection.CreateBoltConnWithConfig(d.internalDriver.client.connStr, d.internalDriver.client.connectionConfig())
+}
+
+// opens a connection whose queries default to db, empty db uses the client's database
+func (d *Driver) OpenWithDatabase(mode bolt_mode.AccessMode, db string) (connection.IConnection, error) {
+	return d.OpenSession(mode, SessionConfig{Database: db})
+}
+
+// opens a connection set up for session
+func (d *Driver) OpenSession(mode bolt_mode.AccessMode, session SessionConfig) (connection.IConnection, error) {
+	config := d.internalDriver.client.connectionConfig()
+	if session.Database != "" {
+		config.Database = session.Database
+	}
+	if session.AuthToken != nil {
+		config.AuthToken = session.AuthToken
+	}
+
+	conn, err := connection.CreateBoltConnWithConfig(d.internalDriver.client.connStr, config)
+	if err != nil {
+		return nil, err
+	}
+
+	conn.SetImpersonatedUser(session.ImpersonatedUser)
+	return conn, nil
 }
@@ -6,62 +6,184 @@ import (
 	"github.com/mindstand/go-bolt/bolt_mode"
 	"github.com/mindstand/go-bolt/connection"
 	"github.com/mindstand/go-bolt/errors"
+	"github.com/mindstand/go-bolt/routing"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type driverPool struct {
-	connStr  string
-	maxConns int
-	pool     *pool.ObjectPool
-	refLock  sync.Mutex
-	closed   bool
+	connStr         string
+	maxConns        int
+	acquireStrategy routing.AcquireStrategy
+	maxWait         time.Duration
+	// database connections default to when a borrow doesn't name one
+	database string
+	// factory and poolConfig are copied for every credential partition
+	factory    ConnectionPooledObjectFactory
+	poolConfig pool.ObjectPoolConfig
+	pool       *pool.ObjectPool
+	// pools for sessions that override the client's credentials, keyed by connection.AuthTokenKey
+	authPools map[string]*pool.ObjectPool
+	// partition each borrowed connection with overridden credentials came from
+	borrowedFrom map[connection.IConnection]*pool.ObjectPool
+	refLock      sync.Mutex
+	closed       bool
+	// borrows that haven't been handed a connection yet
+	waiters int32
 }
 
-func newDriverPool(connStr string, maxConns int) (*driverPool, error) {
-	dPool := pool.NewObjectPool(context.Background(), &ConnectionPooledObjectFactory{connectionString: connStr}, &pool.ObjectPoolConfig{
-		LIFO:                     true,
-		MaxTotal:                 maxConns,
-		MaxIdle:                  maxConns,
-		MinIdle:                  5,
-		TestOnCreate:             true,
-		TestOnBorrow:             true,
-		TestOnReturn:             true,
-		TestWhileIdle:            true,
-		BlockWhenExhausted:       true,
-		MinEvictableIdleTime:     pool.DefaultMinEvictableIdleTime,
+func newDriverPool(client *Client, maxConns int) (*driverPool, error) {
+	if client == nil {
+		return nil, errors.New("client can not be nil")
+	}
+
+	config, err := client.poolConfig.resolve(client, maxConns)
+	if err != nil {
+		return nil, err
+	}
+
+	factory := ConnectionPooledObjectFactory{
+		connectionString: client.connStr,
+		config:           client.connectionConfig(),
+		health:           client.healthCheck(),
+	}
+
+	poolConfig := pool.ObjectPoolConfig{
+		LIFO:                     config.LIFO,
+		MaxTotal:                 config.MaxTotal,
+		MaxIdle:                  config.MaxIdle,
+		MinIdle:                  config.MinIdle,
+		TestOnCreate:             config.TestOnCreate,
+		TestOnBorrow:             config.TestOnBorrow,
+		TestOnReturn:             config.TestOnReturn,
+		TestWhileIdle:            config.TestWhileIdle,
+		BlockWhenExhausted:       client.acquireStrategy != routing.AcquireFailFast,
+		MinEvictableIdleTime:     config.IdleEvictionTime,
 		SoftMinEvictableIdleTime: pool.DefaultSoftMinEvictableIdleTime,
 		NumTestsPerEvictionRun:   3,
-		TimeBetweenEvictionRuns:  0,
-	})
+		EvictionPolicyName:       pool.DefaultEvictionPolicyName,
+		TimeBetweenEvictionRuns:  config.EvictionInterval,
+		EvitionContext:           context.Background(),
+	}
+
+	dPool := pool.NewObjectPool(context.Background(), &factory, &poolConfig)
+
+	// open min idle up front rather than waiting for the first eviction run
+	dPool.PreparePool(context.Background())
 
 	return &driverPool{
-		connStr:  connStr,
-		maxConns: maxConns,
-		pool:     dPool,
+		connStr:         client.connStr,
+		maxConns:        maxConns,
+		acquireStrategy: client.acquireStrategy,
+		maxWait:         config.MaxWait,
+		database:        client.database,
+		factory:         factory,
+		poolConfig:      poolConfig,
+		pool:            dPool,
+		authPools:       map[string]*pool.ObjectPool{},
+		borrowedFrom:    map[connection.IConnection]*pool.ObjectPool{},
 	}, nil
 }
 
-func (d *driverPool) open() (connection.IConnection, error) {
+// partition returns the pool for connections authenticated with authToken, creating it on first use.
+// Bolt 4 can't re-authenticate a connection so each token gets its own pool. Caller must hold refLock
+func (d *driverPool) partition(authKey string, authToken map[string]interface{}) *pool.ObjectPool {
+	if authKey == "" {
+		return d.pool
+	}
+
+	if objectPool, ok := d.authPools[authKey]; ok {
+		return objectPool
+	}
+
+	factory := d.factory
+	factory.config.AuthToken = authToken
+
+	// partitions only hold connections while they are used
+	poolConfig := d.poolConfig
+	poolConfig.MinIdle = 0
+
+	objectPool := pool.NewObjectPool(context.Background(), &factory, &poolConfig)
+	d.authPools[authKey] = objectPool
+	return objectPool
+}
+
+func (d *driverPool) open(session SessionConfig) (connection.IConnection, error) {
+	authKey := connection.AuthTokenKey(session.AuthToken)
+
 	d.refLock.Lock()
-	defer d.refLock.Unlock()
-	if !d.closed {
-		connObj, err := d.pool.BorrowObject(context.Background())
-		if err != nil {
-			return nil, err
-		}
+	closed := d.closed
+	var objectPool *pool.ObjectPool
+	if !closed {
+		objectPool = d.partition(authKey, session.AuthToken)
+	}
+	d.refLock.Unlock()
 
-		conn, ok := connObj.(connection.IConnection)
-		if !ok {
-			return nil, errors.Wrap(errors.ErrInternal, "cannot cast from [%T] to [IConnection]", connObj)
-		}
+	// the lock isn't held while borrowing so concurrent borrows can wait on the pool together
+	if closed {
+		return nil, errors.New("Driver pool has been closed")
+	}
 
-		if !conn.ValidateOpen() {
-			return nil, errors.New("pool returned dead connection")
+	ctx, cancel := context.WithTimeout(context.Background(), d.maxWait)
+	defer cancel()
+
+	atomic.AddInt32(&d.waiters, 1)
+	connObj, err := objectPool.BorrowObject(ctx)
+	atomic.AddInt32(&d.waiters, -1)
+	if err != nil {
+		// match the errors the routing pool returns for an exhausted pool
+		if ctx.Err() != nil {
+			return nil, routing.ErrAcquireTimeout
+		}
+		if _, ok := err.(*pool.NoSuchElementErr); ok && d.acquireStrategy == routing.AcquireFailFast {
+			return nil, routing.ErrPoolExhausted
 		}
+		return nil, err
+	}
 
-		return conn, nil
+	conn, ok := connObj.(connection.IConnection)
+	if !ok {
+		return nil, errors.Wrap(errors.ErrInternal, "cannot cast from [%T] to [IConnection]", connObj)
 	}
-	return nil, errors.New("Driver pool has been closed")
+
+	if !conn.ValidateOpen() {
+		return nil, errors.New("pool returned dead connection")
+	}
+
+	// pooled connections keep the settings of their last borrow
+	db := session.Database
+	if db == "" {
+		db = d.database
+	}
+	conn.SetDatabase(db)
+	conn.SetImpersonatedUser(session.ImpersonatedUser)
+
+	if authKey != "" {
+		d.refLock.Lock()
+		d.borrowedFrom[conn] = objectPool
+		d.refLock.Unlock()
+	}
+
+	return conn, nil
+}
+
+func (d *driverPool) stats() PoolStats {
+	d.refLock.Lock()
+	defer d.refLock.Unlock()
+
+	stats := PoolStats{
+		Active:  d.pool.GetNumActive(),
+		Idle:    d.pool.GetNumIdle(),
+		Waiters: int(atomic.LoadInt32(&d.waiters)),
+	}
+
+	for _, objectPool := range d.authPools {
+		stats.Active += objectPool.GetNumActive()
+		stats.Idle += objectPool.GetNumIdle()
+	}
+
+	return stats
 }
 
 func (d *driverPool) close() error {
@@ -77,6 +199,9 @@ func (d *driverPool) close() error {
 	}
 
 	d.pool.Close(context.Background())
+	for _, objectPool := range d.authPools {
+		objectPool.Close(context.Background())
+	}
 
 	d.closed = true
 
@@ -88,7 +213,16 @@ func (d *driverPool) reclaim(conn connection.IConnection) error {
 		return errors.New("cannot reclaim nil connection")
 	}
 
-	return d.pool.ReturnObject(context.Background(), conn)
+	d.refLock.Lock()
+	objectPool, ok := d.borrowedFrom[conn]
+	if ok {
+		delete(d.borrowedFrom, conn)
+	} else {
+		objectPool = d.pool
+	}
+	d.refLock.Unlock()
+
+	return objectPool.ReturnObject(context.Background(), conn)
 }
 
 // ----------------------
@@ -98,13 +232,25 @@ type DriverPool struct {
 }
 
 func (d *DriverPool) Open(mode bolt_mode.AccessMode) (connection.IConnection, error) {
-	return d.internalPool.open()
+	return d.internalPool.open(SessionConfig{})
+}
+
+func (d *DriverPool) OpenWithDatabase(mode bolt_mode.AccessMode, db string) (connection.IConnection, error) {
+	return d.internalPool.open(SessionConfig{Database: db})
+}
+
+func (d *DriverPool) OpenSession(mode bolt_mode.AccessMode, session SessionConfig) (connection.IConnection, error) {
+	return d.internalPool.open(session)
 }
 
 func (d *DriverPool) Reclaim(conn connection.IConnection) error {
 	return d.internalPool.reclaim(conn)
 }
 
+func (d *DriverPool) Stats() PoolStats {
+	return d.internalPool.stats()
+}
+
 func (d *DriverPool) Close() error {
 	return d.internalPool.close()
 }
@@ -1 +1,258 @@
 package goBolt
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mindstand/go-bolt/bolt_mode"
+	"github.com/mindstand/go-bolt/connection"
+	"github.com/mindstand/go-bolt/routing"
+	"github.com/mindstand/go-bolt/structures/messages"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePoolConn stands in for a bolt connection in the pool tests
+type fakePoolConn struct {
+	connection.IConnection
+
+	mutex    sync.Mutex
+	closed   bool
+	database string
+	impUser  string
+	// credentials the connection was opened with
+	auth map[string]interface{}
+}
+
+func (f *fakePoolConn) ValidateOpen() bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return !f.closed
+}
+
+func (f *fakePoolConn) Ping() error {
+	return nil
+}
+
+func (f *fakePoolConn) MakeIdle() error {
+	return nil
+}
+
+func (f *fakePoolConn) SetDatabase(db string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.database = db
+}
+
+func (f *fakePoolConn) GetDatabase() string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.database
+}
+
+func (f *fakePoolConn) SetImpersonatedUser(user string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.impUser = user
+}
+
+func (f *fakePoolConn) GetImpersonatedUser() string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.impUser
+}
+
+func (f *fakePoolConn) GetConnectionId() string {
+	return ""
+}
+
+func (f *fakePoolConn) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.closed = true
+	return nil
+}
+
+func newFakeDriverPool(t *testing.T, client *Client, size int) *DriverPool {
+	original := createBoltConn
+	createBoltConn = func(connStr string, config connection.Config) (connection.IConnection, error) {
+		return &fakePoolConn{auth: config.AuthToken}, nil
+	}
+
+	if client.acquireTimeout == 0 {
+		client.acquireTimeout = 100 * time.Millisecond
+	}
+
+	dPool, err := newDriverPool(client, size)
+	require.Nil(t, err)
+
+	t.Cleanup(func() {
+		_ = dPool.close()
+		createBoltConn = original
+	})
+
+	return &DriverPool{internalPool: dPool}
+}
+
+func TestPoolConfigResolve(t *testing.T) {
+	req := require.New(t)
+	client := &Client{acquireTimeout: time.Second}
+
+	config, err := DefaultPoolConfig().resolve(client, 10)
+	req.Nil(err)
+	req.Equal(10, config.MaxTotal)
+	req.Equal(10, config.MaxIdle)
+	req.Equal(0, config.MinIdle)
+	req.Equal(time.Second, config.MaxWait)
+
+	_, err = DefaultPoolConfig().resolve(client, 0)
+	req.NotNil(err)
+
+	config = DefaultPoolConfig()
+	config.MaxTotal = 5
+	_, err = config.resolve(client, 10)
+	req.NotNil(err)
+
+	config = DefaultPoolConfig()
+	config.MaxIdle = 11
+	_, err = config.resolve(client, 10)
+	req.NotNil(err)
+
+	// min idle above the pool size used to be accepted silently
+	config = DefaultPoolConfig()
+	config.MinIdle = 5
+	_, err = config.resolve(client, 2)
+	req.NotNil(err)
+
+	config = DefaultPoolConfig()
+	config.MaxWait = -1
+	req.NotNil(config.validate())
+
+	client.acquireStrategy = routing.AcquireGrow
+	client.maxOverflow = 3
+	config, err = DefaultPoolConfig().resolve(client, 10)
+	req.Nil(err)
+	req.Equal(13, config.MaxTotal)
+	req.Equal(10, config.MaxIdle)
+}
+
+func TestWithPoolConfig(t *testing.T) {
+	req := require.New(t)
+
+	config := DefaultPoolConfig()
+	config.MinIdle = 2
+	config.LIFO = false
+
+	client := &Client{}
+	req.Nil(WithPoolConfig(config)(client))
+	req.Equal(config, client.poolConfig)
+
+	config.MinIdle = -1
+	req.NotNil(WithPoolConfig(config)(client))
+}
+
+func TestDriverPoolStats(t *testing.T) {
+	req := require.New(t)
+
+	config := DefaultPoolConfig()
+	config.MinIdle = 1
+	dPool := newFakeDriverPool(t, &Client{poolConfig: config}, 2)
+
+	// min idle is opened up front
+	req.Equal(PoolStats{Idle: 1}, dPool.Stats())
+
+	first, err := dPool.Open(bolt_mode.WriteMode)
+	req.Nil(err)
+	second, err := dPool.Open(bolt_mode.WriteMode)
+	req.Nil(err)
+	req.Equal(PoolStats{Active: 2}, dPool.Stats())
+
+	// a third borrow waits for a reclaim
+	done := make(chan error)
+	go func() {
+		conn, err := dPool.Open(bolt_mode.WriteMode)
+		if err == nil {
+			err = dPool.Reclaim(conn)
+		}
+		done <- err
+	}()
+
+	req.Eventually(func() bool {
+		return dPool.Stats().Waiters == 1
+	}, time.Second, time.Millisecond)
+
+	req.Nil(dPool.Reclaim(first))
+	req.Nil(<-done)
+	req.Nil(dPool.Reclaim(second))
+	req.Equal(PoolStats{Idle: 2}, dPool.Stats())
+}
+
+func TestDriverPoolExhausted(t *testing.T) {
+	req := require.New(t)
+
+	dPool := newFakeDriverPool(t, &Client{poolConfig: DefaultPoolConfig()}, 1)
+	_, err := dPool.Open(bolt_mode.WriteMode)
+	req.Nil(err)
+
+	_, err = dPool.Open(bolt_mode.WriteMode)
+	req.Equal(routing.ErrAcquireTimeout, err)
+
+	failFast := newFakeDriverPool(t, &Client{poolConfig: DefaultPoolConfig(), acquireStrategy: routing.AcquireFailFast}, 1)
+	_, err = failFast.Open(bolt_mode.WriteMode)
+	req.Nil(err)
+
+	_, err = failFast.Open(bolt_mode.WriteMode)
+	req.Equal(routing.ErrPoolExhausted, err)
+}
+
+func TestDriverPoolDatabase(t *testing.T) {
+	req := require.New(t)
+	dPool := newFakeDriverPool(t, &Client{poolConfig: DefaultPoolConfig(), database: "neo4j"}, 1)
+
+	conn, err := dPool.OpenWithDatabase(bolt_mode.WriteMode, "movies")
+	req.Nil(err)
+	req.Equal("movies", conn.GetDatabase())
+	req.Nil(dPool.Reclaim(conn))
+
+	// the same connection goes back to the client's database
+	conn, err = dPool.Open(bolt_mode.WriteMode)
+	req.Nil(err)
+	req.Equal("neo4j", conn.GetDatabase())
+	req.Nil(dPool.Reclaim(conn))
+}
+
+func TestDriverPoolSession(t *testing.T) {
+	req := require.New(t)
+	dPool := newFakeDriverPool(t, &Client{poolConfig: DefaultPoolConfig(), database: "neo4j"}, 1)
+
+	conn, err := dPool.OpenSession(bolt_mode.WriteMode, SessionConfig{ImpersonatedUser: "alice"})
+	req.Nil(err)
+	req.Equal("neo4j", conn.GetDatabase())
+	req.Equal("alice", conn.GetImpersonatedUser())
+	req.Nil(conn.(*fakePoolConn).auth)
+
+	// other credentials get their own partition even though the default pool is exhausted
+	bob := messages.BuildAuthTokenBasic("bob", "secret")
+	bobConn, err := dPool.OpenSession(bolt_mode.WriteMode, SessionConfig{Database: "movies", AuthToken: bob})
+	req.Nil(err)
+	req.Equal("movies", bobConn.GetDatabase())
+	req.Equal(bob, bobConn.(*fakePoolConn).auth)
+	req.Equal(2, dPool.Stats().Active)
+
+	req.Nil(dPool.Reclaim(conn))
+	req.Nil(dPool.Reclaim(bobConn))
+	req.Equal(0, dPool.Stats().Active)
+	req.Equal(2, dPool.Stats().Idle)
+
+	// impersonation is cleared when the connection is borrowed again
+	conn, err = dPool.Open(bolt_mode.WriteMode)
+	req.Nil(err)
+	req.Equal("", conn.GetImpersonatedUser())
+	req.Nil(dPool.Reclaim(conn))
+
+	// the same credentials reuse the partition's connection
+	again, err := dPool.OpenSession(bolt_mode.WriteMode, SessionConfig{AuthToken: messages.BuildAuthTokenBasic("bob", "secret")})
+	req.Nil(err)
+	req.True(again == bobConn)
+	req.Nil(dPool.Reclaim(again))
+}
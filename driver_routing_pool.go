@@ -5,11 +5,8 @@ import (
 	"github.com/mindstand/go-bolt/connection"
 	"github.com/mindstand/go-bolt/errors"
 	"github.com/mindstand/go-bolt/routing"
-	"time"
 )
 
-const refreshInterval = time.Minute * 2
-
 type RoutingDriverPool struct {
 	internalPool routing.IRoutingPool
 }
@@ -22,7 +19,7 @@ func newRoutingPool(client *Client, size int) (*RoutingDriverPool, error) {
 	userPart, _ := client.getUsernamePassword()
 	tlsPart, _ := client.getTlsPortion()
 
-	internalPool, err := routing.NewRoutingPool(client.connStr, size, refreshInterval, userPart, tlsPart)
+	internalPool, err := routing.NewRoutingPool(client.connStr, client.routingConfig(size), userPart, tlsPart)
 	if err != nil {
 		return nil, err
 	}
@@ -43,10 +40,27 @@ func (r *RoutingDriverPool) Open(mode bolt_mode.AccessMode) (connection.IConnect
 	}
 }
 
+func (r *RoutingDriverPool) OpenWithDatabase(mode bolt_mode.AccessMode, db string) (connection.IConnection, error) {
+	return r.internalPool.BorrowConnection(mode, db)
+}
+
+func (r *RoutingDriverPool) OpenSession(mode bolt_mode.AccessMode, session SessionConfig) (connection.IConnection, error) {
+	return r.internalPool.BorrowSession(mode, session.routingSession())
+}
+
 func (r *RoutingDriverPool) Reclaim(conn connection.IConnection) error {
 	return r.internalPool.Reclaim(conn)
 }
 
+func (r *RoutingDriverPool) Stats() PoolStats {
+	stats := r.internalPool.Stats()
+	return PoolStats{
+		Active:  stats.Active,
+		Idle:    stats.Idle,
+		Waiters: stats.Waiters,
+	}
+}
+
 func (r *RoutingDriverPool) Close() error {
 	return r.internalPool.Stop()
 }
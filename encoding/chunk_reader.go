@@ -0,0 +1,315 @@
+package encoding
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"math"
+	"sync"
+
+	"github.com/mindstand/go-bolt/errors"
+)
+
+// readBufferSize is how much a chunk reader reads ahead, values up to this size are read without a copy
+const readBufferSize = 32 * 1024
+
+// maxPooledScratch is the largest scratch buffer kept when a reader goes back to the pool
+const maxPooledScratch = 1024 * 1024
+
+// bounds on the strings a reader interns
+const (
+	maxInternedLength = 64
+	maxInterned       = 1024
+)
+
+var chunkReaderPool = sync.Pool{
+	New: func() interface{} {
+		return &ChunkReader{
+			br:       bufio.NewReaderSize(nil, readBufferSize),
+			interned: map[string]string{},
+		}
+	},
+}
+
+// ChunkReader reads the values of chunked messages straight off a stream. Chunk headers are read as the
+// values cross them, so a message is never buffered whole. Readers come from a pool, Release hands one back
+// once the stream is done with. Keep one reader per stream, it reads ahead of the message being decoded
+type ChunkReader struct {
+	br *bufio.Reader
+	// bytes left in the current chunk
+	remaining int
+	// inMessage is set between Begin and End
+	inMessage bool
+	// size of the message so far, and the most it can be, zero is no limit
+	size           int
+	maxMessageSize int
+	// scratch holds values split over chunks or bigger than the read buffer
+	scratch []byte
+	// interned holds short strings read by ReadInternedString
+	interned map[string]string
+}
+
+// NewChunkReader returns a pooled reader for r
+func NewChunkReader(r io.Reader) *ChunkReader {
+	c := chunkReaderPool.Get().(*ChunkReader)
+	c.br.Reset(r)
+	c.remaining = 0
+	c.inMessage = false
+	c.maxMessageSize = 0
+	return c
+}
+
+// SetMaxMessageSize limits how many bytes a message can have over all of its chunks, zero is no limit
+func (c *ChunkReader) SetMaxMessageSize(max int) {
+	c.maxMessageSize = max
+}
+
+// Release hands the reader back to the pool, it can't be used after
+func (c *ChunkReader) Release() {
+	c.br.Reset(nil)
+	if cap(c.scratch) > maxPooledScratch {
+		c.scratch = nil
+	}
+	chunkReaderPool.Put(c)
+}
+
+// Begin starts reading the next message, skipping the empty chunks bolt 4.1+ sends as keep alives
+func (c *ChunkReader) Begin() error {
+	for {
+		size, err := c.chunkHeader()
+		if err != nil {
+			return err
+		}
+
+		if size > 0 {
+			c.remaining = size
+			c.inMessage = true
+			c.size = 0
+			return c.grow(size)
+		}
+	}
+}
+
+// End skips what is left of the message, so the next message is read from its start even when decoding
+// the last one failed part way
+func (c *ChunkReader) End() error {
+	if !c.inMessage {
+		return nil
+	}
+
+	for {
+		if c.remaining > 0 {
+			if _, err := c.br.Discard(c.remaining); err != nil {
+				return errors.Wrap(err, "An error occurred skipping the rest of the message")
+			}
+		}
+
+		size, err := c.chunkHeader()
+		if err != nil {
+			return err
+		}
+
+		if size == 0 {
+			c.remaining = 0
+			c.inMessage = false
+			return nil
+		}
+		c.remaining = size
+	}
+}
+
+// chunkHeader reads the size of the next chunk
+func (c *ChunkReader) chunkHeader() (int, error) {
+	header, err := c.br.Peek(2)
+	if err != nil {
+		return 0, errors.Wrap(err, "Couldn't read expected bytes for message length. Read: %d Expected: 2.", len(header))
+	}
+
+	size := int(binary.BigEndian.Uint16(header))
+	_, _ = c.br.Discard(2)
+	return size, nil
+}
+
+// nextChunk moves on to the next chunk of the message, the end of the message is an error mid value
+func (c *ChunkReader) nextChunk() error {
+	if !c.inMessage {
+		return errors.New("Reading a value outside of a message")
+	}
+
+	size, err := c.chunkHeader()
+	if err != nil {
+		return err
+	}
+
+	if size == 0 {
+		c.inMessage = false
+		return errors.Wrap(io.ErrUnexpectedEOF, "The message ended in the middle of a value")
+	}
+
+	c.remaining = size
+	return c.grow(size)
+}
+
+// grow adds a chunk to the size of the message, the message is left to End when it is too big
+func (c *ChunkReader) grow(size int) error {
+	c.size += size
+	if c.maxMessageSize > 0 && c.size > c.maxMessageSize {
+		return &LimitError{Limit: "message size", Max: c.maxMessageSize, Size: c.size}
+	}
+	return nil
+}
+
+// ReadByte reads the next byte of the message
+func (c *ChunkReader) ReadByte() (byte, error) {
+	if c.remaining == 0 {
+		if err := c.nextChunk(); err != nil {
+			return 0, err
+		}
+	}
+
+	b, err := c.br.ReadByte()
+	if err != nil {
+		return 0, errors.Wrap(err, "An error occurred reading from stream")
+	}
+
+	c.remaining--
+	return b, nil
+}
+
+// Next reads the next n bytes of the message. The bytes are only good until the next read
+func (c *ChunkReader) Next(n int) ([]byte, error) {
+	if n == 0 {
+		return nil, nil
+	}
+
+	if c.remaining == 0 {
+		if err := c.nextChunk(); err != nil {
+			return nil, err
+		}
+	}
+
+	// the common case, the bytes are in this chunk and fit the read buffer
+	if n <= c.remaining && n <= readBufferSize {
+		b, err := c.br.Peek(n)
+		if err != nil {
+			return nil, errors.Wrap(err, "Couldn't read expected bytes for message. Read: %d Expected: %d.", len(b), n)
+		}
+
+		_, _ = c.br.Discard(n)
+		c.remaining -= n
+		return b, nil
+	}
+
+	// otherwise gather the bytes in scratch, growing it as they arrive rather than trusting n up front
+	out := c.scratch[:0]
+	for len(out) < n {
+		if c.remaining == 0 {
+			if err := c.nextChunk(); err != nil {
+				return nil, err
+			}
+		}
+
+		want := n - len(out)
+		if want > c.remaining {
+			want = c.remaining
+		}
+		if want > readBufferSize {
+			want = readBufferSize
+		}
+
+		b, err := c.br.Peek(want)
+		if err != nil {
+			return nil, errors.Wrap(err, "Couldn't read expected bytes for message. Read: %d Expected: %d.", len(out)+len(b), n)
+		}
+
+		out = append(out, b...)
+		_, _ = c.br.Discard(want)
+		c.remaining -= want
+	}
+	c.scratch = out
+
+	return out, nil
+}
+
+// ReadSize reads the unsigned size following a marker, width is 1, 2 or 4 bytes
+func (c *ChunkReader) ReadSize(width int) (int, error) {
+	switch width {
+	case 1:
+		b, err := c.ReadByte()
+		return int(b), err
+	case 2:
+		size, err := c.ReadUint16()
+		return int(size), err
+	default:
+		size, err := c.ReadUint32()
+		return int(size), err
+	}
+}
+
+// ReadUint16 reads a big endian two byte unsigned int
+func (c *ChunkReader) ReadUint16() (uint16, error) {
+	b, err := c.Next(2)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint16(b), nil
+}
+
+// ReadUint32 reads a big endian four byte unsigned int
+func (c *ChunkReader) ReadUint32() (uint32, error) {
+	b, err := c.Next(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(b), nil
+}
+
+// ReadUint64 reads a big endian eight byte unsigned int
+func (c *ChunkReader) ReadUint64() (uint64, error) {
+	b, err := c.Next(8)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
+
+// ReadFloat64 reads a big endian eight byte float
+func (c *ChunkReader) ReadFloat64() (float64, error) {
+	bits, err := c.ReadUint64()
+	if err != nil {
+		return 0, err
+	}
+	return math.Float64frombits(bits), nil
+}
+
+// ReadString reads a string of size bytes
+func (c *ChunkReader) ReadString(size int) (string, error) {
+	b, err := c.Next(size)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// ReadInternedString reads a string like ReadString, but short strings read before are shared instead of
+// allocated again. Map keys repeat on every record of a result
+func (c *ChunkReader) ReadInternedString(size int) (string, error) {
+	b, err := c.Next(size)
+	if err != nil {
+		return "", err
+	}
+
+	if size > maxInternedLength {
+		return string(b), nil
+	}
+
+	if interned, ok := c.interned[string(b)]; ok {
+		return interned, nil
+	}
+
+	str := string(b)
+	if len(c.interned) < maxInterned {
+		c.interned[str] = str
+	}
+	return str, nil
+}
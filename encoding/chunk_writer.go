@@ -0,0 +1,147 @@
+package encoding
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"sync"
+
+	"github.com/mindstand/go-bolt/encoding/encode_consts"
+	"github.com/mindstand/go-bolt/errors"
+	"github.com/mindstand/go-bolt/log"
+)
+
+// maxPooledBuffer is the largest buffer kept when a writer goes back to the pool
+const maxPooledBuffer = 1024 * 1024
+
+var chunkWriterPool = sync.Pool{
+	New: func() interface{} {
+		return &ChunkWriter{}
+	},
+}
+
+// ChunkWriter collects messages and writes them chunked to a stream. A message is built up with the Write
+// methods and chunked by End, and everything ended is sent in a single write by Flush, so a pipeline of
+// messages costs one syscall. Writers come from a pool, Release hands one back once the stream is done with
+type ChunkWriter struct {
+	w         io.Writer
+	chunkSize int
+	// message holds the message being written
+	message bytes.Buffer
+	// out holds ended messages, chunked, until they are flushed
+	out bytes.Buffer
+	// scratch holds the bytes of a number
+	scratch [8]byte
+}
+
+// NewChunkWriter returns a pooled writer for w, messages are split into chunks of at most chunkSize bytes
+func NewChunkWriter(w io.Writer, chunkSize uint16) *ChunkWriter {
+	if chunkSize == 0 {
+		chunkSize = math.MaxUint16
+	}
+
+	c := chunkWriterPool.Get().(*ChunkWriter)
+	c.w = w
+	c.chunkSize = int(chunkSize)
+	c.message.Reset()
+	c.out.Reset()
+	return c
+}
+
+// Release hands the writer back to the pool, it can't be used after. Anything not flushed is dropped
+func (c *ChunkWriter) Release() {
+	c.w = nil
+	if c.message.Cap() > maxPooledBuffer || c.out.Cap() > maxPooledBuffer {
+		c.message = bytes.Buffer{}
+		c.out = bytes.Buffer{}
+	}
+	chunkWriterPool.Put(c)
+}
+
+// Write adds p to the message
+func (c *ChunkWriter) Write(p []byte) (int, error) {
+	return c.message.Write(p)
+}
+
+// WriteByte adds a byte to the message
+func (c *ChunkWriter) WriteByte(b byte) error {
+	return c.message.WriteByte(b)
+}
+
+// WriteString adds the bytes of s to the message
+func (c *ChunkWriter) WriteString(s string) (int, error) {
+	return c.message.WriteString(s)
+}
+
+// WriteUint16 adds a big endian two byte unsigned int to the message
+func (c *ChunkWriter) WriteUint16(v uint16) error {
+	binary.BigEndian.PutUint16(c.scratch[:2], v)
+	_, err := c.message.Write(c.scratch[:2])
+	return err
+}
+
+// WriteUint32 adds a big endian four byte unsigned int to the message
+func (c *ChunkWriter) WriteUint32(v uint32) error {
+	binary.BigEndian.PutUint32(c.scratch[:4], v)
+	_, err := c.message.Write(c.scratch[:4])
+	return err
+}
+
+// WriteUint64 adds a big endian eight byte unsigned int to the message
+func (c *ChunkWriter) WriteUint64(v uint64) error {
+	binary.BigEndian.PutUint64(c.scratch[:8], v)
+	_, err := c.message.Write(c.scratch[:8])
+	return err
+}
+
+// WriteFloat64 adds a big endian eight byte float to the message
+func (c *ChunkWriter) WriteFloat64(v float64) error {
+	return c.WriteUint64(math.Float64bits(v))
+}
+
+// End chunks the message and queues it for the next Flush
+func (c *ChunkWriter) End() {
+	log.TraceBytesFromBuf(&c.message)
+
+	data := c.message.Bytes()
+	for len(data) > 0 {
+		size := len(data)
+		if size > c.chunkSize {
+			size = c.chunkSize
+		}
+
+		binary.BigEndian.PutUint16(c.scratch[:2], uint16(size))
+		c.out.Write(c.scratch[:2])
+		c.out.Write(data[:size])
+		data = data[size:]
+	}
+
+	c.out.Write(encode_consts.EndMessage)
+	c.message.Reset()
+}
+
+// Abort drops the message being written, the messages already ended are kept
+func (c *ChunkWriter) Abort() {
+	c.message.Reset()
+}
+
+// Buffered returns how many bytes are waiting for Flush
+func (c *ChunkWriter) Buffered() int {
+	return c.out.Len()
+}
+
+// Flush sends the ended messages to the stream in one write
+func (c *ChunkWriter) Flush() error {
+	if c.out.Len() == 0 {
+		return nil
+	}
+
+	_, err := c.w.Write(c.out.Bytes())
+	c.out.Reset()
+	if err != nil {
+		return errors.Wrap(err, "An error occurred writing messages to stream")
+	}
+
+	return nil
+}
@@ -2,7 +2,6 @@ package encoding_v1
 
 import (
 	"bytes"
-	"encoding/binary"
 	"github.com/mindstand/go-bolt/encoding"
 	"github.com/mindstand/go-bolt/encoding/encode_consts"
 	"io"
@@ -21,100 +20,80 @@ import (
 // map[string]interface{} and []interface{} are supported.
 // The interface for maps and slices may be more permissive in the future.
 type DecoderV1 struct {
-	r   io.Reader
-	buf *bytes.Buffer
+	r      *encoding.ChunkReader
+	limits encoding.Limits
+	// depth is how many collections the value being decoded is nested in
+	depth int
 }
 
-// NewDecoder Creates a new DecoderV1 object
-func NewDecoder(r io.Reader) DecoderV1 {
-	return DecoderV1{
-		r:   r,
-		buf: &bytes.Buffer{},
+// NewDecoder Creates a new DecoderV1 object reading from r. Keep one decoder for a stream, it reads ahead of the
+// message it is decoding. Release hands its buffers back once the stream is done with
+func NewDecoder(r io.Reader) *DecoderV1 {
+	d := &DecoderV1{
+		r: encoding.NewChunkReader(r),
 	}
+	d.SetLimits(encoding.DefaultLimits)
+	return d
 }
 
-// Unmarshal is used to marshal an object to the bolt interface encoded bytes
-func Unmarshal(b []byte) (interface{}, error) {
-	return NewDecoder(bytes.NewBuffer(b)).Decode()
+// SetLimits bounds the messages the decoder accepts, going over a limit fails the message with a LimitError.
+// Limits left at zero keep their defaults
+func (d *DecoderV1) SetLimits(limits encoding.Limits) {
+	limits = limits.WithDefaults()
+	d.limits = limits
+	if d.r != nil {
+		d.r.SetMaxMessageSize(limits.MaxMessageSize)
+	}
 }
 
-// Read out the object bytes to decode
-func (d DecoderV1) read() (*bytes.Buffer, error) {
-	output := &bytes.Buffer{}
-	for {
-		lengthBytes := make([]byte, 2)
-		if numRead, err := io.ReadFull(d.r, lengthBytes); numRead != 2 {
-			return nil, errors.Wrap(err, "Couldn't read expected bytes for message length. Read: %d Expected: 2.", numRead)
-		}
-
-		// Chunk header contains length of current message
-		messageLen := binary.BigEndian.Uint16(lengthBytes)
-		if messageLen == 0 {
-			// If the length is 0, the chunk is done.
-			return output, nil
-		}
+// Unmarshal is used to marshal an object to the bolt interface encoded bytes
+func Unmarshal(b []byte) (interface{}, error) {
+	decoder := NewDecoder(bytes.NewReader(b))
+	defer decoder.Release()
 
-		data, err := d.readData(messageLen)
-		if err != nil {
-			return output, errors.Wrap(err, "An error occurred reading message data")
-		}
+	return decoder.Decode()
+}
 
-		numWritten, err := output.Write(data)
-		if numWritten < len(data) {
-			return output, errors.New("Didn't write full data on output. Expected: %d Wrote: %d", len(data), numWritten)
-		} else if err != nil {
-			return output, errors.Wrap(err, "Error writing data to output")
-		}
+// Release hands the decoder's buffers back to the pool, the decoder can't be used after
+func (d *DecoderV1) Release() {
+	if d.r != nil {
+		d.r.Release()
+		d.r = nil
 	}
 }
 
-func (d DecoderV1) readData(messageLen uint16) ([]byte, error) {
-	output := make([]byte, messageLen)
-	var totalRead uint16
-	for totalRead < messageLen {
-		data := make([]byte, messageLen-totalRead)
-		numRead, err := d.r.Read(data)
-		if err != nil {
-			return nil, errors.Wrap(err, "An error occurred reading from stream")
-		} else if numRead == 0 {
-			return nil, errors.Wrap(err, "Couldn't read expected bytes for message. Read: %d Expected: %d.", totalRead, messageLen)
-		}
-
-		for idx, b := range data {
-			output[uint16(idx)+totalRead] = b
-		}
+// Decode decodes the next message on the stream to an object
+func (d *DecoderV1) Decode() (interface{}, error) {
+	if d.r == nil {
+		return nil, errors.Wrap(errors.ErrClosed, "decoder has been released")
+	}
 
-		totalRead += uint16(numRead)
+	if err := d.r.Begin(); err != nil {
+		// a message over the size limit is skipped, so the next one can still be read
+		_ = d.r.End()
+		return nil, err
 	}
 
-	return output, nil
-}
+	d.depth = 0
+	decoded, err := d.decode()
 
-// Decode decodes the stream to an object
-func (d DecoderV1) Decode() (interface{}, error) {
-	data, err := d.read()
+	// skip what is left of the message so the stream stays in step, even when decoding failed
+	if endErr := d.r.End(); err == nil {
+		err = endErr
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	return d.decode(data)
+	return decoded, nil
 }
 
-func (d DecoderV1) decode(buffer *bytes.Buffer) (interface{}, error) {
-
-	marker, err := buffer.ReadByte()
+func (d *DecoderV1) decode() (interface{}, error) {
+	marker, err := d.r.ReadByte()
 	if err != nil {
 		return nil, errors.Wrap(err, "Error reading marker")
 	}
 
-	// Here we have to get the marker as an int to check and see
-	// if it's a TINYINT
-	var markerInt int8
-	err = binary.Read(bytes.NewBuffer([]byte{marker}), binary.BigEndian, &markerInt)
-	if err != nil {
-		return nil, errors.Wrap(err, "Error reading marker as int8 from bolt message")
-	}
-
 	switch {
 
 	// NIL
@@ -127,399 +106,410 @@ func (d DecoderV1) decode(buffer *bytes.Buffer) (interface{}, error) {
 	case marker == encode_consts.FalseMarker:
 		return false, nil
 
-	// INT
-	case markerInt >= -16 && markerInt <= 127:
+	// INT, tiny ints are the marker itself
+	case int8(marker) >= -16:
 		return int64(int8(marker)), nil
 	case marker == encode_consts.Int8Marker:
-		var out int8
-		err := binary.Read(buffer, binary.BigEndian, &out)
-		return int64(out), err
+		out, err := d.r.ReadByte()
+		return int64(int8(out)), err
 	case marker == encode_consts.Int16Marker:
-		var out int16
-		err := binary.Read(buffer, binary.BigEndian, &out)
-		return int64(out), err
+		out, err := d.r.ReadUint16()
+		return int64(int16(out)), err
 	case marker == encode_consts.Int32Marker:
-		var out int32
-		err := binary.Read(buffer, binary.BigEndian, &out)
-		return int64(out), err
+		out, err := d.r.ReadUint32()
+		return int64(int32(out)), err
 	case marker == encode_consts.Int64Marker:
-		var out int64
-		err := binary.Read(buffer, binary.BigEndian, &out)
+		out, err := d.r.ReadUint64()
 		return int64(out), err
 
 	// FLOAT
 	case marker == encode_consts.FloatMarker:
-		var out float64
-		err := binary.Read(buffer, binary.BigEndian, &out)
-		return out, err
+		return d.r.ReadFloat64()
 
 	// STRING
 	case marker >= encode_consts.TinyStringMarker && marker <= encode_consts.TinyStringMarker+0x0F:
-		size := int(marker) - int(encode_consts.TinyStringMarker)
-		if size == 0 {
-			return "", nil
-		}
-		return string(buffer.Next(size)), nil
-	case marker == encode_consts.String8Marker:
-		var size int8
-		if err := binary.Read(buffer, binary.BigEndian, &size); err != nil {
-			return nil, errors.Wrap(err, "An error occurred reading string size")
-		}
-		return string(buffer.Next(int(size))), nil
-	case marker == encode_consts.String16Marker:
-		var size int16
-		if err := binary.Read(buffer, binary.BigEndian, &size); err != nil {
-			return nil, errors.Wrap(err, "An error occurred reading string size")
-		}
-		return string(buffer.Next(int(size))), nil
-	case marker == encode_consts.String32Marker:
-		var size int32
-		if err := binary.Read(buffer, binary.BigEndian, &size); err != nil {
+		return d.r.ReadString(int(marker) - int(encode_consts.TinyStringMarker))
+	case marker >= encode_consts.String8Marker && marker <= encode_consts.String32Marker:
+		size, err := d.r.ReadSize(1 << (marker - encode_consts.String8Marker))
+		if err != nil {
 			return nil, errors.Wrap(err, "An error occurred reading string size")
 		}
-		return string(buffer.Next(int(size))), nil
+		return d.r.ReadString(size)
 
 	// SLICE
 	case marker >= encode_consts.TinySliceMarker && marker <= encode_consts.TinySliceMarker+0x0F:
-		size := int(marker) - int(encode_consts.TinySliceMarker)
-		return d.decodeSlice(buffer, size)
-	case marker == encode_consts.Slice8Marker:
-		var size int8
-		if err := binary.Read(buffer, binary.BigEndian, &size); err != nil {
-			return nil, errors.Wrap(err, "An error occurred reading slice size")
-		}
-		return d.decodeSlice(buffer, int(size))
-	case marker == encode_consts.Slice16Marker:
-		var size int16
-		if err := binary.Read(buffer, binary.BigEndian, &size); err != nil {
-			return nil, errors.Wrap(err, "An error occurred reading slice size")
-		}
-		return d.decodeSlice(buffer, int(size))
-	case marker == encode_consts.Slice32Marker:
-		var size int32
-		if err := binary.Read(buffer, binary.BigEndian, &size); err != nil {
+		return d.decodeSlice(int(marker) - int(encode_consts.TinySliceMarker))
+	case marker >= encode_consts.Slice8Marker && marker <= encode_consts.Slice32Marker:
+		size, err := d.r.ReadSize(1 << (marker - encode_consts.Slice8Marker))
+		if err != nil {
 			return nil, errors.Wrap(err, "An error occurred reading slice size")
 		}
-		return d.decodeSlice(buffer, int(size))
+		return d.decodeSlice(size)
 
 	// MAP
 	case marker >= encode_consts.TinyMapMarker && marker <= encode_consts.TinyMapMarker+0x0F:
-		size := int(marker) - int(encode_consts.TinyMapMarker)
-		return d.decodeMap(buffer, size)
-	case marker == encode_consts.Map8Marker:
-		var size int8
-		if err := binary.Read(buffer, binary.BigEndian, &size); err != nil {
-			return nil, errors.Wrap(err, "An error occurred reading map size")
-		}
-		return d.decodeMap(buffer, int(size))
-	case marker == encode_consts.Map16Marker:
-		var size int16
-		if err := binary.Read(buffer, binary.BigEndian, &size); err != nil {
-			return nil, errors.Wrap(err, "An error occurred reading map size")
-		}
-		return d.decodeMap(buffer, int(size))
-	case marker == encode_consts.Map32Marker:
-		var size int32
-		if err := binary.Read(buffer, binary.BigEndian, &size); err != nil {
+		return d.decodeMap(int(marker) - int(encode_consts.TinyMapMarker))
+	case marker >= encode_consts.Map8Marker && marker <= encode_consts.Map32Marker:
+		size, err := d.r.ReadSize(1 << (marker - encode_consts.Map8Marker))
+		if err != nil {
 			return nil, errors.Wrap(err, "An error occurred reading map size")
 		}
-		return d.decodeMap(buffer, int(size))
+		return d.decodeMap(size)
 
 	// STRUCTURES
 	case marker >= encode_consts.TinyStructMarker && marker <= encode_consts.TinyStructMarker+0x0F:
-		size := int(marker) - int(encode_consts.TinyStructMarker)
-		return d.decodeStruct(buffer, size)
-	case marker == encode_consts.Struct8Marker:
-		var size int8
-		if err := binary.Read(buffer, binary.BigEndian, &size); err != nil {
-			return nil, errors.Wrap(err, "An error occurred reading struct size")
-		}
-		return d.decodeStruct(buffer, int(size))
-	case marker == encode_consts.Struct16Marker:
-		var size int16
-		if err := binary.Read(buffer, binary.BigEndian, &size); err != nil {
+		return d.decodeStruct(int(marker) - int(encode_consts.TinyStructMarker))
+	case marker == encode_consts.Struct8Marker || marker == encode_consts.Struct16Marker:
+		size, err := d.r.ReadSize(1 << (marker - encode_consts.Struct8Marker))
+		if err != nil {
 			return nil, errors.Wrap(err, "An error occurred reading struct size")
 		}
-		return d.decodeStruct(buffer, int(size))
+		return d.decodeStruct(size)
 
 	default:
-		return nil, errors.New("Unrecognized marker byte!: %x", marker)
+		return nil, encoding.Malformed("Unrecognized marker byte!: %x", marker)
 	}
 
 }
 
-func (d DecoderV1) decodeSlice(buffer *bytes.Buffer, size int) ([]interface{}, error) {
-	slice := make([]interface{}, size)
+func (d *DecoderV1) decodeSlice(size int) ([]interface{}, error) {
+	if err := d.enter(size); err != nil {
+		return nil, err
+	}
+	defer d.leave()
+
+	slice := make([]interface{}, 0, encoding.Prealloc(size))
 	for i := 0; i < size; i++ {
-		item, err := d.decode(buffer)
+		item, err := d.decode()
 		if err != nil {
 			return nil, err
 		}
-		slice[i] = item
+		slice = append(slice, item)
 	}
 
 	return slice, nil
 }
 
-func (d DecoderV1) decodeMap(buffer *bytes.Buffer, size int) (map[string]interface{}, error) {
-	mapp := make(map[string]interface{}, size)
+func (d *DecoderV1) decodeMap(size int) (map[string]interface{}, error) {
+	if err := d.enter(size); err != nil {
+		return nil, err
+	}
+	defer d.leave()
+
+	mapp := make(map[string]interface{}, encoding.Prealloc(size))
 	for i := 0; i < size; i++ {
-		keyInt, err := d.decode(buffer)
+		key, err := d.decodeKey()
 		if err != nil {
 			return nil, err
 		}
-		val, err := d.decode(buffer)
+		val, err := d.decode()
 		if err != nil {
 			return nil, err
 		}
 
-		key, ok := keyInt.(string)
-		if !ok {
-			return nil, errors.New("Unexpected key type: %T with value %+v", keyInt, keyInt)
-		}
 		mapp[key] = val
 	}
 
 	return mapp, nil
 }
 
-func (d DecoderV1) decodeStruct(buffer *bytes.Buffer, size int) (interface{}, error) {
+// enter checks a collection of size items against the limits before it is decoded, leave has to follow
+func (d *DecoderV1) enter(size int) error {
+	if err := d.limits.CheckLength(size); err != nil {
+		return err
+	}
+
+	d.depth++
+	if err := d.limits.CheckDepth(d.depth); err != nil {
+		d.depth--
+		return err
+	}
+	return nil
+}
+
+// leave is called once a collection entered is decoded
+func (d *DecoderV1) leave() {
+	d.depth--
+}
+
+// decodeKey decodes a map key, keys repeat across records so they are interned
+func (d *DecoderV1) decodeKey() (string, error) {
+	marker, err := d.r.ReadByte()
+	if err != nil {
+		return "", errors.Wrap(err, "Error reading marker")
+	}
+
+	switch {
+	case marker >= encode_consts.TinyStringMarker && marker <= encode_consts.TinyStringMarker+0x0F:
+		return d.r.ReadInternedString(int(marker) - int(encode_consts.TinyStringMarker))
+	case marker >= encode_consts.String8Marker && marker <= encode_consts.String32Marker:
+		size, err := d.r.ReadSize(1 << (marker - encode_consts.String8Marker))
+		if err != nil {
+			return "", errors.Wrap(err, "An error occurred reading string size")
+		}
+		return d.r.ReadInternedString(size)
+	default:
+		return "", encoding.Malformed("Unexpected key type with marker byte: %x", marker)
+	}
+}
+
+func (d *DecoderV1) decodeStruct(size int) (interface{}, error) {
+	if err := d.enter(size); err != nil {
+		return nil, err
+	}
+	defer d.leave()
 
-	signature, err := buffer.ReadByte()
+	signature, err := d.r.ReadByte()
 	if err != nil {
 		return nil, errors.Wrap(err, "An error occurred reading struct signature byte")
 	}
 
 	switch signature {
 	case graph.NodeSignature:
-		return d.decodeNode(buffer)
+		return d.decodeNode()
 	case graph.RelationshipSignature:
-		return d.decodeRelationship(buffer)
+		return d.decodeRelationship()
 	case graph.PathSignature:
-		return d.decodePath(buffer)
+		return d.decodePath()
 	case graph.UnboundRelationshipSignature:
-		return d.decodeUnboundRelationship(buffer)
+		return d.decodeUnboundRelationship()
 	case messages.RecordMessageSignature:
-		return d.decodeRecordMessage(buffer)
+		return d.decodeRecordMessage()
 	case messages.FailureMessageSignature:
-		return d.decodeFailureMessage(buffer)
+		return d.decodeFailureMessage()
 	case messages.IgnoredMessageSignature:
-		return d.decodeIgnoredMessage(buffer)
+		return d.decodeIgnoredMessage()
 	case messages.SuccessMessageSignature:
-		return d.decodeSuccessMessage(buffer)
+		return d.decodeSuccessMessage()
 	case messages.DiscardAllMessageSignature:
-		return d.decodeDiscardAllMessage(buffer)
+		return d.decodeDiscardAllMessage()
 	case messages.PullAllMessageSignature:
-		return d.decodePullAllMessage(buffer)
+		return d.decodePullAllMessage()
 	case messages.ResetMessageSignature:
-		return d.decodeResetMessage(buffer)
+		return d.decodeResetMessage()
 	default:
-		return nil, errors.New("Unrecognized type decoding struct with signature %x", signature)
+		return nil, encoding.Malformed("Unrecognized type decoding struct with signature %x", signature)
 	}
 }
 
-func (d DecoderV1) decodeNode(buffer *bytes.Buffer) (graph.Node, error) {
+func (d *DecoderV1) decodeNode() (graph.Node, error) {
 	node := graph.Node{}
+	var ok bool
 
-	nodeIdentityInt, err := d.decode(buffer)
+	nodeIdentityInt, err := d.decode()
 	if err != nil {
 		return node, err
 	}
-	node.NodeIdentity = nodeIdentityInt.(int64)
+	node.NodeIdentity, ok = nodeIdentityInt.(int64)
+	if !ok {
+		return node, encoding.Malformed("Expected: NodeIdentity int64, but got %T %+v", nodeIdentityInt, nodeIdentityInt)
+	}
 
-	labelInt, err := d.decode(buffer)
+	labelInt, err := d.decode()
 	if err != nil {
 		return node, err
 	}
 	labelIntSlice, ok := labelInt.([]interface{})
 	if !ok {
-		return node, errors.New("Expected: Labels []string, but got %T %+v", labelInt, labelInt)
+		return node, encoding.Malformed("Expected: Labels []string, but got %T %+v", labelInt, labelInt)
 	}
 	node.Labels, err = encoding.SliceInterfaceToString(labelIntSlice)
 	if err != nil {
 		return node, err
 	}
 
-	propertiesInt, err := d.decode(buffer)
+	propertiesInt, err := d.decode()
 	if err != nil {
 		return node, err
 	}
 	node.Properties, ok = propertiesInt.(map[string]interface{})
 	if !ok {
-		return node, errors.New("Expected: Properties map[string]interface{}, but got %T %+v", propertiesInt, propertiesInt)
+		return node, encoding.Malformed("Expected: Properties map[string]interface{}, but got %T %+v", propertiesInt, propertiesInt)
 	}
 
 	return node, nil
 
 }
 
-func (d DecoderV1) decodeRelationship(buffer *bytes.Buffer) (graph.Relationship, error) {
+func (d *DecoderV1) decodeRelationship() (graph.Relationship, error) {
 	rel := graph.Relationship{}
+	var ok bool
 
-	relIdentityInt, err := d.decode(buffer)
+	relIdentityInt, err := d.decode()
 	if err != nil {
 		return rel, err
 	}
-	rel.RelIdentity = relIdentityInt.(int64)
+	rel.RelIdentity, ok = relIdentityInt.(int64)
+	if !ok {
+		return rel, encoding.Malformed("Expected: RelIdentity int64, but got %T %+v", relIdentityInt, relIdentityInt)
+	}
 
-	startNodeIdentityInt, err := d.decode(buffer)
+	startNodeIdentityInt, err := d.decode()
 	if err != nil {
 		return rel, err
 	}
-	rel.StartNodeIdentity = startNodeIdentityInt.(int64)
+	rel.StartNodeIdentity, ok = startNodeIdentityInt.(int64)
+	if !ok {
+		return rel, encoding.Malformed("Expected: StartNodeIdentity int64, but got %T %+v", startNodeIdentityInt, startNodeIdentityInt)
+	}
 
-	endNodeIdentityInt, err := d.decode(buffer)
+	endNodeIdentityInt, err := d.decode()
 	if err != nil {
 		return rel, err
 	}
-	rel.EndNodeIdentity = endNodeIdentityInt.(int64)
+	rel.EndNodeIdentity, ok = endNodeIdentityInt.(int64)
+	if !ok {
+		return rel, encoding.Malformed("Expected: EndNodeIdentity int64, but got %T %+v", endNodeIdentityInt, endNodeIdentityInt)
+	}
 
-	var ok bool
-	typeInt, err := d.decode(buffer)
+	typeInt, err := d.decode()
 	if err != nil {
 		return rel, err
 	}
 	rel.Type, ok = typeInt.(string)
 	if !ok {
-		return rel, errors.New("Expected: Type string, but got %T %+v", typeInt, typeInt)
+		return rel, encoding.Malformed("Expected: Type string, but got %T %+v", typeInt, typeInt)
 	}
 
-	propertiesInt, err := d.decode(buffer)
+	propertiesInt, err := d.decode()
 	if err != nil {
 		return rel, err
 	}
 	rel.Properties, ok = propertiesInt.(map[string]interface{})
 	if !ok {
-		return rel, errors.New("Expected: Properties map[string]interface{}, but got %T %+v", propertiesInt, propertiesInt)
+		return rel, encoding.Malformed("Expected: Properties map[string]interface{}, but got %T %+v", propertiesInt, propertiesInt)
 	}
 
 	return rel, nil
 }
 
-func (d DecoderV1) decodePath(buffer *bytes.Buffer) (graph.Path, error) {
+func (d *DecoderV1) decodePath() (graph.Path, error) {
 	path := graph.Path{}
 
-	nodesInt, err := d.decode(buffer)
+	nodesInt, err := d.decode()
 	if err != nil {
 		return path, err
 	}
 	nodesIntSlice, ok := nodesInt.([]interface{})
 	if !ok {
-		return path, errors.New("Expected: Nodes []Node, but got %T %+v", nodesInt, nodesInt)
+		return path, encoding.Malformed("Expected: Nodes []Node, but got %T %+v", nodesInt, nodesInt)
 	}
 	path.Nodes, err = encoding.SliceInterfaceToNode(nodesIntSlice)
 	if err != nil {
 		return path, err
 	}
 
-	relsInt, err := d.decode(buffer)
+	relsInt, err := d.decode()
 	if err != nil {
 		return path, err
 	}
 	relsIntSlice, ok := relsInt.([]interface{})
 	if !ok {
-		return path, errors.New("Expected: Relationships []Relationship, but got %T %+v", relsInt, relsInt)
+		return path, encoding.Malformed("Expected: Relationships []Relationship, but got %T %+v", relsInt, relsInt)
 	}
 	path.Relationships, err = encoding.SliceInterfaceToUnboundRelationship(relsIntSlice)
 	if err != nil {
 		return path, err
 	}
 
-	seqInt, err := d.decode(buffer)
+	seqInt, err := d.decode()
 	if err != nil {
 		return path, err
 	}
 	seqIntSlice, ok := seqInt.([]interface{})
 	if !ok {
-		return path, errors.New("Expected: Sequence []int, but got %T %+v", seqInt, seqInt)
+		return path, encoding.Malformed("Expected: Sequence []int, but got %T %+v", seqInt, seqInt)
 	}
 	path.Sequence, err = encoding.SliceInterfaceToInt(seqIntSlice)
 
 	return path, err
 }
 
-func (d DecoderV1) decodeUnboundRelationship(buffer *bytes.Buffer) (graph.UnboundRelationship, error) {
+func (d *DecoderV1) decodeUnboundRelationship() (graph.UnboundRelationship, error) {
 	rel := graph.UnboundRelationship{}
+	var ok bool
 
-	relIdentityInt, err := d.decode(buffer)
+	relIdentityInt, err := d.decode()
 	if err != nil {
 		return rel, err
 	}
-	rel.RelIdentity = relIdentityInt.(int64)
+	rel.RelIdentity, ok = relIdentityInt.(int64)
+	if !ok {
+		return rel, encoding.Malformed("Expected: RelIdentity int64, but got %T %+v", relIdentityInt, relIdentityInt)
+	}
 
-	var ok bool
-	typeInt, err := d.decode(buffer)
+	typeInt, err := d.decode()
 	if err != nil {
 		return rel, err
 	}
 	rel.Type, ok = typeInt.(string)
 	if !ok {
-		return rel, errors.New("Expected: Type string, but got %T %+v", typeInt, typeInt)
+		return rel, encoding.Malformed("Expected: Type string, but got %T %+v", typeInt, typeInt)
 	}
 
-	propertiesInt, err := d.decode(buffer)
+	propertiesInt, err := d.decode()
 	if err != nil {
 		return rel, err
 	}
 	rel.Properties, ok = propertiesInt.(map[string]interface{})
 	if !ok {
-		return rel, errors.New("Expected: Properties map[string]interface{}, but got %T %+v", propertiesInt, propertiesInt)
+		return rel, encoding.Malformed("Expected: Properties map[string]interface{}, but got %T %+v", propertiesInt, propertiesInt)
 	}
 
 	return rel, nil
 }
 
-func (d DecoderV1) decodeRecordMessage(buffer *bytes.Buffer) (messages.RecordMessage, error) {
-	fieldsInt, err := d.decode(buffer)
+func (d *DecoderV1) decodeRecordMessage() (messages.RecordMessage, error) {
+	fieldsInt, err := d.decode()
 	if err != nil {
 		return messages.RecordMessage{}, err
 	}
 	fields, ok := fieldsInt.([]interface{})
 	if !ok {
-		return messages.RecordMessage{}, errors.New("Expected: Fields []interface{}, but got %T %+v", fieldsInt, fieldsInt)
+		return messages.RecordMessage{}, encoding.Malformed("Expected: Fields []interface{}, but got %T %+v", fieldsInt, fieldsInt)
 	}
 
 	return messages.NewRecordMessage(fields), nil
 }
 
-func (d DecoderV1) decodeFailureMessage(buffer *bytes.Buffer) (messages.FailureMessage, error) {
-	metadataInt, err := d.decode(buffer)
+func (d *DecoderV1) decodeFailureMessage() (messages.FailureMessage, error) {
+	metadataInt, err := d.decode()
 	if err != nil {
 		return messages.FailureMessage{}, err
 	}
 	metadata, ok := metadataInt.(map[string]interface{})
 	if !ok {
-		return messages.FailureMessage{}, errors.New("Expected: Metadata map[string]interface{}, but got %T %+v", metadataInt, metadataInt)
+		return messages.FailureMessage{}, encoding.Malformed("Expected: Metadata map[string]interface{}, but got %T %+v", metadataInt, metadataInt)
 	}
 
 	return messages.NewFailureMessage(metadata), nil
 }
 
-func (d DecoderV1) decodeIgnoredMessage(buffer *bytes.Buffer) (messages.IgnoredMessage, error) {
+func (d *DecoderV1) decodeIgnoredMessage() (messages.IgnoredMessage, error) {
 	return messages.NewIgnoredMessage(), nil
 }
 
-func (d DecoderV1) decodeSuccessMessage(buffer *bytes.Buffer) (messages.SuccessMessage, error) {
-	metadataInt, err := d.decode(buffer)
+func (d *DecoderV1) decodeSuccessMessage() (messages.SuccessMessage, error) {
+	metadataInt, err := d.decode()
 	if err != nil {
 		return messages.SuccessMessage{}, err
 	}
 	metadata, ok := metadataInt.(map[string]interface{})
 	if !ok {
-		return messages.SuccessMessage{}, errors.New("Expected: Metadata map[string]interface{}, but got %T %+v", metadataInt, metadataInt)
+		return messages.SuccessMessage{}, encoding.Malformed("Expected: Metadata map[string]interface{}, but got %T %+v", metadataInt, metadataInt)
 	}
 
 	return messages.NewSuccessMessage(metadata), nil
 }
 
-func (d DecoderV1) decodeDiscardAllMessage(buffer *bytes.Buffer) (messages.DiscardAllMessage, error) {
+func (d *DecoderV1) decodeDiscardAllMessage() (messages.DiscardAllMessage, error) {
 	return messages.NewDiscardAllMessage(), nil
 }
 
-func (d DecoderV1) decodePullAllMessage(buffer *bytes.Buffer) (messages.PullAllMessage, error) {
+func (d *DecoderV1) decodePullAllMessage() (messages.PullAllMessage, error) {
 	return messages.NewPullAllMessage(), nil
 }
 
-func (d DecoderV1) decodeResetMessage(buffer *bytes.Buffer) (messages.ResetMessage, error) {
+func (d *DecoderV1) decodeResetMessage() (messages.ResetMessage, error) {
 	return messages.NewResetMessage(), nil
 }
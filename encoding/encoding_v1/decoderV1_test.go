@@ -1,7 +1,11 @@
 package encoding_v1
 
 import (
+	"bytes"
 	"testing"
+
+	"github.com/mindstand/go-bolt/structures/messages"
+	"github.com/stretchr/testify/require"
 )
 
 func TestDecoder_read(t *testing.T) {
@@ -16,3 +20,25 @@ func TestDecoder_read(t *testing.T) {
 
 	//
 }
+
+func TestDecoder_stream(t *testing.T) {
+	req := require.New(t)
+
+	// two messages on one stream, the second split over chunks
+	decoder := NewDecoder(bytes.NewReader([]byte{
+		0x00, 0x03, 0xb1, 0x70, 0xa0, 0x00, 0x00,
+		0x00, 0x02, 0xb1, 0x71, 0x00, 0x02, 0x91, 0x2a, 0x00, 0x00,
+	}))
+	defer decoder.Release()
+
+	decoded, err := decoder.Decode()
+	req.Nil(err)
+	req.Equal(messages.NewSuccessMessage(map[string]interface{}{}), decoded)
+
+	decoded, err = decoder.Decode()
+	req.Nil(err)
+	req.Equal(messages.NewRecordMessage([]interface{}{int64(42)}), decoded)
+
+	_, err = decoder.Decode()
+	req.NotNil(err)
+}
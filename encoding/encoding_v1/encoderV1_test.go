@@ -19,7 +19,7 @@ const (
 	maxKeySize = 10
 )
 
-func createNewTestEncoder() (EncoderV1, io.Reader) {
+func createNewTestEncoder() (*EncoderV1, io.Reader) {
 	buf := bytes.NewBuffer([]byte{})
 	return NewEncoder(buf, maxBufSize), buf
 }
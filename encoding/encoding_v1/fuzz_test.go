@@ -0,0 +1,45 @@
+package encoding_v1
+
+import (
+	"testing"
+
+	"github.com/mindstand/go-bolt/structures/graph"
+	"github.com/mindstand/go-bolt/structures/messages"
+)
+
+// fuzzSeeds are messages a server sends, covering every struct the decoder knows
+func fuzzSeeds() []interface{} {
+	node := graph.Node{NodeIdentity: 1, Labels: []string{"Person"}, Properties: map[string]interface{}{"name": "Alice"}}
+	return []interface{}{
+		messages.NewSuccessMessage(map[string]interface{}{"fields": []interface{}{"n"}, "result_available_after": int64(1)}),
+		messages.NewFailureMessage(map[string]interface{}{"code": "Neo.ClientError", "message": "oops"}),
+		messages.NewIgnoredMessage(),
+		messages.NewRecordMessage([]interface{}{
+			nil, true, int64(-17), int64(300), int64(1) << 40, 1.5, "text",
+			[]interface{}{int64(1), "two"},
+			map[string]interface{}{"key": []interface{}{}},
+			node,
+			graph.Relationship{RelIdentity: 2, StartNodeIdentity: 1, EndNodeIdentity: 3, Type: "KNOWS", Properties: map[string]interface{}{}},
+			graph.Path{
+				Nodes:         []graph.Node{node, node},
+				Relationships: []graph.UnboundRelationship{{RelIdentity: 2, Type: "KNOWS", Properties: map[string]interface{}{}}},
+				Sequence:      []int{1, 1},
+			},
+		}),
+	}
+}
+
+func FuzzUnmarshal(f *testing.F) {
+	for _, seed := range fuzzSeeds() {
+		data, err := Marshal(seed)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// anything goes as long as it doesn't panic
+		_, _ = Unmarshal(data)
+	})
+}
@@ -1,7 +1,20 @@
 package encoding_v2
 
 import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"strings"
 	"testing"
+
+	"github.com/mindstand/go-bolt/encoding"
+	"github.com/mindstand/go-bolt/encoding/encode_consts"
+	"github.com/mindstand/go-bolt/errors"
+	"github.com/mindstand/go-bolt/structures/graph"
+	"github.com/mindstand/go-bolt/structures/messages"
+
+	"github.com/stretchr/testify/require"
 )
 
 func TestDecoder_read(t *testing.T) {
@@ -16,3 +29,475 @@ func TestDecoder_read(t *testing.T) {
 
 	//
 }
+
+func TestDecoder_readSkipsNoop(t *testing.T) {
+	req := require.New(t)
+
+	// two NOOP chunks, then a message holding the tiny int 1
+	decoder := NewDecoder(bytes.NewBuffer([]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x01, 0x00, 0x00}))
+
+	decoded, err := decoder.Decode()
+	req.Nil(err)
+	req.EqualValues(1, decoded)
+}
+
+// recordStream encodes a query result of count RECORD messages ended by a SUCCESS, each record holding a
+// node, a string, an int and a list
+func recordStream(b *testing.B, count int) []byte {
+	var buf bytes.Buffer
+	encoder := NewEncoder(&buf, math.MaxUint16)
+
+	for i := 0; i < count; i++ {
+		node := graph.Node{
+			NodeIdentity: int64(i),
+			Labels:       []string{"Person"},
+			Properties: map[string]interface{}{
+				"name":  "person name",
+				"age":   int64(i % 100),
+				"score": 0.5,
+			},
+		}
+
+		record := messages.NewRecordMessage([]interface{}{node, "a string value", int64(i) << 20, []interface{}{int64(1), int64(2), int64(3)}})
+		if err := encoder.Encode(record); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	if err := encoder.Encode(messages.NewSuccessMessage(map[string]interface{}{"t_last": int64(1)})); err != nil {
+		b.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func BenchmarkDecodeRecordStream(b *testing.B) {
+	stream := recordStream(b, 1000)
+
+	b.SetBytes(int64(len(stream)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		decoder := NewDecoder(bytes.NewReader(stream))
+		for {
+			decoded, err := decoder.Decode()
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, ok := decoded.(messages.SuccessMessage); ok {
+				break
+			}
+		}
+	}
+}
+
+// BenchmarkDecodeRecordStreamBuffered decodes the same stream as BenchmarkDecodeRecordStream the way
+// decoders did before ChunkReader, as the baseline it is measured against
+func BenchmarkDecodeRecordStreamBuffered(b *testing.B) {
+	stream := recordStream(b, 1000)
+
+	b.SetBytes(int64(len(stream)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		decoder := bufferedDecoder{r: bytes.NewReader(stream)}
+		for {
+			decoded, err := decoder.Decode()
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, ok := decoded.(messages.SuccessMessage); ok {
+				break
+			}
+		}
+	}
+}
+
+// bufferedDecoder is the decoder before ChunkReader, cut down to the values recordStream holds. Every
+// message is copied out of its chunks into a bytes.Buffer, a chunk at a time, and markers and sizes are
+// read with binary.Read
+type bufferedDecoder struct {
+	r io.Reader
+}
+
+func (d bufferedDecoder) Decode() (interface{}, error) {
+	output := &bytes.Buffer{}
+	for {
+		lengthBytes := make([]byte, 2)
+		if _, err := io.ReadFull(d.r, lengthBytes); err != nil {
+			return nil, err
+		}
+
+		messageLen := binary.BigEndian.Uint16(lengthBytes)
+		if messageLen == 0 {
+			if output.Len() == 0 {
+				continue
+			}
+			break
+		}
+
+		data := make([]byte, messageLen)
+		if _, err := io.ReadFull(d.r, data); err != nil {
+			return nil, err
+		}
+		output.Write(data)
+	}
+
+	return d.decode(output)
+}
+
+func (d bufferedDecoder) decode(buffer *bytes.Buffer) (interface{}, error) {
+	marker, err := buffer.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	var markerInt int8
+	if err := binary.Read(bytes.NewBuffer([]byte{marker}), binary.BigEndian, &markerInt); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case marker == encode_consts.NilMarker:
+		return nil, nil
+	case markerInt >= -16 && markerInt <= 127:
+		return int64(markerInt), nil
+	case marker == encode_consts.Int8Marker:
+		var out int8
+		err := binary.Read(buffer, binary.BigEndian, &out)
+		return int64(out), err
+	case marker == encode_consts.Int16Marker:
+		var out int16
+		err := binary.Read(buffer, binary.BigEndian, &out)
+		return int64(out), err
+	case marker == encode_consts.Int32Marker:
+		var out int32
+		err := binary.Read(buffer, binary.BigEndian, &out)
+		return int64(out), err
+	case marker == encode_consts.Int64Marker:
+		var out int64
+		err := binary.Read(buffer, binary.BigEndian, &out)
+		return out, err
+	case marker == encode_consts.FloatMarker:
+		var out float64
+		err := binary.Read(buffer, binary.BigEndian, &out)
+		return out, err
+	case marker >= encode_consts.TinyStringMarker && marker <= encode_consts.TinyStringMarker+0x0F:
+		return string(buffer.Next(int(marker - encode_consts.TinyStringMarker))), nil
+	case marker == encode_consts.String8Marker:
+		var size uint8
+		if err := binary.Read(buffer, binary.BigEndian, &size); err != nil {
+			return nil, err
+		}
+		return string(buffer.Next(int(size))), nil
+	case marker >= encode_consts.TinySliceMarker && marker <= encode_consts.TinySliceMarker+0x0F:
+		slice := make([]interface{}, marker-encode_consts.TinySliceMarker)
+		for i := range slice {
+			if slice[i], err = d.decode(buffer); err != nil {
+				return nil, err
+			}
+		}
+		return slice, nil
+	case marker >= encode_consts.TinyMapMarker && marker <= encode_consts.TinyMapMarker+0x0F:
+		size := int(marker - encode_consts.TinyMapMarker)
+		mapp := make(map[string]interface{}, size)
+		for i := 0; i < size; i++ {
+			key, err := d.decode(buffer)
+			if err != nil {
+				return nil, err
+			}
+			if mapp[key.(string)], err = d.decode(buffer); err != nil {
+				return nil, err
+			}
+		}
+		return mapp, nil
+	case marker >= encode_consts.TinyStructMarker && marker <= encode_consts.TinyStructMarker+0x0F:
+		return d.decodeStruct(buffer, int(marker-encode_consts.TinyStructMarker))
+	default:
+		return nil, errors.New("Unrecognized marker byte!: %x", marker)
+	}
+}
+
+func (d bufferedDecoder) decodeStruct(buffer *bytes.Buffer, size int) (interface{}, error) {
+	signature, err := buffer.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]interface{}, size)
+	for i := range fields {
+		if fields[i], err = d.decode(buffer); err != nil {
+			return nil, err
+		}
+	}
+
+	switch signature {
+	case graph.NodeSignature:
+		labels, err := encoding.SliceInterfaceToString(fields[1].([]interface{}))
+		if err != nil {
+			return nil, err
+		}
+		return graph.Node{NodeIdentity: fields[0].(int64), Labels: labels, Properties: fields[2].(map[string]interface{})}, nil
+	case messages.RecordMessageSignature:
+		return messages.NewRecordMessage(fields[0].([]interface{})), nil
+	case messages.SuccessMessageSignature:
+		return messages.NewSuccessMessage(fields[0].(map[string]interface{})), nil
+	default:
+		return nil, errors.New("Unrecognized type decoding struct with signature %x", signature)
+	}
+}
+
+func TestDecoder_valuesAcrossChunks(t *testing.T) {
+	req := require.New(t)
+
+	// small chunks and strings bigger than the read buffer both split values over chunks
+	for _, tt := range []struct {
+		chunkSize uint16
+		text      string
+	}{
+		{3, "a string value"},
+		{16, strings.Repeat("neo4j", 10)},
+		{math.MaxUint16, strings.Repeat("neo4j", 20000)},
+	} {
+		record := messages.NewRecordMessage([]interface{}{tt.text, int64(1) << 40, 1.5, map[string]interface{}{"key": "value"}})
+
+		var buf bytes.Buffer
+		encoder := NewEncoder(&buf, tt.chunkSize)
+		req.Nil(encoder.Encode(record))
+		req.Nil(encoder.Encode(messages.NewSuccessMessage(map[string]interface{}{})))
+
+		decoder := NewDecoder(&buf)
+		decoded, err := decoder.Decode()
+		req.Nil(err, "chunk size %d", tt.chunkSize)
+		req.Equal(record, decoded)
+
+		decoded, err = decoder.Decode()
+		req.Nil(err)
+		req.IsType(messages.SuccessMessage{}, decoded)
+		decoder.Release()
+	}
+}
+
+func TestDecoder_unsignedSizes(t *testing.T) {
+	req := require.New(t)
+
+	// sizes are unsigned, a String8 can hold 255 bytes
+	text := strings.Repeat("a", 200)
+	data := append([]byte{0x00, 0xca, 0xd0, 0xc8}, []byte(text)...)
+	data = append(data, 0x00, 0x00)
+
+	decoded, err := NewDecoder(bytes.NewReader(data)).Decode()
+	req.Nil(err)
+	req.Equal(text, decoded)
+}
+
+func TestDecoder_failureKeepsStreamInStep(t *testing.T) {
+	req := require.New(t)
+
+	// a message with an unknown marker, then a message holding the tiny int 1
+	decoder := NewDecoder(bytes.NewReader([]byte{0x00, 0x03, 0x92, 0xc4, 0x01, 0x00, 0x00, 0x00, 0x01, 0x01, 0x00, 0x00}))
+
+	_, err := decoder.Decode()
+	req.NotNil(err)
+
+	decoded, err := decoder.Decode()
+	req.Nil(err)
+	req.EqualValues(1, decoded)
+
+	// a message ending in the middle of a value
+	_, err = NewDecoder(bytes.NewReader([]byte{0x00, 0x02, 0x92, 0x01, 0x00, 0x00})).Decode()
+	req.NotNil(err)
+
+	// map keys have to be strings
+	_, err = Unmarshal([]byte{0x00, 0x03, 0xa1, 0x01, 0x01, 0x00, 0x00})
+	req.NotNil(err)
+
+	decoder.Release()
+	_, err = decoder.Decode()
+	req.NotNil(err)
+}
+
+// innerMost unwraps the driver's errors down to the error the decoder returned
+func innerMost(err error) error {
+	if e, ok := err.(*errors.Error); ok {
+		return e.InnerMost()
+	}
+	return err
+}
+
+func TestDecoder_partialLimits(t *testing.T) {
+	req := require.New(t)
+
+	nested := func(depth int) []byte {
+		data := bytes.Repeat([]byte{0x91}, depth)
+		return append(data, 0x01)
+	}
+	decode := func(limits encoding.Limits, data []byte) error {
+		// one chunk holding data, then the end of the message
+		message := append([]byte{byte(len(data) >> 8), byte(len(data))}, data...)
+		message = append(message, 0x00, 0x00)
+
+		decoder := NewDecoder(bytes.NewReader(message))
+		defer decoder.Release()
+		decoder.SetLimits(limits)
+
+		_, err := decoder.Decode()
+		return err
+	}
+
+	// setting the message size keeps the default nesting depth
+	err := decode(encoding.Limits{MaxMessageSize: 1 << 20}, nested(encoding.DefaultLimits.MaxDepth+1))
+	limitErr, ok := innerMost(err).(*encoding.LimitError)
+	req.True(ok, "expected a LimitError, got %v", err)
+	req.Equal("nesting depth", limitErr.Limit)
+	req.Nil(decode(encoding.Limits{MaxMessageSize: 1 << 20}, nested(encoding.DefaultLimits.MaxDepth)))
+
+	// NoLimit turns it off
+	req.Nil(decode(encoding.Limits{MaxDepth: encoding.NoLimit}, nested(encoding.DefaultLimits.MaxDepth+1)))
+
+	req.Equal(encoding.DefaultLimits, encoding.Limits{}.WithDefaults())
+}
+
+func TestDecoder_limits(t *testing.T) {
+	nested := func(depth int) interface{} {
+		var value interface{} = int64(1)
+		for i := 0; i < depth; i++ {
+			value = []interface{}{value}
+		}
+		return value
+	}
+
+	for _, tt := range []struct {
+		name   string
+		limits encoding.Limits
+		value  interface{}
+		limit  string
+	}{
+		{name: "collection length", limits: encoding.Limits{MaxCollectionLength: 2}, value: []interface{}{int64(1), int64(2), int64(3)}, limit: "collection length"},
+		{name: "map length", limits: encoding.Limits{MaxCollectionLength: 1}, value: map[string]interface{}{"a": int64(1), "b": int64(2)}, limit: "collection length"},
+		{name: "nesting depth", limits: encoding.Limits{MaxDepth: 4}, value: nested(5), limit: "nesting depth"},
+		{name: "message size", limits: encoding.Limits{MaxMessageSize: 10}, value: strings.Repeat("a", 20), limit: "message size"},
+		{name: "message size over chunks", limits: encoding.Limits{MaxMessageSize: 10}, value: []interface{}{"abcd", "efgh", "ijkl"}, limit: "message size"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := require.New(t)
+
+			var buf bytes.Buffer
+			encoder := NewEncoder(&buf, 4)
+			req.Nil(encoder.Encode(tt.value))
+			req.Nil(encoder.Encode("next"))
+			encoder.Release()
+
+			decoder := NewDecoder(&buf)
+			defer decoder.Release()
+			decoder.SetLimits(tt.limits)
+
+			_, err := decoder.Decode()
+			limitErr, ok := innerMost(err).(*encoding.LimitError)
+			req.True(ok, "expected a LimitError, got %v", err)
+			req.Equal(tt.limit, limitErr.Limit)
+
+			// the rest of the message is skipped, the next one reads fine
+			decoded, err := decoder.Decode()
+			req.Nil(err)
+			req.Equal("next", decoded)
+
+			// and without limits the value decodes
+			var again bytes.Buffer
+			encoder = NewEncoder(&again, 4)
+			req.Nil(encoder.Encode(tt.value))
+			encoder.Release()
+
+			decoded, err = Unmarshal(again.Bytes())
+			req.Nil(err)
+			req.Equal(tt.value, decoded)
+		})
+	}
+}
+
+func TestDecoder_malformed(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		data []byte
+	}{
+		// sizes are only trusted as far as there are items to read
+		{name: "huge list", data: []byte{0x00, 0x05, 0xd6, 0xff, 0xff, 0xff, 0xff, 0x00, 0x00}},
+		{name: "huge map", data: []byte{0x00, 0x05, 0xda, 0xff, 0xff, 0xff, 0xff, 0x00, 0x00}},
+		{name: "huge string", data: []byte{0x00, 0x05, 0xd2, 0xff, 0xff, 0xff, 0xff, 0x00, 0x00}},
+		{name: "node id is a string", data: []byte{0x00, 0x06, 0xb3, 0x4e, 0x81, 0x61, 0x90, 0xa0, 0x00, 0x00}},
+		{name: "relationship end is nil", data: []byte{0x00, 0x08, 0xb5, 0x52, 0x01, 0x02, 0xc0, 0x80, 0xa0, 0x00, 0x00}},
+		{name: "path sequence of strings", data: []byte{0x00, 0x07, 0xb3, 0x50, 0x90, 0x90, 0x91, 0x81, 0x61, 0x00, 0x00}},
+		{name: "unknown signature", data: []byte{0x00, 0x02, 0xb0, 0x01, 0x00, 0x00}},
+		{name: "unknown marker", data: []byte{0x00, 0x01, 0xc7, 0x00, 0x00}},
+		{name: "map key is an int", data: []byte{0x00, 0x03, 0xa1, 0x01, 0x01, 0x00, 0x00}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := require.New(t)
+
+			req.NotPanics(func() {
+				_, err := Unmarshal(tt.data)
+				req.NotNil(err)
+			})
+		})
+	}
+
+	// fields that don't match the signature are a MalformedError
+	_, err := Unmarshal([]byte{0x00, 0x06, 0xb3, 0x4e, 0x81, 0x61, 0x90, 0xa0, 0x00, 0x00})
+	_, ok := innerMost(err).(*encoding.MalformedError)
+	require.True(t, ok, "expected a MalformedError, got %v", err)
+}
+
+func TestDecoder_pathSegments(t *testing.T) {
+	req := require.New(t)
+
+	// MATCH p = (:Person {name: "Alice"})-[:KNOWS]->(:Person {name: "Bob"})<-[:LIKES]-(:Person {name: "Carol"}) RETURN p
+	// as neo4j sends it, each node and relationship once and a sequence walking them
+	data := []byte{
+		0x00, 0x63,
+		0xb1, 0x71, 0x91, // RECORD [
+		0xb3, 0x50, // Path
+		0x93, // nodes
+		0xb3, 0x4e, 0x01, 0x91, 0x86, 'P', 'e', 'r', 's', 'o', 'n', 0xa1, 0x84, 'n', 'a', 'm', 'e', 0x85, 'A', 'l', 'i', 'c', 'e',
+		0xb3, 0x4e, 0x02, 0x91, 0x86, 'P', 'e', 'r', 's', 'o', 'n', 0xa1, 0x84, 'n', 'a', 'm', 'e', 0x83, 'B', 'o', 'b',
+		0xb3, 0x4e, 0x03, 0x91, 0x86, 'P', 'e', 'r', 's', 'o', 'n', 0xa1, 0x84, 'n', 'a', 'm', 'e', 0x85, 'C', 'a', 'r', 'o', 'l',
+		0x92, // relationships, unbound
+		0xb3, 0x72, 0x0a, 0x85, 'K', 'N', 'O', 'W', 'S', 0xa0,
+		0xb3, 0x72, 0x0b, 0x85, 'L', 'I', 'K', 'E', 'S', 0xa0,
+		0x94, 0x01, 0x01, 0xfe, 0x02, // sequence, KNOWS forwards to node 1 then LIKES backwards to node 2
+		0x00, 0x00,
+	}
+
+	decoded, err := Unmarshal(data)
+	req.Nil(err)
+	record, ok := decoded.(messages.RecordMessage)
+	req.True(ok)
+	path, ok := record.Fields[0].(graph.Path)
+	req.True(ok)
+
+	segments, err := path.Segments()
+	req.Nil(err)
+	req.Len(segments, 2)
+
+	req.Equal("Alice", segments[0].Start.Properties["name"])
+	req.Equal(graph.Relationship{RelIdentity: 10, StartNodeIdentity: 1, EndNodeIdentity: 2, Type: "KNOWS", Properties: map[string]interface{}{}}, segments[0].Relationship)
+	req.Equal("Bob", segments[0].End.Properties["name"])
+	req.False(segments[0].Reversed)
+
+	req.Equal("Bob", segments[1].Start.Properties["name"])
+	req.Equal(graph.Relationship{RelIdentity: 11, StartNodeIdentity: 3, EndNodeIdentity: 2, Type: "LIKES", Properties: map[string]interface{}{}}, segments[1].Relationship)
+	req.Equal("Carol", segments[1].End.Properties["name"])
+	req.True(segments[1].Reversed)
+
+	req.EqualValues(1, path.Start().NodeIdentity)
+	req.EqualValues(3, path.End().NodeIdentity)
+	req.Equal(2, path.Len())
+
+	// and the path survives a round trip
+	encoded, err := Marshal(path)
+	req.Nil(err)
+	again, err := Unmarshal(encoded)
+	req.Nil(err)
+	req.True(path.Equal(again.(graph.Path)))
+}
@@ -1,9 +1,8 @@
 package encoding_v2
 
 import (
-	"encoding/binary"
+	"github.com/mindstand/go-bolt/encoding"
 	"github.com/mindstand/go-bolt/encoding/encode_consts"
-	"github.com/mindstand/go-bolt/log"
 	"github.com/mindstand/gotime"
 	"io"
 	"math"
@@ -14,6 +13,7 @@ import (
 
 	"github.com/mindstand/go-bolt/errors"
 	"github.com/mindstand/go-bolt/structures"
+	"github.com/mindstand/go-bolt/structures/types"
 )
 
 // EncoderV2 encodes objects of different types to the given stream.
@@ -25,94 +25,80 @@ import (
 // map[string]interface{} and []interface{} are supported.
 // The interface for maps and slices may be more permissive in the future.
 type EncoderV2 struct {
-	w         io.Writer
-	buf       *bytes.Buffer
-	chunkSize uint16
+	w *encoding.ChunkWriter
 }
 
-// NewEncoder Creates a new EncoderV2 object
-func NewEncoder(w io.Writer, chunkSize uint16) EncoderV2 {
-	return EncoderV2{
-		w:         w,
-		buf:       &bytes.Buffer{},
-		chunkSize: chunkSize,
+// NewEncoder Creates a new EncoderV2 object writing to w. Messages are queued by Buffer and sent together by
+// Flush, keep one encoder for a stream to reuse its buffers. Release hands them back once the stream is done with
+func NewEncoder(w io.Writer, chunkSize uint16) *EncoderV2 {
+	return &EncoderV2{
+		w: encoding.NewChunkWriter(w, chunkSize),
 	}
 }
 
 // Marshal is used to marshal an object to the bolt interface encoded bytes
 func Marshal(v interface{}) ([]byte, error) {
 	x := &bytes.Buffer{}
-	err := NewEncoder(x, math.MaxUint16).Encode(v)
+	encoder := NewEncoder(x, math.MaxUint16)
+	defer encoder.Release()
+
+	err := encoder.Encode(v)
 	return x.Bytes(), err
 }
 
-// write writes to the writer.  Buffers the writes using chunkSize.
-func (e EncoderV2) Write(p []byte) (n int, err error) {
-	//log.Trace("in encode write")
-	//log.Trace(fmt.Sprintf("len writing is %v", len(p)))
-	//log.Trace(fmt.Sprintf("%x", p))
+// Release hands the encoder's buffers back to the pool, the encoder can't be used after
+func (e *EncoderV2) Release() {
+	if e.w != nil {
+		e.w.Release()
+		e.w = nil
+	}
+}
 
-	n, err = e.buf.Write(p)
+// Write adds p to the message being encoded
+func (e *EncoderV2) Write(p []byte) (n int, err error) {
+	n, err = e.w.Write(p)
 	if err != nil {
 		err = errors.Wrap(err, "An error occurred writing to encoder temp buffer")
-		return n, err
-	}
-
-	length := e.buf.Len()
-	for length >= int(e.chunkSize) {
-		if err := binary.Write(e.w, binary.BigEndian, e.chunkSize); err != nil {
-			return 0, errors.Wrap(err, "An error occured writing chunksize")
-		}
-
-		numWritten, err := e.w.Write(e.buf.Next(int(e.chunkSize)))
-		if err != nil {
-			err = errors.Wrap(err, "An error occured writing a chunk")
-		}
-
-		return numWritten, err
 	}
 
-	return n, nil
+	return n, err
 }
 
-// flush finishes the encoding stream by flushing it to the writer
-func (e EncoderV2) flush() error {
-	length := e.buf.Len()
-	if length > 0 {
-		log.TraceBytesFromBuf(e.buf)
-
-		if err := binary.Write(e.w, binary.BigEndian, uint16(length)); err != nil {
-			return errors.Wrap(err, "An error occured writing length bytes during flush")
-		}
-
-		if _, err := e.buf.WriteTo(e.w); err != nil {
-			return errors.Wrap(err, "An error occured writing message bytes during flush")
-		}
+// Buffer encodes an object as a message and queues it for the next Flush. Nothing is queued when encoding fails
+func (e *EncoderV2) Buffer(iVal interface{}) error {
+	if e.w == nil {
+		return errors.Wrap(errors.ErrClosed, "encoder has been released")
 	}
 
-	_, err := e.w.Write(encode_consts.EndMessage)
-	if err != nil {
-		return errors.Wrap(err, "An error occurred ending encoding message")
+	if err := e.encode(iVal); err != nil {
+		e.w.Abort()
+		return err
 	}
-	e.buf.Reset()
 
+	e.w.End()
 	return nil
 }
 
-// Encode encodes an object to the stream
-func (e EncoderV2) Encode(iVal interface{}) error {
+// Flush sends the queued messages to the stream in a single write
+func (e *EncoderV2) Flush() error {
+	if e.w == nil {
+		return errors.Wrap(errors.ErrClosed, "encoder has been released")
+	}
 
-	err := e.encode(iVal)
-	if err != nil {
+	return e.w.Flush()
+}
+
+// Encode encodes an object to the stream, sending it along with anything queued before
+func (e *EncoderV2) Encode(iVal interface{}) error {
+	if err := e.Buffer(iVal); err != nil {
 		return err
 	}
 
-	// Whatever is left in the buffer for the chunk at the end, write it out
-	return e.flush()
+	return e.Flush()
 }
 
 // Encode encodes an object to the stream
-func (e EncoderV2) encode(iVal interface{}) error {
+func (e *EncoderV2) encode(iVal interface{}) error {
 
 	var err error
 	switch val := iVal.(type) {
@@ -162,8 +148,12 @@ func (e EncoderV2) encode(iVal interface{}) error {
 	case gotime.LocalTime:
 		err = e.encodeLocalTime(val)
 	case time.Time:
-		err = e.encodeTime(val)
+		err = e.encodeDateTime(types.NewDateTime(val))
+	case types.DateTime:
+		err = e.encodeDateTime(val)
 	case time.Duration:
+		err = e.encodeDuration(types.DurationFromTime(val))
+	case types.Duration:
 		err = e.encodeDuration(val)
 	case structures.Structure:
 		err = e.encodeStructure(val)
@@ -199,13 +189,13 @@ func roundTime(input float64) int {
 	return int(i)
 }
 
-func (e EncoderV2) encodeDate(d gotime.Date) error {
-	_, err := e.Write([]byte{byte(encode_consts.TinyStructMarker | encode_consts.DateStructSize)})
+func (e *EncoderV2) encodeDate(d gotime.Date) error {
+	err := e.w.WriteByte(byte(encode_consts.TinyStructMarker | encode_consts.DateStructSize))
 	if err != nil {
 		return err
 	}
 
-	_, err = e.Write([]byte{encode_consts.DateSignature})
+	err = e.w.WriteByte(encode_consts.DateSignature)
 	if err != nil {
 		return err
 	}
@@ -213,13 +203,13 @@ func (e EncoderV2) encodeDate(d gotime.Date) error {
 	return e.encode(int64(d.ToEpochDays()))
 }
 
-func (e EncoderV2) encodeClock(c gotime.Clock) error {
-	_, err := e.Write([]byte{byte(encode_consts.TinyStructMarker | encode_consts.TimeStructSize)})
+func (e *EncoderV2) encodeClock(c gotime.Clock) error {
+	err := e.w.WriteByte(byte(encode_consts.TinyStructMarker | encode_consts.TimeStructSize))
 	if err != nil {
 		return err
 	}
 
-	_, err = e.Write([]byte{encode_consts.TimeSignature})
+	err = e.w.WriteByte(encode_consts.TimeSignature)
 	if err != nil {
 		return err
 	}
@@ -234,13 +224,13 @@ func (e EncoderV2) encodeClock(c gotime.Clock) error {
 	return e.encode(offset)
 }
 
-func (e EncoderV2) encodeLocalClock(lc gotime.LocalClock) error {
-	_, err := e.Write([]byte{byte(encode_consts.TinyStructMarker | encode_consts.LocalTimeStructSize)})
+func (e *EncoderV2) encodeLocalClock(lc gotime.LocalClock) error {
+	err := e.w.WriteByte(byte(encode_consts.TinyStructMarker | encode_consts.LocalTimeStructSize))
 	if err != nil {
 		return err
 	}
 
-	_, err = e.Write([]byte{encode_consts.LocalTimeSignature})
+	err = e.w.WriteByte(encode_consts.LocalTimeSignature)
 	if err != nil {
 		return err
 	}
@@ -248,13 +238,13 @@ func (e EncoderV2) encodeLocalClock(lc gotime.LocalClock) error {
 	return e.encode(lc.ToDayNano())
 }
 
-func (e EncoderV2) encodeLocalTime(lt gotime.LocalTime) error {
-	_, err := e.Write([]byte{byte(encode_consts.TinyStructMarker | encode_consts.LocalDateTimeStructSize)})
+func (e *EncoderV2) encodeLocalTime(lt gotime.LocalTime) error {
+	err := e.w.WriteByte(byte(encode_consts.TinyStructMarker | encode_consts.LocalDateTimeStructSize))
 	if err != nil {
 		return err
 	}
 
-	_, err = e.Write([]byte{encode_consts.LocalDateTimeSignature})
+	err = e.w.WriteByte(encode_consts.LocalDateTimeSignature)
 	if err != nil {
 		return err
 	}
@@ -271,14 +261,26 @@ func (e EncoderV2) encodeLocalTime(lt gotime.LocalTime) error {
 	return e.encode(t.Nanosecond())
 }
 
-// encodeTime encodes native golang time.Time object as neo DateTime
-func (e EncoderV2) encodeTime(t time.Time) error {
-	_, err := e.Write([]byte{byte(encode_consts.TinyStructMarker | encode_consts.DateTimeStructSize)})
+// encodeDateTime encodes a neo DateTime, named zones are sent by id and everything else as an offset
+func (e *EncoderV2) encodeDateTime(dt types.DateTime) error {
+	t := dt.Time
+	signature := encode_consts.DateTimeWithZoneOffsetSignature
+	if dt.HasZoneId() {
+		loc, err := time.LoadLocation(dt.ZoneId)
+		if err != nil {
+			return errors.Wrap(err, "unable to load zone info for [%s]", dt.ZoneId)
+		}
+
+		t = t.In(loc)
+		signature = encode_consts.DateTimeWithZoneIdSignature
+	}
+
+	err := e.w.WriteByte(byte(encode_consts.TinyStructMarker | encode_consts.DateTimeStructSize))
 	if err != nil {
 		return err
 	}
 
-	_, err = e.Write([]byte{encode_consts.DateTimeWithZoneOffsetSignature})
+	err = e.w.WriteByte(signature)
 	if err != nil {
 		return err
 	}
@@ -299,106 +301,106 @@ func (e EncoderV2) encodeTime(t time.Time) error {
 		return err
 	}
 
+	if dt.HasZoneId() {
+		return e.encode(dt.ZoneId)
+	}
+
 	return e.encode(offset)
 }
 
-func (e EncoderV2) encodeDuration(d time.Duration) error {
-	months := d.Seconds() / 2600640
-	days := d.Seconds() / 86400
-	seconds := d.Seconds()
-	nano := d.Nanoseconds()
-
-	_, err := e.Write([]byte{byte(encode_consts.TinyStructMarker | encode_consts.DurationTimeStructSize)})
+// encodeDuration encodes a neo duration, months, days, seconds and nanos are all integers
+func (e *EncoderV2) encodeDuration(d types.Duration) error {
+	err := e.w.WriteByte(byte(encode_consts.TinyStructMarker | encode_consts.DurationTimeStructSize))
 	if err != nil {
 		return err
 	}
 
-	_, err = e.Write([]byte{encode_consts.DurationSignature})
+	err = e.w.WriteByte(encode_consts.DurationSignature)
 	if err != nil {
 		return err
 	}
 
-	err = e.encode(months)
+	err = e.encodeInt(d.Months)
 	if err != nil {
 		return err
 	}
 
-	err = e.encode(days)
+	err = e.encodeInt(d.Days)
 	if err != nil {
 		return err
 	}
 
-	err = e.encode(seconds)
+	err = e.encodeInt(d.Seconds)
 	if err != nil {
 		return err
 	}
 
-	return e.encode(nano)
+	return e.encodeInt(d.Nanos)
 }
 
-func (e EncoderV2) encodeNil() error {
-	_, err := e.Write([]byte{encode_consts.NilMarker})
+func (e *EncoderV2) encodeNil() error {
+	err := e.w.WriteByte(encode_consts.NilMarker)
 	return err
 }
 
-func (e EncoderV2) encodeBool(val bool) error {
+func (e *EncoderV2) encodeBool(val bool) error {
 	var err error
 	if val {
-		_, err = e.Write([]byte{encode_consts.TrueMarker})
+		err = e.w.WriteByte(encode_consts.TrueMarker)
 	} else {
-		_, err = e.Write([]byte{encode_consts.FalseMarker})
+		err = e.w.WriteByte(encode_consts.FalseMarker)
 	}
 	return err
 }
 
-func (e EncoderV2) encodeInt(val int64) error {
+func (e *EncoderV2) encodeInt(val int64) error {
 	var err error
 	switch {
 	case val >= math.MinInt64 && val < math.MinInt32:
 		// Write as INT_64
-		if _, err = e.Write([]byte{encode_consts.Int64Marker}); err != nil {
+		if err = e.w.WriteByte(encode_consts.Int64Marker); err != nil {
 			return err
 		}
-		err = binary.Write(e, binary.BigEndian, val)
+		err = e.w.WriteUint64(uint64(val))
 	case val >= math.MinInt32 && val < math.MinInt16:
 		// Write as INT_32
-		if _, err = e.Write([]byte{encode_consts.Int32Marker}); err != nil {
+		if err = e.w.WriteByte(encode_consts.Int32Marker); err != nil {
 			return err
 		}
-		err = binary.Write(e, binary.BigEndian, int32(val))
+		err = e.w.WriteUint32(uint32(val))
 	case val >= math.MinInt16 && val < math.MinInt8:
 		// Write as INT_16
-		if _, err = e.Write([]byte{encode_consts.Int16Marker}); err != nil {
+		if err = e.w.WriteByte(encode_consts.Int16Marker); err != nil {
 			return err
 		}
-		err = binary.Write(e, binary.BigEndian, int16(val))
+		err = e.w.WriteUint16(uint16(val))
 	case val >= math.MinInt8 && val < -16:
 		// Write as INT_8
-		if _, err = e.Write([]byte{encode_consts.Int8Marker}); err != nil {
+		if err = e.w.WriteByte(encode_consts.Int8Marker); err != nil {
 			return err
 		}
-		err = binary.Write(e, binary.BigEndian, int8(val))
+		err = e.w.WriteByte(byte(val))
 	case val >= -16 && val <= math.MaxInt8:
 		// Write as TINY_INT
-		err = binary.Write(e, binary.BigEndian, int8(val))
+		err = e.w.WriteByte(byte(val))
 	case val > math.MaxInt8 && val <= math.MaxInt16:
 		// Write as INT_16
-		if _, err = e.Write([]byte{encode_consts.Int16Marker}); err != nil {
+		if err = e.w.WriteByte(encode_consts.Int16Marker); err != nil {
 			return err
 		}
-		err = binary.Write(e, binary.BigEndian, int16(val))
+		err = e.w.WriteUint16(uint16(val))
 	case val > math.MaxInt16 && val <= math.MaxInt32:
 		// Write as INT_32
-		if _, err = e.Write([]byte{encode_consts.Int32Marker}); err != nil {
+		if err = e.w.WriteByte(encode_consts.Int32Marker); err != nil {
 			return err
 		}
-		err = binary.Write(e, binary.BigEndian, int32(val))
+		err = e.w.WriteUint32(uint32(val))
 	case val > math.MaxInt32 && val <= math.MaxInt64:
 		// Write as INT_64
-		if _, err = e.Write([]byte{encode_consts.Int64Marker}); err != nil {
+		if err = e.w.WriteByte(encode_consts.Int64Marker); err != nil {
 			return err
 		}
-		err = binary.Write(e, binary.BigEndian, val)
+		err = e.w.WriteUint64(uint64(val))
 	default:
 		return errors.New("Int too long to write: %d", val)
 	}
@@ -408,12 +410,12 @@ func (e EncoderV2) encodeInt(val int64) error {
 	return err
 }
 
-func (e EncoderV2) encodeFloat(val float64) error {
-	if _, err := e.Write([]byte{encode_consts.FloatMarker}); err != nil {
+func (e *EncoderV2) encodeFloat(val float64) error {
+	if err := e.w.WriteByte(encode_consts.FloatMarker); err != nil {
 		return err
 	}
 
-	err := binary.Write(e, binary.BigEndian, val)
+	err := e.w.WriteFloat64(val)
 	if err != nil {
 		return errors.Wrap(err, "An error occured writing a float to bolt")
 	}
@@ -421,73 +423,71 @@ func (e EncoderV2) encodeFloat(val float64) error {
 	return err
 }
 
-func (e EncoderV2) encodeString(val string) error {
+func (e *EncoderV2) encodeString(val string) error {
 	var err error
-	bytes := []byte(val)
-
-	length := len(bytes)
+	length := len(val)
 	switch {
 	case length <= 15:
-		if _, err = e.Write([]byte{byte(encode_consts.TinyStringMarker | length)}); err != nil {
+		if err = e.w.WriteByte(byte(encode_consts.TinyStringMarker | length)); err != nil {
 			return err
 		}
-		_, err = e.Write(bytes)
+		_, err = e.w.WriteString(val)
 	case length > 15 && length <= math.MaxUint8:
-		if _, err = e.Write([]byte{encode_consts.String8Marker}); err != nil {
+		if err = e.w.WriteByte(encode_consts.String8Marker); err != nil {
 			return err
 		}
-		if err = binary.Write(e, binary.BigEndian, int8(length)); err != nil {
+		if err = e.w.WriteByte(byte(length)); err != nil {
 			return err
 		}
-		_, err = e.Write(bytes)
+		_, err = e.w.WriteString(val)
 	case length > math.MaxUint8 && length <= math.MaxUint16:
-		if _, err = e.Write([]byte{encode_consts.String16Marker}); err != nil {
+		if err = e.w.WriteByte(encode_consts.String16Marker); err != nil {
 			return err
 		}
-		if err = binary.Write(e, binary.BigEndian, int16(length)); err != nil {
+		if err = e.w.WriteUint16(uint16(length)); err != nil {
 			return err
 		}
-		_, err = e.Write(bytes)
+		_, err = e.w.WriteString(val)
 	case length > math.MaxUint16 && int64(length) <= math.MaxUint32:
-		if _, err = e.Write([]byte{encode_consts.String32Marker}); err != nil {
+		if err = e.w.WriteByte(encode_consts.String32Marker); err != nil {
 			return err
 		}
-		if err = binary.Write(e, binary.BigEndian, int32(length)); err != nil {
+		if err = e.w.WriteUint32(uint32(length)); err != nil {
 			return err
 		}
-		_, err = e.Write(bytes)
+		_, err = e.w.WriteString(val)
 	default:
 		return errors.New("String too long to write: %s", val)
 	}
 	return err
 }
 
-func (e EncoderV2) encodeSlice(val []interface{}) error {
+func (e *EncoderV2) encodeSlice(val []interface{}) error {
 	length := len(val)
 	switch {
 	case length <= 15:
-		if _, err := e.Write([]byte{byte(encode_consts.TinySliceMarker | length)}); err != nil {
+		if err := e.w.WriteByte(byte(encode_consts.TinySliceMarker | length)); err != nil {
 			return err
 		}
 	case length > 15 && length <= math.MaxUint8:
-		if _, err := e.Write([]byte{encode_consts.Slice8Marker}); err != nil {
+		if err := e.w.WriteByte(encode_consts.Slice8Marker); err != nil {
 			return err
 		}
-		if err := binary.Write(e, binary.BigEndian, int8(length)); err != nil {
+		if err := e.w.WriteByte(byte(length)); err != nil {
 			return err
 		}
 	case length > math.MaxUint8 && length <= math.MaxUint16:
-		if _, err := e.Write([]byte{encode_consts.Slice16Marker}); err != nil {
+		if err := e.w.WriteByte(encode_consts.Slice16Marker); err != nil {
 			return err
 		}
-		if err := binary.Write(e, binary.BigEndian, int16(length)); err != nil {
+		if err := e.w.WriteUint16(uint16(length)); err != nil {
 			return err
 		}
 	case length >= math.MaxUint16 && int64(length) <= math.MaxUint32:
-		if _, err := e.Write([]byte{encode_consts.Slice32Marker}); err != nil {
+		if err := e.w.WriteByte(encode_consts.Slice32Marker); err != nil {
 			return err
 		}
-		if err := binary.Write(e, binary.BigEndian, int32(length)); err != nil {
+		if err := e.w.WriteUint32(uint32(length)); err != nil {
 			return err
 		}
 	default:
@@ -504,32 +504,32 @@ func (e EncoderV2) encodeSlice(val []interface{}) error {
 	return nil
 }
 
-func (e EncoderV2) encodeMap(val map[string]interface{}) error {
+func (e *EncoderV2) encodeMap(val map[string]interface{}) error {
 	length := len(val)
 	switch {
 	case length <= 15:
-		if _, err := e.Write([]byte{byte(encode_consts.TinyMapMarker | length)}); err != nil {
+		if err := e.w.WriteByte(byte(encode_consts.TinyMapMarker | length)); err != nil {
 			return err
 		}
 	case length > 15 && length <= math.MaxUint8:
-		if _, err := e.Write([]byte{encode_consts.Map8Marker}); err != nil {
+		if err := e.w.WriteByte(encode_consts.Map8Marker); err != nil {
 			return err
 		}
-		if err := binary.Write(e, binary.BigEndian, int8(length)); err != nil {
+		if err := e.w.WriteByte(byte(length)); err != nil {
 			return err
 		}
 	case length > math.MaxUint8 && length <= math.MaxUint16:
-		if _, err := e.Write([]byte{encode_consts.Map16Marker}); err != nil {
+		if err := e.w.WriteByte(encode_consts.Map16Marker); err != nil {
 			return err
 		}
-		if err := binary.Write(e, binary.BigEndian, int16(length)); err != nil {
+		if err := e.w.WriteUint16(uint16(length)); err != nil {
 			return err
 		}
 	case length >= math.MaxUint16 && int64(length) <= math.MaxUint32:
-		if _, err := e.Write([]byte{encode_consts.Map32Marker}); err != nil {
+		if err := e.w.WriteByte(encode_consts.Map32Marker); err != nil {
 			return err
 		}
-		if err := binary.Write(e, binary.BigEndian, int32(length)); err != nil {
+		if err := e.w.WriteUint32(uint32(length)); err != nil {
 			return err
 		}
 	default:
@@ -549,34 +549,34 @@ func (e EncoderV2) encodeMap(val map[string]interface{}) error {
 	return nil
 }
 
-func (e EncoderV2) encodeStructure(val structures.Structure) error {
+func (e *EncoderV2) encodeStructure(val structures.Structure) error {
 
 	fields := val.AllFields()
 	length := len(fields)
 	switch {
 	case length <= 15:
-		if _, err := e.Write([]byte{byte(encode_consts.TinyStructMarker | length)}); err != nil {
+		if err := e.w.WriteByte(byte(encode_consts.TinyStructMarker | length)); err != nil {
 			return err
 		}
 	case length > 15 && length <= math.MaxUint8:
-		if _, err := e.Write([]byte{encode_consts.Struct8Marker}); err != nil {
+		if err := e.w.WriteByte(encode_consts.Struct8Marker); err != nil {
 			return err
 		}
-		if err := binary.Write(e, binary.BigEndian, int8(length)); err != nil {
+		if err := e.w.WriteByte(byte(length)); err != nil {
 			return err
 		}
 	case length > math.MaxUint8 && length <= math.MaxUint16:
-		if _, err := e.Write([]byte{encode_consts.Struct16Marker}); err != nil {
+		if err := e.w.WriteByte(encode_consts.Struct16Marker); err != nil {
 			return err
 		}
-		if err := binary.Write(e, binary.BigEndian, int16(length)); err != nil {
+		if err := e.w.WriteUint16(uint16(length)); err != nil {
 			return err
 		}
 	default:
 		return errors.New("Structure too long to write: %+v", val)
 	}
 
-	_, err := e.Write([]byte{byte(val.Signature())})
+	err := e.w.WriteByte(byte(val.Signature()))
 	if err != nil {
 		return errors.Wrap(err, "An error occurred writing to encoder a struct field")
 	}
@@ -8,10 +8,16 @@ import (
 	"github.com/stretchr/testify/require"
 	"io"
 	"math"
+	"strings"
 	"testing"
 	"testing/quick"
+	"time"
 
+	"github.com/mindstand/go-bolt/bolt_mode"
 	"github.com/mindstand/go-bolt/errors"
+	"github.com/mindstand/go-bolt/structures"
+	"github.com/mindstand/go-bolt/structures/messages"
+	"github.com/mindstand/go-bolt/structures/types"
 )
 
 const (
@@ -19,7 +25,7 @@ const (
 	maxKeySize = 10
 )
 
-func createNewTestEncoder() (EncoderV2, io.Reader) {
+func createNewTestEncoder() (*EncoderV2, io.Reader) {
 	buf := bytes.NewBuffer([]byte{})
 	return NewEncoder(buf, maxBufSize), buf
 }
@@ -568,14 +574,456 @@ func TestEncodeStringSlice(t *testing.T) {
 	req.Nil(quick.CheckEqual(expected, result, nil))
 }
 
-// todo implement this test
 func TestEncodeTime(t *testing.T) {
-	t.Log("not implemented")
-	t.Skip()
+	req := require.New(t)
+
+	paris, err := time.LoadLocation("Europe/Paris")
+	req.Nil(err)
+
+	// named zones are sent by id with seconds local to the zone
+	encoded, err := Marshal(time.Date(2021, 3, 28, 3, 0, 0, 0, paris))
+	req.Nil(err)
+
+	expected := []byte{0x00, 0x15,
+		encode_consts.TinyStructMarker | 3, encode_consts.DateTimeWithZoneIdSignature,
+		encode_consts.Int32Marker, 0x60, 0x5f, 0xf1, 0x30, 0x00,
+		encode_consts.TinyStringMarker | 12}
+	expected = append(expected, "Europe/Paris"...)
+	expected = append(expected, 0x00, 0x00)
+	req.Equal(expected, encoded)
+
+	tests := []struct {
+		name      string
+		zone      string
+		local     time.Time
+		signature byte
+		// the legacy zone id format only carries local time, so the repeated hour
+		// of a dst fall back can decode to either offset
+		ambiguous bool
+	}{
+		{name: "utc", zone: "UTC", local: time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC), signature: encode_consts.DateTimeWithZoneOffsetSignature},
+		{name: "fixed offset", zone: "", local: time.Date(2021, 6, 1, 12, 0, 0, 500, time.FixedZone("", 5*3600+1800)), signature: encode_consts.DateTimeWithZoneOffsetSignature},
+		{name: "no dst", zone: "Asia/Kolkata", local: time.Date(2021, 3, 14, 2, 30, 0, 0, time.UTC), signature: encode_consts.DateTimeWithZoneIdSignature},
+		{name: "new york before spring forward", zone: "America/New_York", local: time.Date(2021, 3, 14, 1, 59, 59, 999999999, time.UTC), signature: encode_consts.DateTimeWithZoneIdSignature},
+		{name: "new york after spring forward", zone: "America/New_York", local: time.Date(2021, 3, 14, 3, 0, 0, 0, time.UTC), signature: encode_consts.DateTimeWithZoneIdSignature},
+		{name: "new york before fall back", zone: "America/New_York", local: time.Date(2021, 11, 7, 0, 59, 59, 0, time.UTC), signature: encode_consts.DateTimeWithZoneIdSignature},
+		{name: "new york repeated hour", zone: "America/New_York", local: time.Date(2021, 11, 7, 1, 30, 0, 0, time.UTC), signature: encode_consts.DateTimeWithZoneIdSignature, ambiguous: true},
+		{name: "new york after fall back", zone: "America/New_York", local: time.Date(2021, 11, 7, 2, 0, 0, 0, time.UTC), signature: encode_consts.DateTimeWithZoneIdSignature},
+		{name: "london after spring forward", zone: "Europe/London", local: time.Date(2021, 3, 28, 2, 0, 0, 0, time.UTC), signature: encode_consts.DateTimeWithZoneIdSignature},
+		{name: "london summer", zone: "Europe/London", local: time.Date(2021, 7, 1, 9, 15, 0, 0, time.UTC), signature: encode_consts.DateTimeWithZoneIdSignature},
+		{name: "sydney repeated hour", zone: "Australia/Sydney", local: time.Date(2021, 4, 4, 2, 30, 0, 0, time.UTC), signature: encode_consts.DateTimeWithZoneIdSignature, ambiguous: true},
+		{name: "sydney after spring forward", zone: "Australia/Sydney", local: time.Date(2021, 10, 3, 3, 0, 0, 0, time.UTC), signature: encode_consts.DateTimeWithZoneIdSignature},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := require.New(t)
+
+			// local is the wall clock, move it into the zone under test
+			val := test.local
+			if test.zone != "" {
+				loc, err := time.LoadLocation(test.zone)
+				req.Nil(err)
+				val = time.Date(val.Year(), val.Month(), val.Day(), val.Hour(), val.Minute(), val.Second(), val.Nanosecond(), loc)
+			}
+
+			encoded, err := Marshal(val)
+			req.Nil(err)
+			req.Equal(test.signature, encoded[3])
+
+			decodedI, err := Unmarshal(encoded)
+			req.Nil(err)
+			decodedDateTime, ok := decodedI.(types.DateTime)
+			req.True(ok)
+			decoded := decodedDateTime.Time
+
+			req.Equal(val.Format("2006-01-02T15:04:05.999999999"), decoded.Format("2006-01-02T15:04:05.999999999"))
+			if test.signature == encode_consts.DateTimeWithZoneIdSignature {
+				req.Equal(test.zone, decoded.Location().String())
+				req.Equal(test.zone, decodedDateTime.ZoneId)
+			} else {
+				req.False(decodedDateTime.HasZoneId())
+			}
+			if !test.ambiguous {
+				req.True(val.Equal(decoded), "expected %s, got %s", val, decoded)
+			}
+
+			// what was decoded goes back the way it came, by zone id or by offset
+			reencoded, err := Marshal(decodedDateTime)
+			req.Nil(err)
+			req.Equal(encoded, reencoded)
+		})
+	}
+
+	// DateTime overrides how the zone is sent
+	newYork, err := time.LoadLocation("America/New_York")
+	req.Nil(err)
+	val := time.Date(2021, 11, 7, 12, 0, 0, 0, time.UTC)
+
+	encoded, err = Marshal(types.DateTime{Time: val, ZoneId: "America/New_York"})
+	req.Nil(err)
+	req.Equal(encode_consts.DateTimeWithZoneIdSignature, encoded[3])
+
+	decoded, err := Unmarshal(encoded)
+	req.Nil(err)
+	req.Equal(types.DateTime{Time: val.In(newYork), ZoneId: "America/New_York"}, decoded)
+
+	encoded, err = Marshal(types.NewDateTimeWithOffset(val.In(newYork)))
+	req.Nil(err)
+	req.Equal(encode_consts.DateTimeWithZoneOffsetSignature, encoded[3])
+
+	decoded, err = Unmarshal(encoded)
+	req.Nil(err)
+	req.True(val.Equal(decoded.(types.DateTime).Time))
+	req.False(decoded.(types.DateTime).HasZoneId())
+
+	_, err = Marshal(types.DateTime{Time: val, ZoneId: "Not/AZone"})
+	req.NotNil(err)
 }
 
-// todo implement this test
 func TestEncodeDuration(t *testing.T) {
-	t.Log("not implemented")
-	t.Skip()
+	req := require.New(t)
+
+	encoded, err := Marshal(types.NewDuration(14, 3, 100000, 7))
+	req.Nil(err)
+
+	// struct of four ints, 100000 needs an INT_32
+	expected := []byte{0x00, 0x0a,
+		encode_consts.TinyStructMarker | 4, encode_consts.DurationSignature,
+		0x0e, 0x03, encode_consts.Int32Marker, 0x00, 0x01, 0x86, 0xa0, 0x07,
+		0x00, 0x00}
+	req.Equal(expected, encoded)
+
+	durations := []types.Duration{
+		{},
+		types.NewDuration(1, 2, 3, 4),
+		types.NewDuration(-13, -40, -3600, -500),
+		types.NewDuration(0, 0, math.MaxInt64, 999999999),
+	}
+
+	for _, duration := range durations {
+		encoded, err := Marshal(duration)
+		req.Nil(err)
+
+		decoded, err := Unmarshal(encoded)
+		req.Nil(err)
+		req.Equal(duration, decoded)
+	}
+
+	// time.Duration is sent as seconds and nanos
+	encoded, err = Marshal(-1500 * time.Millisecond)
+	req.Nil(err)
+
+	decoded, err := Unmarshal(encoded)
+	req.Nil(err)
+	req.Equal(types.Duration{Seconds: -2, Nanos: 500000000}, decoded)
+}
+
+// countingWriter counts writes, each would be a syscall on a connection
+type countingWriter struct {
+	writes int
+	bytes  int
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.writes++
+	c.bytes += len(p)
+	return len(p), nil
+}
+
+// runPull is the RUN and PULL pair a query sends
+func runPull() []structures.Structure {
+	return []structures.Structure{
+		messages.NewAutoCommitTxRunMessage("MATCH (p:Person) WHERE p.name = $name AND p.age > $age RETURN p", map[string]interface{}{
+			"name": "Alice",
+			"age":  int64(42),
+			"tags": []interface{}{"a", "b", "c"},
+		}, 0, nil, "neo4j", bolt_mode.WriteMode),
+		messages.NewPullMessage(1000, -1),
+	}
+}
+
+// BenchmarkEncodeRunPullUnbuffered sends RUN and PULL the way sendMessage did before ChunkWriter, as the
+// baseline for the benchmarks below: an encoder a message, every marker and number written to it on its own
+// and each message flushed to the connection in three writes of its own
+func BenchmarkEncodeRunPullUnbuffered(b *testing.B) {
+	w := &countingWriter{}
+	pair := runPull()
+	var markerWrites int
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for _, message := range pair {
+			encoder := &unbufferedEncoder{w: w, buf: &bytes.Buffer{}, chunkSize: math.MaxUint16}
+			if err := encoder.Encode(message); err != nil {
+				b.Fatal(err)
+			}
+			markerWrites += encoder.writes
+		}
+	}
+
+	b.ReportMetric(float64(w.writes)/float64(b.N), "writes/op")
+	b.ReportMetric(float64(markerWrites)/float64(b.N), "encoder-writes/op")
+	b.SetBytes(int64(w.bytes / b.N))
+}
+
+// unbufferedEncoder is the encoder before ChunkWriter, cut down to the values RUN and PULL hold. Markers,
+// sizes and numbers each go through Write, numbers by binary.Write, into a buffer that is chunked out to w
+type unbufferedEncoder struct {
+	w         io.Writer
+	buf       *bytes.Buffer
+	chunkSize uint16
+	// writes counts the calls to Write
+	writes int
+}
+
+func (e *unbufferedEncoder) Write(p []byte) (int, error) {
+	e.writes++
+	n, _ := e.buf.Write(p)
+
+	if e.buf.Len() >= int(e.chunkSize) {
+		if err := binary.Write(e.w, binary.BigEndian, e.chunkSize); err != nil {
+			return 0, err
+		}
+		return e.w.Write(e.buf.Next(int(e.chunkSize)))
+	}
+	return n, nil
+}
+
+func (e *unbufferedEncoder) Encode(val interface{}) error {
+	if err := e.encode(val); err != nil {
+		return err
+	}
+
+	if length := e.buf.Len(); length > 0 {
+		if err := binary.Write(e.w, binary.BigEndian, uint16(length)); err != nil {
+			return err
+		}
+		if _, err := e.buf.WriteTo(e.w); err != nil {
+			return err
+		}
+	}
+
+	_, err := e.w.Write(encode_consts.EndMessage)
+	return err
+}
+
+func (e *unbufferedEncoder) encode(iVal interface{}) error {
+	var err error
+	switch val := iVal.(type) {
+	case nil:
+		_, err = e.Write([]byte{encode_consts.NilMarker})
+	case bool:
+		marker := byte(encode_consts.FalseMarker)
+		if val {
+			marker = encode_consts.TrueMarker
+		}
+		_, err = e.Write([]byte{marker})
+	case int:
+		err = e.encodeInt(int64(val))
+	case int64:
+		err = e.encodeInt(val)
+	case string:
+		err = e.header(encode_consts.TinyStringMarker, encode_consts.String8Marker, len(val))
+		if err == nil {
+			_, err = e.Write([]byte(val))
+		}
+	case []interface{}:
+		if err = e.header(encode_consts.TinySliceMarker, encode_consts.Slice8Marker, len(val)); err != nil {
+			return err
+		}
+		for _, item := range val {
+			if err := e.encode(item); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		if err = e.header(encode_consts.TinyMapMarker, encode_consts.Map8Marker, len(val)); err != nil {
+			return err
+		}
+		for k, v := range val {
+			if err := e.encode(k); err != nil {
+				return err
+			}
+			if err := e.encode(v); err != nil {
+				return err
+			}
+		}
+	case structures.Structure:
+		fields := val.AllFields()
+		if err = e.header(encode_consts.TinyStructMarker, encode_consts.Struct8Marker, len(fields)); err != nil {
+			return err
+		}
+		if _, err = e.Write([]byte{byte(val.Signature())}); err != nil {
+			return err
+		}
+		for _, field := range fields {
+			if err := e.encode(field); err != nil {
+				return err
+			}
+		}
+	default:
+		return errors.New("Unrecognized type when encoding data for Bolt transport: %T %+v", val, val)
+	}
+	return err
+}
+
+// header writes the marker of a string, list, map or struct of size items
+func (e *unbufferedEncoder) header(tiny, marker8 byte, size int) error {
+	if size <= 15 {
+		_, err := e.Write([]byte{tiny | byte(size)})
+		return err
+	}
+	if _, err := e.Write([]byte{marker8}); err != nil {
+		return err
+	}
+	return binary.Write(e, binary.BigEndian, int8(size))
+}
+
+func (e *unbufferedEncoder) encodeInt(val int64) error {
+	switch {
+	case val >= -16 && val <= math.MaxInt8:
+		return binary.Write(e, binary.BigEndian, int8(val))
+	case val >= math.MinInt8 && val < -16:
+		if _, err := e.Write([]byte{encode_consts.Int8Marker}); err != nil {
+			return err
+		}
+		return binary.Write(e, binary.BigEndian, int8(val))
+	case val >= math.MinInt16 && val <= math.MaxInt16:
+		if _, err := e.Write([]byte{encode_consts.Int16Marker}); err != nil {
+			return err
+		}
+		return binary.Write(e, binary.BigEndian, int16(val))
+	case val >= math.MinInt32 && val <= math.MaxInt32:
+		if _, err := e.Write([]byte{encode_consts.Int32Marker}); err != nil {
+			return err
+		}
+		return binary.Write(e, binary.BigEndian, int32(val))
+	default:
+		if _, err := e.Write([]byte{encode_consts.Int64Marker}); err != nil {
+			return err
+		}
+		return binary.Write(e, binary.BigEndian, val)
+	}
+}
+
+func BenchmarkEncodeRunPullPerMessage(b *testing.B) {
+	w := &countingWriter{}
+	pair := runPull()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		// an encoder a message, each sending its message on its own
+		for _, message := range pair {
+			encoder := NewEncoder(w, math.MaxUint16)
+			if err := encoder.Encode(message); err != nil {
+				b.Fatal(err)
+			}
+			encoder.Release()
+		}
+	}
+
+	b.ReportMetric(float64(w.writes)/float64(b.N), "writes/op")
+	b.SetBytes(int64(w.bytes / b.N))
+}
+
+func BenchmarkEncodeRunPullBatched(b *testing.B) {
+	w := &countingWriter{}
+	pair := runPull()
+	encoder := NewEncoder(w, math.MaxUint16)
+	defer encoder.Release()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for _, message := range pair {
+			if err := encoder.Buffer(message); err != nil {
+				b.Fatal(err)
+			}
+		}
+
+		if err := encoder.Flush(); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.ReportMetric(float64(w.writes)/float64(b.N), "writes/op")
+	b.SetBytes(int64(w.bytes / b.N))
+}
+
+func TestEncoder_bufferAndFlush(t *testing.T) {
+	req := require.New(t)
+
+	// maps of one key, so the messages encode the same every time
+	pair := []structures.Structure{
+		messages.NewRunMessage("RETURN $n", map[string]interface{}{"n": int64(1)}),
+		messages.NewPullMessage(1000, -1),
+	}
+
+	var separate bytes.Buffer
+	for _, message := range pair {
+		req.Nil(NewEncoder(&separate, math.MaxUint16).Encode(message))
+	}
+
+	var batched bytes.Buffer
+	w := &countingWriter{}
+	encoder := NewEncoder(io.MultiWriter(w, &batched), math.MaxUint16)
+	for _, message := range pair {
+		req.Nil(encoder.Buffer(message))
+	}
+	req.Equal(0, w.writes)
+
+	// a message that fails to encode is dropped, the ones queued before it are kept
+	req.NotNil(encoder.Buffer(messages.NewRecordMessage([]interface{}{"ok", struct{}{}})))
+
+	req.Nil(encoder.Flush())
+	req.Equal(1, w.writes)
+	req.Equal(separate.Bytes(), batched.Bytes())
+
+	// nothing queued, nothing written
+	req.Nil(encoder.Flush())
+	req.Equal(1, w.writes)
+
+	encoder.Release()
+	err := encoder.Encode(messages.NewResetMessage())
+	req.Equal(errors.ErrClosed, err.(*errors.Error).InnerMost())
+}
+
+func TestEncoder_chunksLargeMessages(t *testing.T) {
+	req := require.New(t)
+
+	text := strings.Repeat("neo4j", 30000)
+	for _, chunkSize := range []uint16{16, 1000, math.MaxUint16} {
+		var buf bytes.Buffer
+		encoder := NewEncoder(&buf, chunkSize)
+		req.Nil(encoder.Encode(text))
+		encoder.Release()
+
+		// every chunk fits the chunk size, and the chunks put back together are the whole value
+		data := buf.Bytes()
+		var message []byte
+		for {
+			req.True(len(data) >= 2)
+			size := int(binary.BigEndian.Uint16(data))
+			data = data[2:]
+			if size == 0 {
+				break
+			}
+
+			req.True(size <= int(chunkSize), "chunk of %d bytes, chunk size %d", size, chunkSize)
+			message = append(message, data[:size]...)
+			data = data[size:]
+		}
+		req.Empty(data)
+		req.Equal(append([]byte{encode_consts.String32Marker, 0x00, 0x02, 0x49, 0xf0}, text...), message)
+
+		decoded, err := Unmarshal(buf.Bytes())
+		req.Nil(err)
+		req.Equal(text, decoded)
+	}
 }
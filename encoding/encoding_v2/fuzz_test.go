@@ -0,0 +1,57 @@
+package encoding_v2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mindstand/go-bolt/structures/graph"
+	"github.com/mindstand/go-bolt/structures/messages"
+	"github.com/mindstand/go-bolt/structures/types"
+	"github.com/mindstand/gotime"
+)
+
+// fuzzSeeds are messages a server sends, covering every struct the decoder knows
+func fuzzSeeds() []interface{} {
+	node := graph.Node{NodeIdentity: 1, Labels: []string{"Person"}, Properties: map[string]interface{}{"name": "Alice"}}
+	return []interface{}{
+		messages.NewSuccessMessage(map[string]interface{}{"fields": []interface{}{"n"}, "t_first": int64(1)}),
+		messages.NewFailureMessage(map[string]interface{}{"code": "Neo.ClientError", "message": "oops"}),
+		messages.NewIgnoredMessage(),
+		messages.NewRecordMessage([]interface{}{
+			nil, true, int64(-17), int64(300), int64(1) << 40, 1.5, "text",
+			[]interface{}{int64(1), "two"},
+			map[string]interface{}{"key": []interface{}{}},
+			node,
+			graph.Relationship{RelIdentity: 2, StartNodeIdentity: 1, EndNodeIdentity: 3, Type: "KNOWS", Properties: map[string]interface{}{}},
+			graph.Path{
+				Nodes:         []graph.Node{node, node},
+				Relationships: []graph.UnboundRelationship{{RelIdentity: 2, Type: "KNOWS", Properties: map[string]interface{}{}}},
+				Sequence:      []int{1, 1},
+			},
+		}),
+		messages.NewRecordMessage([]interface{}{
+			&types.Point2D{SRID: 7203, X: 1, Y: 2},
+			&types.Point3D{SRID: 9157, X: 1, Y: 2, Z: 3},
+			gotime.NewDate(2020, 4, 14),
+			gotime.NewLocalClock(12, 30, 0, 0),
+			types.NewDateTime(time.Date(2020, 4, 14, 12, 30, 0, 0, time.UTC)),
+			types.DateTime{Time: time.Date(2020, 4, 14, 12, 30, 0, 0, time.UTC), ZoneId: "Europe/London"},
+			types.NewDuration(1, 2, 3, 4),
+		}),
+	}
+}
+
+func FuzzUnmarshal(f *testing.F) {
+	for _, seed := range fuzzSeeds() {
+		data, err := Marshal(seed)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// anything goes as long as it doesn't panic
+		_, _ = Unmarshal(data)
+	})
+}
@@ -2,9 +2,18 @@ package encoding
 
 type IDecoder interface {
 	Decode() (interface{}, error)
+	// SetLimits bounds the messages the decoder accepts, decoders start with DefaultLimits
+	SetLimits(limits Limits)
+	// Release hands the decoder's buffers back to be reused, the decoder can't be used after
+	Release()
 }
 
 type IEncoder interface {
 	Write(p []byte) (n int, err error)
 	Encode(iVal interface{}) error
+	// Buffer queues a message to be sent by the next Flush, so a pipeline goes out in one write
+	Buffer(iVal interface{}) error
+	Flush() error
+	// Release hands the encoder's buffers back to be reused, the encoder can't be used after
+	Release()
 }
@@ -0,0 +1,92 @@
+package encoding
+
+import "fmt"
+
+// maxPrealloc is the most items made room for up front when decoding a list or map. Sizes come off the
+// wire, so bigger collections grow as their items are actually read
+const maxPrealloc = 1024
+
+// NoLimit turns off a limit in Limits
+const NoLimit = -1
+
+// Limits bounds what a decoder accepts from the stream. A zero field keeps the limit from DefaultLimits, so
+// setting one limit doesn't lift the others, and NoLimit turns a limit off
+type Limits struct {
+	// MaxMessageSize is the most bytes a message can have over all of its chunks
+	MaxMessageSize int
+	// MaxCollectionLength is the most items a list, map or struct can have
+	MaxCollectionLength int
+	// MaxDepth is how deep lists, maps and structs can nest
+	MaxDepth int
+}
+
+// DefaultLimits are the limits decoders start with. Messages and collections aren't limited since results
+// can be big, nesting is so malformed input can't run the decoder out of stack
+var DefaultLimits = Limits{
+	MaxDepth: 256,
+}
+
+// WithDefaults returns l with its zero fields taken from DefaultLimits
+func (l Limits) WithDefaults() Limits {
+	if l.MaxMessageSize == 0 {
+		l.MaxMessageSize = DefaultLimits.MaxMessageSize
+	}
+	if l.MaxCollectionLength == 0 {
+		l.MaxCollectionLength = DefaultLimits.MaxCollectionLength
+	}
+	if l.MaxDepth == 0 {
+		l.MaxDepth = DefaultLimits.MaxDepth
+	}
+	return l
+}
+
+// CheckLength returns a LimitError when a collection of size items is too long
+func (l Limits) CheckLength(size int) error {
+	if l.MaxCollectionLength > 0 && size > l.MaxCollectionLength {
+		return &LimitError{Limit: "collection length", Max: l.MaxCollectionLength, Size: size}
+	}
+	return nil
+}
+
+// CheckDepth returns a LimitError when collections are nested depth deep
+func (l Limits) CheckDepth(depth int) error {
+	if l.MaxDepth > 0 && depth > l.MaxDepth {
+		return &LimitError{Limit: "nesting depth", Max: l.MaxDepth, Size: depth}
+	}
+	return nil
+}
+
+// Prealloc is how many items to make room for in a collection of size items
+func Prealloc(size int) int {
+	if size > maxPrealloc {
+		return maxPrealloc
+	}
+	return size
+}
+
+// LimitError is returned when a message goes over one of the decoder's Limits
+type LimitError struct {
+	// Limit names the limit, message size, collection length or nesting depth
+	Limit string
+	Max   int
+	Size  int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s of %d is over the limit of %d", e.Limit, e.Size, e.Max)
+}
+
+// MalformedError is returned when the stream isn't valid packstream, or a struct's fields aren't what its
+// signature says they are
+type MalformedError struct {
+	Reason string
+}
+
+// Malformed returns a MalformedError with a formatted reason
+func Malformed(reason string, args ...interface{}) error {
+	return &MalformedError{Reason: fmt.Sprintf(reason, args...)}
+}
+
+func (e *MalformedError) Error() string {
+	return "malformed packstream: " + e.Reason
+}
@@ -0,0 +1,21 @@
+/*
+Package packstream prints packstream, the encoding bolt messages are written in, as an annotated tree.
+
+Dump reads chunked messages, as they are sent over a connection, and DumpValues reads bare packstream
+values. Each line starts with the offset of the item in the input and its bytes, followed by the marker
+named after its constant in encode_consts, its size and its value. Structs show their signature and the
+message or type it stands for, and chunk headers and message ends get lines of their own:
+
+	0000  00 0d                   chunk 13 bytes
+	0002  b1 70                   TinyStructMarker size 1 signature 0x70 SUCCESS
+	0004  a1                        TinyMapMarker size 1
+	0005  86 66 69 65 6c 64 73        TinyStringMarker size 6 "fields"
+	000c  91                            TinySliceMarker size 1
+	000d  81 31                           TinyStringMarker size 1 "1"
+	000f  00 00                   end of message
+
+Input that isn't valid packstream, or nests deeper than encoding.DefaultLimits allows, is flagged with a
+line at the offset it goes wrong and a MalformedError.
+ParseHex reads the hex dumps written by log.TraceBytes and most hex editors.
+*/
+package packstream
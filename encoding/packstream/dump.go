@@ -0,0 +1,398 @@
+package packstream
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mindstand/go-bolt/encoding"
+	"github.com/mindstand/go-bolt/encoding/encode_consts"
+)
+
+// longest run of bytes shown on a line, longer items are cut short with ..
+const maxLineBytes = 8
+
+// MalformedError is returned for input that isn't valid packstream
+type MalformedError struct {
+	// Offset is where in the input the packstream goes wrong
+	Offset int
+	Reason string
+}
+
+func (m *MalformedError) Error() string {
+	return fmt.Sprintf("malformed packstream at offset 0x%04x: %s", m.Offset, m.Reason)
+}
+
+// chunkHeader is a chunk header of chunked input, printed before the first item read from its chunk
+type chunkHeader struct {
+	offset int
+	size   int
+}
+
+type dumper struct {
+	w io.Writer
+	// data is the packstream being read, chunked messages are read one dechunked message at a time
+	data []byte
+	pos  int
+	// offsets maps positions in data to offsets in the input, nil when data is the input
+	offsets []int
+	// end is the offset in the input data ends at
+	end int
+	// chunk headers of the message not printed yet
+	chunks []chunkHeader
+	// nested is how many lists, maps and structs the value being read is in
+	nested int
+	// first error writing to w
+	err error
+}
+
+// Dump writes the chunked messages in data to w as a tree, with a line for every chunk header and message end
+func Dump(w io.Writer, data []byte) error {
+	d := &dumper{w: w}
+
+	for offset := 0; offset < len(data); offset += 2 {
+		d.data, d.pos, d.offsets, d.chunks = nil, 0, nil, nil
+
+		// dechunk the message
+		for {
+			if offset == len(data) {
+				return d.malformed(offset, "the input ends before the message is ended with 00 00")
+			}
+			if len(data)-offset < 2 {
+				return d.malformed(offset, "chunk header needs 2 bytes, 1 left")
+			}
+
+			size := int(binary.BigEndian.Uint16(data[offset:]))
+			if size == 0 {
+				break
+			}
+			if len(data)-offset-2 < size {
+				return d.malformed(offset, "chunk of %d bytes runs past the end of the input, %d bytes left", size, len(data)-offset-2)
+			}
+
+			d.chunks = append(d.chunks, chunkHeader{offset: offset, size: size})
+			for i := 0; i < size; i++ {
+				d.offsets = append(d.offsets, offset+2+i)
+			}
+			d.data = append(d.data, data[offset+2:offset+2+size]...)
+			offset += 2 + size
+		}
+		d.end = offset
+
+		// bolt 4.1+ keep alives
+		if len(d.data) == 0 {
+			d.line(offset, data[offset:offset+2], 0, "NOOP")
+			continue
+		}
+
+		if err := d.value(0, true); err != nil {
+			return err
+		}
+		if d.pos < len(d.data) {
+			return d.malformed(d.offset(d.pos), "%d bytes after the end of the message struct", len(d.data)-d.pos)
+		}
+
+		d.flushChunks(offset)
+		d.line(offset, data[offset:offset+2], 0, "end of message")
+	}
+
+	return d.err
+}
+
+// DumpValues writes the packstream values in data to w as a tree, data isn't chunked
+func DumpValues(w io.Writer, data []byte) error {
+	d := &dumper{w: w, data: data, end: len(data)}
+
+	for d.pos < len(d.data) {
+		if err := d.value(0, false); err != nil {
+			return err
+		}
+	}
+
+	return d.err
+}
+
+// value writes the value at d.pos and everything in it. Structs in messages are named after the message
+func (d *dumper) value(depth int, message bool) error {
+	start := d.pos
+	if start >= len(d.data) {
+		return d.malformed(d.offset(start), "expected a value, the input ends")
+	}
+
+	marker := d.data[start]
+	name, ok := markerName(marker)
+	if !ok {
+		return d.malformed(d.offset(start), "unknown marker 0x%02x", marker)
+	}
+	d.pos++
+
+	switch {
+	case marker == encode_consts.NilMarker:
+		d.item(start, depth, name+" null")
+	case marker == encode_consts.TrueMarker:
+		d.item(start, depth, name+" true")
+	case marker == encode_consts.FalseMarker:
+		d.item(start, depth, name+" false")
+	case marker < 0x80 || marker >= 0xf0:
+		d.item(start, depth, fmt.Sprintf("%s %d", name, int8(marker)))
+
+	case marker >= encode_consts.Int8Marker && marker <= encode_consts.Int64Marker:
+		b, err := d.next(start, name, 1<<(marker-encode_consts.Int8Marker))
+		if err != nil {
+			return err
+		}
+
+		var val int64
+		switch len(b) {
+		case 1:
+			val = int64(int8(b[0]))
+		case 2:
+			val = int64(int16(binary.BigEndian.Uint16(b)))
+		case 4:
+			val = int64(int32(binary.BigEndian.Uint32(b)))
+		default:
+			val = int64(binary.BigEndian.Uint64(b))
+		}
+		d.item(start, depth, fmt.Sprintf("%s %d", name, val))
+
+	case marker == encode_consts.FloatMarker:
+		b, err := d.next(start, name, 8)
+		if err != nil {
+			return err
+		}
+		d.item(start, depth, fmt.Sprintf("%s %v", name, math.Float64frombits(binary.BigEndian.Uint64(b))))
+
+	case isStringMarker(marker):
+		size, err := d.size(start, name, marker)
+		if err != nil {
+			return err
+		}
+
+		textStart := d.pos
+		text, err := d.next(start, name, size)
+		if err != nil {
+			return err
+		}
+		if !utf8.Valid(text) {
+			return d.malformed(d.offset(textStart), "%s of %d bytes isn't valid utf-8", name, size)
+		}
+		d.item(start, depth, fmt.Sprintf("%s size %d %s", name, size, strconv.Quote(string(text))))
+
+	case marker&0xf0 == encode_consts.TinySliceMarker || (marker >= encode_consts.Slice8Marker && marker <= encode_consts.Slice32Marker):
+		size, err := d.size(start, name, marker)
+		if err != nil {
+			return err
+		}
+
+		if err := d.enter(start); err != nil {
+			return err
+		}
+		d.item(start, depth, fmt.Sprintf("%s size %d", name, size))
+		for i := 0; i < size; i++ {
+			if err := d.value(depth+1, false); err != nil {
+				return err
+			}
+		}
+		d.nested--
+
+	case marker&0xf0 == encode_consts.TinyMapMarker || (marker >= encode_consts.Map8Marker && marker <= encode_consts.Map32Marker):
+		size, err := d.size(start, name, marker)
+		if err != nil {
+			return err
+		}
+
+		if err := d.enter(start); err != nil {
+			return err
+		}
+		d.item(start, depth, fmt.Sprintf("%s size %d", name, size))
+		for i := 0; i < size; i++ {
+			// keys are strings, their values are written under them
+			if d.pos < len(d.data) && !isStringMarker(d.data[d.pos]) {
+				keyName, _ := markerName(d.data[d.pos])
+				if keyName == "" {
+					keyName = fmt.Sprintf("0x%02x", d.data[d.pos])
+				}
+				return d.malformed(d.offset(d.pos), "map key is a %s, keys have to be strings", keyName)
+			}
+			if err := d.value(depth+1, false); err != nil {
+				return err
+			}
+			if err := d.value(depth+2, false); err != nil {
+				return err
+			}
+		}
+		d.nested--
+
+	default:
+		// structs, every other marker was named by markerName
+		size, err := d.size(start, name, marker)
+		if err != nil {
+			return err
+		}
+
+		b, err := d.next(start, name, 1)
+		if err != nil {
+			return err
+		}
+
+		signature := b[0]
+		desc := fmt.Sprintf("%s size %d signature 0x%02x", name, size, signature)
+		if structName, ok := d.structName(signature, message); ok {
+			desc += " " + structName
+		}
+
+		if err := d.enter(start); err != nil {
+			return err
+		}
+		d.item(start, depth, desc)
+		for i := 0; i < size; i++ {
+			if err := d.value(depth+1, false); err != nil {
+				return err
+			}
+		}
+		d.nested--
+	}
+
+	return nil
+}
+
+// enter checks the list, map or struct starting at start against the nesting depth decoders allow, so
+// deeply nested input is reported instead of running the dumper out of stack
+func (d *dumper) enter(start int) error {
+	d.nested++
+	if err := encoding.DefaultLimits.CheckDepth(d.nested); err != nil {
+		return d.malformed(d.offset(start), "%s", err)
+	}
+	return nil
+}
+
+// structName names a struct signature, messages take precedence over types for the struct of a message
+func (d *dumper) structName(signature byte, message bool) (string, bool) {
+	if message {
+		if name, ok := MessageName(signature); ok {
+			return name, true
+		}
+	}
+	if name, ok := StructName(signature); ok {
+		return name, true
+	}
+	if d.offsets == nil {
+		// bare values may be a dechunked message
+		return MessageName(signature)
+	}
+	return "", false
+}
+
+// size reads the size following a marker, tiny markers hold it in their low nibble
+func (d *dumper) size(start int, name string, marker byte) (int, error) {
+	if marker < encode_consts.String8Marker {
+		return int(marker & 0x0f), nil
+	}
+
+	var n int
+	switch marker {
+	case encode_consts.String8Marker, encode_consts.Slice8Marker, encode_consts.Map8Marker, encode_consts.Struct8Marker:
+		n = 1
+	case encode_consts.String16Marker, encode_consts.Slice16Marker, encode_consts.Map16Marker, encode_consts.Struct16Marker:
+		n = 2
+	default:
+		n = 4
+	}
+
+	b, err := d.next(start, name, n)
+	if err != nil {
+		return 0, err
+	}
+
+	switch n {
+	case 1:
+		return int(b[0]), nil
+	case 2:
+		return int(binary.BigEndian.Uint16(b)), nil
+	default:
+		return int(binary.BigEndian.Uint32(b)), nil
+	}
+}
+
+// next reads n bytes of the item starting at start
+func (d *dumper) next(start int, name string, n int) ([]byte, error) {
+	if left := len(d.data) - d.pos; left < n {
+		return nil, d.malformed(d.offset(start), "%s needs %d more bytes, %d left", name, n, left)
+	}
+
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+// offset returns the offset in the input of position pos in d.data
+func (d *dumper) offset(pos int) int {
+	if d.offsets == nil {
+		return pos
+	}
+	if pos < len(d.offsets) {
+		return d.offsets[pos]
+	}
+	return d.end
+}
+
+// item writes the line of the item starting at start, its bytes run up to d.pos
+func (d *dumper) item(start, depth int, desc string) {
+	offset := d.offset(start)
+	d.flushChunks(offset)
+	d.line(offset, d.data[start:d.pos], depth, desc)
+}
+
+// flushChunks writes the chunk headers before offset
+func (d *dumper) flushChunks(offset int) {
+	for len(d.chunks) > 0 && d.chunks[0].offset < offset {
+		chunk := d.chunks[0]
+		d.chunks = d.chunks[1:]
+		d.line(chunk.offset, []byte{byte(chunk.size >> 8), byte(chunk.size)}, 0, fmt.Sprintf("chunk %d bytes", chunk.size))
+	}
+}
+
+// malformed flags the input at offset and returns the error for it
+func (d *dumper) malformed(offset int, format string, args ...interface{}) error {
+	malformed := &MalformedError{Offset: offset, Reason: fmt.Sprintf(format, args...)}
+
+	d.flushChunks(offset)
+	d.write(fmt.Sprintf("%04x  !! %s\n", offset, malformed.Reason))
+	return malformed
+}
+
+func (d *dumper) line(offset int, b []byte, depth int, desc string) {
+	d.write(fmt.Sprintf("%04x  %-23s %s%s\n", offset, hexBytes(b), strings.Repeat("  ", depth), desc))
+}
+
+func (d *dumper) write(line string) {
+	if d.err != nil {
+		return
+	}
+	_, d.err = io.WriteString(d.w, line)
+}
+
+// hexBytes writes b as space separated hex, cut short after maxLineBytes
+func hexBytes(b []byte) string {
+	cut := len(b) > maxLineBytes
+	if cut {
+		b = b[:maxLineBytes-1]
+	}
+
+	parts := make([]string, 0, len(b)+1)
+	for _, c := range b {
+		parts = append(parts, fmt.Sprintf("%02x", c))
+	}
+	if cut {
+		parts = append(parts, "..")
+	}
+	return strings.Join(parts, " ")
+}
+
+func isStringMarker(marker byte) bool {
+	return marker&0xf0 == encode_consts.TinyStringMarker || (marker >= encode_consts.String8Marker && marker <= encode_consts.String32Marker)
+}
@@ -0,0 +1,151 @@
+package packstream
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mindstand/go-bolt/encoding/encoding_v2"
+	"github.com/mindstand/go-bolt/structures/messages"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDump(t *testing.T) {
+	req := require.New(t)
+
+	// SUCCESS {fields: ["1"]} split over two chunks, then a NOOP
+	data, err := ParseHex("00 05 b1 70 a1 86 66 00 08 69 65 6c 64 73 91 81 31 00 00 00 00")
+	req.Nil(err)
+
+	var out bytes.Buffer
+	req.Nil(Dump(&out, data))
+	req.Equal(strings.Join([]string{
+		"0000  00 05                   chunk 5 bytes",
+		"0002  b1 70                   TinyStructMarker size 1 signature 0x70 SUCCESS",
+		"0004  a1                        TinyMapMarker size 1",
+		"0005  86 66 69 65 6c 64 73        TinyStringMarker size 6 \"fields\"",
+		"0007  00 08                   chunk 8 bytes",
+		"000e  91                            TinySliceMarker size 1",
+		"000f  81 31                           TinyStringMarker size 1 \"1\"",
+		"0011  00 00                   end of message",
+		"0013  00 00                   NOOP",
+	}, "\n")+"\n", out.String())
+}
+
+func TestDumpValues(t *testing.T) {
+	req := require.New(t)
+
+	// Node 1 [:Person] {age: 1000, score: 1.5}, then a tiny negative int and nil
+	data, err := ParseHex(`b3 4e 01 91 86 50 65 72 73 6f 6e a2 83 61 67 65 c9 03 e8
+		85 73 63 6f 72 65 c1 3f f8 00 00 00 00 00 00 f0 c0`)
+	req.Nil(err)
+
+	var out bytes.Buffer
+	req.Nil(DumpValues(&out, data))
+	req.Equal(strings.Join([]string{
+		"0000  b3 4e                   TinyStructMarker size 3 signature 0x4e Node",
+		"0002  01                        TinyInt 1",
+		"0003  91                        TinySliceMarker size 1",
+		"0004  86 50 65 72 73 6f 6e        TinyStringMarker size 6 \"Person\"",
+		"000b  a2                        TinyMapMarker size 2",
+		"000c  83 61 67 65                 TinyStringMarker size 3 \"age\"",
+		"0010  c9 03 e8                      Int16Marker 1000",
+		"0013  85 73 63 6f 72 65           TinyStringMarker size 5 \"score\"",
+		"0019  c1 3f f8 00 00 00 00 ..       FloatMarker 1.5",
+		"0022  f0                      TinyInt -16",
+		"0023  c0                      NilMarker null",
+	}, "\n")+"\n", out.String())
+}
+
+func TestDumpMalformed(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		hex    string
+		chunk  bool
+		offset int
+		reason string
+	}{
+		{name: "unknown marker", hex: "91 c4", offset: 1, reason: "unknown marker 0xc4"},
+		{name: "short string", hex: "85 61 62", offset: 0, reason: "TinyStringMarker needs 5 more bytes, 2 left"},
+		{name: "short size", hex: "92 01 d5 00", offset: 2, reason: "Slice16Marker needs 2 more bytes, 1 left"},
+		{name: "missing list item", hex: "92 01", offset: 2, reason: "expected a value, the input ends"},
+		{name: "invalid utf-8", hex: "82 ff fe", offset: 1, reason: "TinyStringMarker of 2 bytes isn't valid utf-8"},
+		{name: "map key", hex: "a1 01 02", offset: 1, reason: "map key is a TinyInt, keys have to be strings"},
+		{name: "chunk past the end", hex: "00 05 b0 7e", chunk: true, offset: 0, reason: "chunk of 5 bytes runs past the end of the input, 2 bytes left"},
+		{name: "no message end", hex: "00 02 b0 7e", chunk: true, offset: 4, reason: "the input ends before the message is ended with 00 00"},
+		{name: "short chunk header", hex: "00 02 b0 7e 00", chunk: true, offset: 4, reason: "chunk header needs 2 bytes, 1 left"},
+		{name: "after the message", hex: "00 03 b0 7e 01 00 00", chunk: true, offset: 4, reason: "1 bytes after the end of the message struct"},
+		{name: "too deep", hex: strings.Repeat("91 ", 257) + "01", offset: 256, reason: "nesting depth of 257 is over the limit of 256"},
+		// the offset is in the input, past the second chunk header
+		{name: "second chunk", hex: "00 01 91 00 01 c7 00 00", chunk: true, offset: 5, reason: "unknown marker 0xc7"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := require.New(t)
+
+			data, err := ParseHex(tt.hex)
+			req.Nil(err)
+
+			var out bytes.Buffer
+			if tt.chunk {
+				err = Dump(&out, data)
+			} else {
+				err = DumpValues(&out, data)
+			}
+
+			malformed, ok := err.(*MalformedError)
+			req.True(ok, "expected a MalformedError, got %v", err)
+			req.Equal(tt.offset, malformed.Offset)
+			req.Equal(tt.reason, malformed.Reason)
+			req.Contains(out.String(), "!! "+tt.reason)
+		})
+	}
+}
+
+func TestDumpEncoded(t *testing.T) {
+	req := require.New(t)
+
+	// messages the encoder writes over several small chunks dump without complaint
+	var buf bytes.Buffer
+	encoder := encoding_v2.NewEncoder(&buf, 16)
+	req.Nil(encoder.Encode(messages.NewRunMessage("MATCH (n) WHERE n.name = $name RETURN n", map[string]interface{}{
+		"name":   "Alice",
+		"ids":    []interface{}{int64(1), int64(-200), int64(70000), int64(1) << 40},
+		"nested": map[string]interface{}{"ok": true, "none": nil, "ratio": 0.25},
+	})))
+
+	var out bytes.Buffer
+	req.Nil(Dump(&out, buf.Bytes()))
+	req.Contains(out.String(), "signature 0x10 RUN")
+	req.Contains(out.String(), "Int64Marker 1099511627776")
+	req.Contains(out.String(), "Int32Marker 70000")
+	req.Contains(out.String(), "chunk 16 bytes")
+	req.True(strings.HasSuffix(out.String(), "end of message\n"))
+}
+
+func TestParseHex(t *testing.T) {
+	req := require.New(t)
+
+	for _, text := range []string{"b1 70 0a", "b1700a", "0xb1, 0x70, 0x0a", "b1:70:0a", "b1 70 a", "\n\tb1 70\n\n\ta\n"} {
+		data, err := ParseHex(text)
+		req.Nil(err, text)
+		req.Equal([]byte{0xb1, 0x70, 0x0a}, data, text)
+	}
+
+	_, err := ParseHex("b1 7g")
+	req.NotNil(err)
+
+	_, err = ParseHex("b170a")
+	req.NotNil(err)
+}
+
+func TestDumpMaxDepth(t *testing.T) {
+	req := require.New(t)
+
+	// nesting as deep as decoders allow is dumped
+	data, err := ParseHex(strings.Repeat("91 ", 256) + "01")
+	req.Nil(err)
+
+	var out bytes.Buffer
+	req.Nil(DumpValues(&out, data))
+	req.Contains(out.String(), strings.Repeat("  ", 256)+"TinyInt 1")
+}
@@ -0,0 +1,34 @@
+package packstream
+
+import (
+	"encoding/hex"
+	"strings"
+	"unicode"
+
+	"github.com/mindstand/go-bolt/errors"
+)
+
+// ParseHex reads hex dumps. Bytes may be run together or separated by spaces, commas or colons and have 0x
+// prefixes, so `b1 70 a0`, `b170a0` and `0xb1, 0x70, 0xa0` are the same. Separated bytes may drop their
+// leading zero like log.TraceBytes writes them
+func ParseHex(s string) ([]byte, error) {
+	tokens := strings.FieldsFunc(s, func(r rune) bool {
+		return unicode.IsSpace(r) || r == ',' || r == ':'
+	})
+
+	var data []byte
+	for _, token := range tokens {
+		digits := strings.TrimPrefix(strings.TrimPrefix(token, "0x"), "0X")
+		if len(digits) == 1 {
+			digits = "0" + digits
+		}
+
+		b, err := hex.DecodeString(digits)
+		if err != nil || len(b) == 0 {
+			return nil, errors.New("[%s] isn't hex", token)
+		}
+		data = append(data, b...)
+	}
+
+	return data, nil
+}
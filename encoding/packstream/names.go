@@ -0,0 +1,105 @@
+package packstream
+
+import (
+	"github.com/mindstand/go-bolt/encoding/encode_consts"
+	"github.com/mindstand/go-bolt/structures/graph"
+	"github.com/mindstand/go-bolt/structures/messages"
+	"github.com/mindstand/go-bolt/structures/types"
+)
+
+// message signatures without a message type in the messages package
+const (
+	AckFailureMessageSignature = 0x0e
+	RouteMessageSignature      = 0x66
+)
+
+// messageNames names message signatures. Client and server messages don't share signatures, INIT became
+// HELLO and PULL_ALL and DISCARD_ALL became PULL and DISCARD in bolt 3 and 4
+var messageNames = map[byte]string{
+	messages.HelloMessageSignature:    "HELLO",
+	messages.GoodbyeMessageSignature:  "GOODBYE",
+	AckFailureMessageSignature:        "ACK_FAILURE",
+	messages.ResetMessageSignature:    "RESET",
+	messages.RunMessageSignature:      "RUN",
+	messages.BeginMessageSignature:    "BEGIN",
+	messages.CommitMessageSignature:   "COMMIT",
+	messages.RollbackMessageSignature: "ROLLBACK",
+	messages.DiscardMessageSignature:  "DISCARD",
+	messages.PullMessageSignature:     "PULL",
+	RouteMessageSignature:             "ROUTE",
+	messages.SuccessMessageSignature:  "SUCCESS",
+	messages.RecordMessageSignature:   "RECORD",
+	messages.IgnoredMessageSignature:  "IGNORED",
+	messages.FailureMessageSignature:  "FAILURE",
+}
+
+// structNames names the signatures of structs inside messages
+var structNames = map[byte]string{
+	graph.NodeSignature:                           "Node",
+	graph.RelationshipSignature:                   "Relationship",
+	graph.UnboundRelationshipSignature:            "UnboundRelationship",
+	graph.PathSignature:                           "Path",
+	types.Point2DStructSignature:                  "Point2D",
+	types.Point3DStructSignature:                  "Point3D",
+	encode_consts.DateSignature:                   "Date",
+	encode_consts.TimeSignature:                   "Time",
+	encode_consts.LocalTimeSignature:              "LocalTime",
+	encode_consts.LocalDateTimeSignature:          "LocalDateTime",
+	encode_consts.DateTimeWithZoneOffsetSignature: "DateTime",
+	encode_consts.DateTimeWithZoneIdSignature:     "DateTimeZoneId",
+	encode_consts.DurationSignature:               "Duration",
+}
+
+// MessageName returns the name of a message signature, like RUN or SUCCESS
+func MessageName(signature byte) (string, bool) {
+	name, ok := messageNames[signature]
+	return name, ok
+}
+
+// StructName returns the name of the signature of a struct inside a message, like Node or Date
+func StructName(signature byte) (string, bool) {
+	name, ok := structNames[signature]
+	return name, ok
+}
+
+// markerNames names the markers with one byte each, tiny markers are named by markerName
+var markerNames = map[byte]string{
+	encode_consts.NilMarker:      "NilMarker",
+	encode_consts.TrueMarker:     "TrueMarker",
+	encode_consts.FalseMarker:    "FalseMarker",
+	encode_consts.Int8Marker:     "Int8Marker",
+	encode_consts.Int16Marker:    "Int16Marker",
+	encode_consts.Int32Marker:    "Int32Marker",
+	encode_consts.Int64Marker:    "Int64Marker",
+	encode_consts.FloatMarker:    "FloatMarker",
+	encode_consts.String8Marker:  "String8Marker",
+	encode_consts.String16Marker: "String16Marker",
+	encode_consts.String32Marker: "String32Marker",
+	encode_consts.Slice8Marker:   "Slice8Marker",
+	encode_consts.Slice16Marker:  "Slice16Marker",
+	encode_consts.Slice32Marker:  "Slice32Marker",
+	encode_consts.Map8Marker:     "Map8Marker",
+	encode_consts.Map16Marker:    "Map16Marker",
+	encode_consts.Map32Marker:    "Map32Marker",
+	encode_consts.Struct8Marker:  "Struct8Marker",
+	encode_consts.Struct16Marker: "Struct16Marker",
+}
+
+// markerName names a marker after its constant in encode_consts, tiny ints have no constant
+func markerName(marker byte) (string, bool) {
+	switch {
+	case marker < 0x80 || marker >= 0xf0:
+		return "TinyInt", true
+	case marker&0xf0 == encode_consts.TinyStringMarker:
+		return "TinyStringMarker", true
+	case marker&0xf0 == encode_consts.TinySliceMarker:
+		return "TinySliceMarker", true
+	case marker&0xf0 == encode_consts.TinyMapMarker:
+		return "TinyMapMarker", true
+	case marker&0xf0 == encode_consts.TinyStructMarker:
+		return "TinyStructMarker", true
+	}
+
+	name, ok := markerNames[marker]
+	return name, ok
+}
@@ -1,7 +1,6 @@
 package encoding
 
 import (
-	"github.com/mindstand/go-bolt/errors"
 	"github.com/mindstand/go-bolt/structures/graph"
 )
 
@@ -10,7 +9,7 @@ func SliceInterfaceToString(from []interface{}) ([]string, error) {
 	for idx, item := range from {
 		toItem, ok := item.(string)
 		if !ok {
-			return nil, errors.New("Expected string value. Got %T %+v", item, item)
+			return nil, Malformed("Expected string value. Got %T %+v", item, item)
 		}
 		to[idx] = toItem
 	}
@@ -20,7 +19,11 @@ func SliceInterfaceToString(from []interface{}) ([]string, error) {
 func SliceInterfaceToInt(from []interface{}) ([]int, error) {
 	to := make([]int, len(from))
 	for idx, item := range from {
-		to[idx] = int(item.(int64))
+		toItem, ok := item.(int64)
+		if !ok {
+			return nil, Malformed("Expected int value. Got %T %+v", item, item)
+		}
+		to[idx] = int(toItem)
 	}
 	return to, nil
 }
@@ -30,7 +33,7 @@ func SliceInterfaceToNode(from []interface{}) ([]graph.Node, error) {
 	for idx, item := range from {
 		toItem, ok := item.(graph.Node)
 		if !ok {
-			return nil, errors.New("Expected Node value. Got %T %+v", item, item)
+			return nil, Malformed("Expected Node value. Got %T %+v", item, item)
 		}
 		to[idx] = toItem
 	}
@@ -42,7 +45,7 @@ func SliceInterfaceToRelationship(from []interface{}) ([]graph.Relationship, err
 	for idx, item := range from {
 		toItem, ok := item.(graph.Relationship)
 		if !ok {
-			return nil, errors.New("Expected Relationship value. Got %T %+v", item, item)
+			return nil, Malformed("Expected Relationship value. Got %T %+v", item, item)
 		}
 		to[idx] = toItem
 	}
@@ -54,7 +57,7 @@ func SliceInterfaceToUnboundRelationship(from []interface{}) ([]graph.UnboundRel
 	for idx, item := range from {
 		toItem, ok := item.(graph.UnboundRelationship)
 		if !ok {
-			return nil, errors.New("Expected UnboundRelationship value. Got %T %+v", item, item)
+			return nil, Malformed("Expected UnboundRelationship value. Got %T %+v", item, item)
 		}
 		to[idx] = toItem
 	}
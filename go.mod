@@ -1,9 +1,16 @@
 module github.com/mindstand/go-bolt
 
-go 1.14
+go 1.18
 
 require (
 	github.com/jolestar/go-commons-pool v2.0.0+incompatible
 	github.com/mindstand/gotime v0.0.0-20200414142228-237d9416b724
 	github.com/stretchr/testify v1.5.1
 )
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/fortytw2/leaktest v1.3.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v2 v2.2.2 // indirect
+)
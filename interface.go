@@ -8,11 +8,24 @@ import (
 // bolt+routing will not work for non pooled connections
 type IDriver interface {
 	Open(mode bolt_mode.AccessMode) (connection.IConnection, error)
+	// OpenWithDatabase opens a connection whose queries and transactions default to db.
+	// Empty db uses the client's database
+	OpenWithDatabase(mode bolt_mode.AccessMode, db string) (connection.IConnection, error)
+	// OpenSession opens a connection set up for session
+	OpenSession(mode bolt_mode.AccessMode, session SessionConfig) (connection.IConnection, error)
 }
 
 type IDriverPool interface {
 	// Open opens a Neo-specific connection.
 	Open(mode bolt_mode.AccessMode) (connection.IConnection, error)
+	// OpenWithDatabase opens a connection whose queries and transactions default to db.
+	// With routing the connection goes to a member serving db. Empty db uses the client's database
+	OpenWithDatabase(mode bolt_mode.AccessMode, db string) (connection.IConnection, error)
+	// OpenSession borrows a connection set up for session. Sessions that override the
+	// credentials get their own connections, with the same limits as the pool
+	OpenSession(mode bolt_mode.AccessMode, session SessionConfig) (connection.IConnection, error)
 	Reclaim(connection.IConnection) error
+	// Stats returns a snapshot of the pool's connections
+	Stats() PoolStats
 	Close() error
 }
@@ -0,0 +1,26 @@
+/*
+Package migrate runs versioned schema and data migrations against neo4j through a driver pool.
+
+Migrations are read from files named `<version>_<name>.up.cypher` and `<version>_<name>.down.cypher`,
+a file named `<version>_<name>.cypher` is an up migration without a down. Versions are whole numbers and
+migrations run in version order. Files are read from a directory with LoadDir or from any fs.FS, like an
+embed.FS, with LoadFS.
+
+Statements in a migration are separated by semicolons. A migration runs in one transaction unless it mixes
+schema and data statements, uses `USING PERIODIC COMMIT` or `CALL {} IN TRANSACTIONS`, or has a
+`// migrate:no-transaction` line. Those run a statement at a time, so a failure can leave them half applied.
+
+Migrations are text/template templates, so index and constraint syntax can follow the server's version:
+
+	{{ createIndex "person_name" "Person" "name" }};
+	{{ createUniqueConstraint "person_id" "Person" "id" }};
+	{{ if ge .ProtocolVersion 4 }}...{{ end }}
+
+dropIndex and dropUniqueConstraint take the same arguments. The checksum of a migration is taken before it
+is rendered.
+
+Applied migrations are recorded on `:__Migration` nodes with their version, name, checksum and when they
+were applied. A `:__MigrationLock` node stops two migrators running at once across a cluster, ForceUnlock
+removes a lock left behind by a migrator that died.
+*/
+package migrate
@@ -0,0 +1,365 @@
+package migrate
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	goBolt "github.com/mindstand/go-bolt"
+	"github.com/mindstand/go-bolt/bolt_mode"
+	"github.com/mindstand/go-bolt/connection"
+	"github.com/mindstand/go-bolt/errors"
+	"github.com/mindstand/go-bolt/structures/messages"
+	"github.com/stretchr/testify/require"
+)
+
+// ranStatement is a migration statement the fake store was given
+type ranStatement struct {
+	statement string
+	inTx      bool
+}
+
+// fakeStore keeps the migration records and lock the migrator's queries read and write
+type fakeStore struct {
+	protocolVersion int
+	records         map[int64][]interface{}
+	lockOwner       string
+	constraint      bool
+	ran             []ranStatement
+	failOn          string
+	borrowed        int
+}
+
+func newFakeStore(protocolVersion int) *fakeStore {
+	return &fakeStore{protocolVersion: protocolVersion, records: map[int64][]interface{}{}}
+}
+
+func (f *fakeStore) exec(query string, params connection.QueryParams, inTx bool) ([][]interface{}, error) {
+	switch query {
+	case acquireLockQuery:
+		if f.lockOwner == "" {
+			f.lockOwner = params["owner"].(string)
+		}
+		return [][]interface{}{{f.lockOwner}}, nil
+	case releaseLockQuery:
+		if f.lockOwner == params["owner"] {
+			f.lockOwner = ""
+		}
+	case forceUnlockQuery:
+		f.lockOwner = ""
+	case appliedQuery:
+		versions := make([]int64, 0, len(f.records))
+		for version := range f.records {
+			versions = append(versions, version)
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+
+		rows := make([][]interface{}, len(versions))
+		for i, version := range versions {
+			rows[i] = f.records[version]
+		}
+		return rows, nil
+	case recordQuery:
+		version := params["version"].(int64)
+		f.records[version] = []interface{}{version, params["name"], params["checksum"], int64(1600000000000)}
+	case removeQuery:
+		delete(f.records, params["version"].(int64))
+	default:
+		if strings.Contains(query, lockLabel) {
+			if f.constraint {
+				return nil, errors.Wrap(messages.NewFailureMessage(map[string]interface{}{
+					"code": "Neo.ClientError.Schema.EquivalentSchemaRuleAlreadyExists",
+				}), "Neo4J reported a failure for the runQuery")
+			}
+			f.constraint = true
+			return nil, nil
+		}
+
+		f.ran = append(f.ran, ranStatement{statement: query, inTx: inTx})
+		if query == f.failOn {
+			return nil, errors.Wrap(messages.NewFailureMessage(map[string]interface{}{
+				"code": "Neo.ClientError.Statement.SyntaxError",
+			}), "Neo4J reported a failure for the runQuery")
+		}
+	}
+
+	return nil, nil
+}
+
+type fakeResult struct {
+	connection.IResult
+}
+
+type fakeConn struct {
+	connection.IConnection
+	store *fakeStore
+}
+
+func (f *fakeConn) GetProtocolVersionNumber() int {
+	return f.store.protocolVersion
+}
+
+func (f *fakeConn) Exec(query string, params connection.QueryParams) (connection.IResult, error) {
+	_, err := f.store.exec(query, params, false)
+	return &fakeResult{}, err
+}
+
+func (f *fakeConn) Query(query string, params connection.QueryParams) ([][]interface{}, connection.IResult, error) {
+	rows, err := f.store.exec(query, params, false)
+	return rows, &fakeResult{}, err
+}
+
+func (f *fakeConn) Begin() (connection.ITransaction, error) {
+	return &fakeTx{store: f.store}, nil
+}
+
+type fakeTx struct {
+	connection.ITransaction
+	store  *fakeStore
+	closed bool
+}
+
+func (f *fakeTx) Exec(query string, params connection.QueryParams) (connection.IResult, error) {
+	_, err := f.store.exec(query, params, true)
+	return &fakeResult{}, err
+}
+
+func (f *fakeTx) Commit() error {
+	f.closed = true
+	return nil
+}
+
+func (f *fakeTx) Rollback() error {
+	f.closed = true
+	return nil
+}
+
+func (f *fakeTx) IsClosed() bool {
+	return f.closed
+}
+
+type fakePool struct {
+	goBolt.IDriverPool
+	store *fakeStore
+}
+
+func (f *fakePool) OpenWithDatabase(mode bolt_mode.AccessMode, db string) (connection.IConnection, error) {
+	f.store.borrowed++
+	return &fakeConn{store: f.store}, nil
+}
+
+func (f *fakePool) Reclaim(conn connection.IConnection) error {
+	f.store.borrowed--
+	return nil
+}
+
+var testMigrations = []Migration{
+	{
+		Version: 1,
+		Name:    "person_index",
+		Up:      `{{ createIndex "person_name" "Person" "name" }};`,
+		Down:    `{{ dropIndex "person_name" "Person" "name" }};`,
+	},
+	{
+		Version: 2,
+		Name:    "people",
+		Up:      "CREATE (:Person {name: 'a;b'});\n// a comment;\nCREATE (:Person {name: 'c'})",
+		Down:    "MATCH (p:Person) DELETE p",
+	},
+	{
+		Version: 3,
+		Name:    "person_id",
+		Up:      "{{ createUniqueConstraint \"person_id\" \"Person\" \"id\" }};\nMATCH (p:Person) SET p.id = id(p);",
+	},
+}
+
+func statements(ran []ranStatement) []string {
+	queries := make([]string, len(ran))
+	for i, r := range ran {
+		queries[i] = r.statement
+	}
+	return queries
+}
+
+func TestUpDown(t *testing.T) {
+	req := require.New(t)
+	store := newFakeStore(4)
+	migrator, err := New(&fakePool{store: store}, testMigrations, WithLockTimeout(0))
+	req.Nil(err)
+
+	req.Nil(migrator.UpTo(2))
+	req.Equal([]ranStatement{
+		{statement: "CREATE INDEX person_name FOR (n:Person) ON (n.name)", inTx: true},
+		{statement: "CREATE (:Person {name: 'a;b'})", inTx: true},
+		{statement: "// a comment;\nCREATE (:Person {name: 'c'})", inTx: true},
+	}, store.ran)
+	req.Len(store.records, 2)
+	req.Empty(store.lockOwner)
+	req.True(store.constraint)
+
+	// schema and data statements can't share a transaction
+	store.ran = nil
+	req.Nil(migrator.Up())
+	req.Equal([]ranStatement{
+		{statement: "CREATE CONSTRAINT person_id ON (n:Person) ASSERT n.id IS UNIQUE", inTx: false},
+		{statement: "MATCH (p:Person) SET p.id = id(p)", inTx: false},
+	}, store.ran)
+
+	applied, err := migrator.Applied()
+	req.Nil(err)
+	req.Len(applied, 3)
+	req.Equal("people", applied[1].Name)
+	req.Equal(testMigrations[1].Checksum(), applied[1].Checksum)
+	req.EqualValues(1600000000, applied[1].AppliedAt.Unix())
+
+	// nothing left to apply
+	store.ran = nil
+	req.Nil(migrator.Up())
+	req.Empty(store.ran)
+
+	// 3 has no down script
+	req.NotNil(migrator.Down())
+	req.Len(store.records, 3)
+
+	delete(store.records, 3)
+	req.Nil(migrator.DownTo(0))
+	req.Equal([]string{"MATCH (p:Person) DELETE p", "DROP INDEX person_name"}, statements(store.ran))
+	req.Empty(store.records)
+	req.Equal(0, store.borrowed)
+}
+
+func TestUpOldProtocol(t *testing.T) {
+	req := require.New(t)
+	store := newFakeStore(3)
+	migrator, err := New(&fakePool{store: store}, testMigrations)
+	req.Nil(err)
+
+	req.Nil(migrator.Up())
+	req.Equal("CREATE INDEX ON :Person(name)", store.ran[0].statement)
+	req.Equal("CREATE CONSTRAINT ON (n:Person) ASSERT n.id IS UNIQUE", store.ran[3].statement)
+}
+
+func TestUpFailure(t *testing.T) {
+	req := require.New(t)
+	store := newFakeStore(4)
+	store.failOn = "CREATE (:Person {name: 'a;b'})"
+	migrator, err := New(&fakePool{store: store}, testMigrations)
+	req.Nil(err)
+
+	req.NotNil(migrator.Up())
+	req.Len(store.records, 1)
+	req.Empty(store.lockOwner)
+}
+
+func TestChangedMigration(t *testing.T) {
+	req := require.New(t)
+	store := newFakeStore(4)
+	migrator, err := New(&fakePool{store: store}, testMigrations[:1])
+	req.Nil(err)
+	req.Nil(migrator.Up())
+
+	changed := append([]Migration{}, testMigrations...)
+	changed[0].Up = `{{ createIndex "person_name" "Person" "name" "age" }};`
+	migrator, err = New(&fakePool{store: store}, changed)
+	req.Nil(err)
+
+	store.ran = nil
+	req.NotNil(migrator.Up())
+	req.Empty(store.ran)
+}
+
+func TestLocked(t *testing.T) {
+	req := require.New(t)
+	store := newFakeStore(4)
+	store.lockOwner = "someone else"
+	migrator, err := New(&fakePool{store: store}, testMigrations, WithLockTimeout(0))
+	req.Nil(err)
+
+	err = migrator.Up()
+	req.NotNil(err)
+	req.Equal(ErrLocked, err.(*errors.Error).InnerMost())
+	req.Empty(store.ran)
+	req.Equal("someone else", store.lockOwner)
+
+	req.Nil(migrator.ForceUnlock())
+	req.Nil(migrator.Up())
+	req.Len(store.records, 3)
+}
+
+func TestNew(t *testing.T) {
+	req := require.New(t)
+	pool := &fakePool{store: newFakeStore(4)}
+
+	_, err := New(nil, testMigrations)
+	req.NotNil(err)
+
+	_, err = New(pool, append([]Migration{{Version: 1, Name: "again", Up: "RETURN 1"}}, testMigrations...))
+	req.NotNil(err)
+
+	_, err = New(pool, []Migration{{Version: 1, Name: "empty"}})
+	req.NotNil(err)
+
+	_, err = New(pool, testMigrations, WithLockTimeout(-1))
+	req.NotNil(err)
+}
+
+func TestLoadFS(t *testing.T) {
+	req := require.New(t)
+
+	fsys := fstest.MapFS{
+		"migrations/0002_people.up.cypher":   {Data: []byte("CREATE (:Person)")},
+		"migrations/0002_people.down.cypher": {Data: []byte("MATCH (p:Person) DELETE p")},
+		"migrations/0001_index.cypher":       {Data: []byte("CREATE INDEX ON :Person(name)")},
+		"migrations/10_later.up.cypher":      {Data: []byte("RETURN 1")},
+		"migrations/README.md":               {Data: []byte("not a migration")},
+	}
+
+	migrations, err := LoadFS(fsys, "migrations")
+	req.Nil(err)
+	req.Equal([]Migration{
+		{Version: 1, Name: "index", Up: "CREATE INDEX ON :Person(name)"},
+		{Version: 2, Name: "people", Up: "CREATE (:Person)", Down: "MATCH (p:Person) DELETE p"},
+		{Version: 10, Name: "later", Up: "RETURN 1"},
+	}, migrations)
+
+	bad := []fstest.MapFS{
+		{"m/people.up.cypher": {Data: []byte("RETURN 1")}},
+		{"m/1_people.down.cypher": {Data: []byte("RETURN 1")}},
+		{"m/1_people.up.cypher": {Data: []byte("RETURN 1")}, "m/1_other.up.cypher": {Data: []byte("RETURN 2")}},
+		{"m/1_people.up.cypher": {Data: []byte("RETURN 1")}, "m/1_people.cypher": {Data: []byte("RETURN 2")}},
+	}
+	for _, fsys := range bad {
+		_, err := LoadFS(fsys, "m")
+		req.NotNil(err)
+	}
+
+	_, err = LoadDir("does-not-exist")
+	req.NotNil(err)
+}
+
+func TestSplitStatements(t *testing.T) {
+	req := require.New(t)
+
+	req.Equal([]string{
+		"CREATE (:A {x: 'a;b', y: \"c\\\";\"})",
+		"MATCH (`odd;name`) RETURN 1 /* ; */",
+	}, splitStatements("CREATE (:A {x: 'a;b', y: \"c\\\";\"});\nMATCH (`odd;name`) RETURN 1 /* ; */;\n// only a comment;\n"))
+
+	cases := []struct {
+		script     string
+		autocommit bool
+	}{
+		{"CREATE INDEX ON :A(x); CREATE CONSTRAINT ON (a:A) ASSERT a.y IS UNIQUE", false},
+		{"CREATE (:A); MATCH (a:A) SET a.x = 1", false},
+		{"CREATE INDEX ON :A(x); CREATE (:A)", true},
+		{"/* schema */ create fulltext index names for (n:A) on each [n.name]; CREATE (:A)", true},
+		{"USING PERIODIC COMMIT LOAD CSV FROM 'file:///a.csv' AS row CREATE (:A)", true},
+		{"MATCH (a:A) CALL { WITH a SET a.x = 1 } IN TRANSACTIONS", true},
+		{"// migrate:no-transaction\nCREATE (:A)", true},
+	}
+
+	for _, c := range cases {
+		req.Equal(c.autocommit, needsAutocommit(c.script, splitStatements(c.script)), c.script)
+	}
+}
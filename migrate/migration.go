@@ -0,0 +1,259 @@
+package migrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/mindstand/go-bolt/errors"
+)
+
+const (
+	fileSuffix     = ".cypher"
+	upSuffix       = ".up" + fileSuffix
+	downSuffix     = ".down" + fileSuffix
+	noTxDirective  = "// migrate:no-transaction"
+	versionPattern = `^(\d+)_(.+)$`
+)
+
+var (
+	versionRegex = regexp.MustCompile(versionPattern)
+	// statements that can't share a transaction with data writes
+	schemaRegex = regexp.MustCompile(`(?i)^(CREATE|DROP)\s+(\w+\s+)?(INDEX|CONSTRAINT)\b`)
+	// statements that commit as they go, so can't run in an explicit transaction
+	periodicRegex = regexp.MustCompile(`(?i)USING\s+PERIODIC\s+COMMIT|\bIN\s+TRANSACTIONS\b`)
+)
+
+// Migration is one versioned change to the database
+type Migration struct {
+	// Version orders migrations, it has to be unique
+	Version int64
+	// Name describes the migration
+	Name string
+	// Up is the script applying the migration
+	Up string
+	// Down is the script undoing it, empty if the migration can't be undone
+	Down string
+}
+
+// Checksum identifies the up script, a changed checksum means an applied migration was edited
+func (m Migration) Checksum() string {
+	sum := sha256.Sum256([]byte(m.Up))
+	return hex.EncodeToString(sum[:])
+}
+
+// LoadDir reads the migrations in dir
+func LoadDir(dir string) ([]Migration, error) {
+	return LoadFS(os.DirFS(dir), ".")
+}
+
+// LoadFS reads the migrations in dir of fsys, sorted by version
+func LoadFS(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read migrations in [%s]", dir)
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		fileName := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(fileName, fileSuffix) {
+			continue
+		}
+
+		base, down := strings.TrimSuffix(fileName, upSuffix), false
+		if strings.HasSuffix(fileName, downSuffix) {
+			base, down = strings.TrimSuffix(fileName, downSuffix), true
+		} else if base == fileName {
+			base = strings.TrimSuffix(fileName, fileSuffix)
+		}
+
+		match := versionRegex.FindStringSubmatch(base)
+		if match == nil {
+			return nil, errors.New("migration [%s] is not named <version>_<name>.up.cypher", fileName)
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, errors.Wrap(err, "migration [%s] has an invalid version", fileName)
+		}
+
+		script, err := fs.ReadFile(fsys, path.Join(dir, fileName))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read migration [%s]", fileName)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, errors.New("migrations [%s] and [%s] share version [%v]", migration.Name, match[2], version)
+		}
+
+		if down {
+			if migration.Down != "" {
+				return nil, errors.New("migration [%v] has more than one down script", version)
+			}
+			migration.Down = string(script)
+		} else {
+			if migration.Up != "" {
+				return nil, errors.New("migration [%v] has more than one up script", version)
+			}
+			migration.Up = string(script)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, errors.New("migration [%v] has a down script but no up script", migration.Version)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sortMigrations(migrations)
+	return migrations, nil
+}
+
+func sortMigrations(migrations []Migration) {
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+}
+
+// templateData is what migration templates are rendered with
+type templateData struct {
+	ProtocolVersion int
+}
+
+// render runs script as a template for a server speaking protocolVersion
+func render(script string, protocolVersion int) (string, error) {
+	tmpl, err := template.New("migration").Funcs(template.FuncMap{
+		"createIndex": func(name, label string, properties ...string) string {
+			return CreateIndexQuery(protocolVersion, name, label, properties...)
+		},
+		"dropIndex": func(name, label string, properties ...string) string {
+			return DropIndexQuery(protocolVersion, name, label, properties...)
+		},
+		"createUniqueConstraint": func(name, label, property string) string {
+			return CreateUniqueConstraintQuery(protocolVersion, name, label, property)
+		},
+		"dropUniqueConstraint": func(name, label, property string) string {
+			return DropUniqueConstraintQuery(protocolVersion, name, label, property)
+		},
+	}).Parse(script)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse migration template")
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, templateData{ProtocolVersion: protocolVersion}); err != nil {
+		return "", errors.Wrap(err, "failed to render migration template")
+	}
+
+	return sb.String(), nil
+}
+
+// needsAutocommit reports if statements have to run one at a time outside of a transaction
+func needsAutocommit(script string, statements []string) bool {
+	for _, line := range strings.Split(script, "\n") {
+		if strings.TrimSpace(line) == noTxDirective {
+			return true
+		}
+	}
+
+	schema, data := false, false
+	for _, statement := range statements {
+		if periodicRegex.MatchString(statement) {
+			return true
+		}
+
+		if schemaRegex.MatchString(stripComments(statement)) {
+			schema = true
+		} else {
+			data = true
+		}
+	}
+
+	return schema && data
+}
+
+// splitStatements splits script on semicolons outside of strings, quoted names and comments,
+// dropping statements that are only comments
+func splitStatements(script string) []string {
+	var statements []string
+	add := func(statement string) {
+		if stripComments(statement) != "" {
+			statements = append(statements, strings.TrimSpace(statement))
+		}
+	}
+
+	start := 0
+	// quote is the character closing the string or name being read, 0 outside of one
+	var quote byte
+	for i := 0; i < len(script); i++ {
+		ch := script[i]
+
+		switch {
+		case quote != 0:
+			if ch == '\\' && quote != '`' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"' || ch == '`':
+			quote = ch
+		case strings.HasPrefix(script[i:], "//"):
+			end := strings.IndexByte(script[i:], '\n')
+			if end < 0 {
+				end = len(script) - i
+			}
+			i += end
+		case strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				end = len(script) - i
+			} else {
+				end += 3
+			}
+			i += end
+		case ch == ';':
+			add(script[start:i])
+			start = i + 1
+		}
+	}
+
+	add(script[start:])
+	return statements
+}
+
+// stripComments removes leading whitespace and comments from statement
+func stripComments(statement string) string {
+	for {
+		statement = strings.TrimSpace(statement)
+		switch {
+		case strings.HasPrefix(statement, "//"):
+			end := strings.IndexByte(statement, '\n')
+			if end < 0 {
+				return ""
+			}
+			statement = statement[end:]
+		case strings.HasPrefix(statement, "/*"):
+			end := strings.Index(statement, "*/")
+			if end < 0 {
+				return ""
+			}
+			statement = statement[end+2:]
+		default:
+			return statement
+		}
+	}
+}
@@ -0,0 +1,417 @@
+package migrate
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"math"
+	"strings"
+	"time"
+
+	goBolt "github.com/mindstand/go-bolt"
+	"github.com/mindstand/go-bolt/bolt_mode"
+	"github.com/mindstand/go-bolt/connection"
+	"github.com/mindstand/go-bolt/errors"
+	"github.com/mindstand/go-bolt/log"
+	"github.com/mindstand/go-bolt/structures/messages"
+)
+
+const (
+	// DefaultLockTimeout is how long a migrator waits for another one to finish
+	DefaultLockTimeout = 30 * time.Second
+	lockRetryInterval  = 500 * time.Millisecond
+
+	lockLabel          = "__MigrationLock"
+	lockName           = "migrate"
+	lockConstraintName = "__migration_lock_name"
+)
+
+const (
+	acquireLockQuery = `MERGE (l:__MigrationLock {name: $name}) ON CREATE SET l.owner = $owner, l.lockedAt = timestamp() RETURN l.owner`
+	releaseLockQuery = `MATCH (l:__MigrationLock {name: $name, owner: $owner}) DELETE l`
+	forceUnlockQuery = `MATCH (l:__MigrationLock {name: $name}) DELETE l`
+	appliedQuery     = `MATCH (m:__Migration) RETURN m.version, m.name, m.checksum, m.appliedAt ORDER BY m.version`
+	recordQuery      = `CREATE (:__Migration {version: $version, name: $name, checksum: $checksum, appliedAt: timestamp()})`
+	removeQuery      = `MATCH (m:__Migration {version: $version}) DELETE m`
+)
+
+// ErrLocked is returned when another migrator held the lock for longer than the lock timeout
+var ErrLocked = errors.New("migrations are locked by another migrator")
+
+// AppliedMigration is a migration recorded on a :__Migration node
+type AppliedMigration struct {
+	Version   int64
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// Migrator applies and undoes migrations through a driver pool
+type Migrator struct {
+	pool        goBolt.IDriverPool
+	migrations  []Migration
+	database    string
+	lockTimeout time.Duration
+	// owner marks the lock node as this migrator's
+	owner string
+}
+
+type Opt func(*Migrator) error
+
+// sets the database migrations run against, defaults to the client's database
+func WithDatabase(db string) Opt {
+	return func(migrator *Migrator) error {
+		if migrator == nil {
+			return errors.Wrap(errors.ErrConfiguration, "migrator can not be nil")
+		}
+
+		if db == "" {
+			return errors.Wrap(errors.ErrConfiguration, "database can not be empty")
+		}
+
+		migrator.database = db
+		return nil
+	}
+}
+
+// sets how long to wait for another migrator to release the lock, 0 gives up straight away
+func WithLockTimeout(timeout time.Duration) Opt {
+	return func(migrator *Migrator) error {
+		if migrator == nil {
+			return errors.Wrap(errors.ErrConfiguration, "migrator can not be nil")
+		}
+
+		if timeout < 0 {
+			return errors.Wrap(errors.ErrConfiguration, "lock timeout can not be negative")
+		}
+
+		migrator.lockTimeout = timeout
+		return nil
+	}
+}
+
+// New returns a migrator for migrations, see LoadDir and LoadFS
+func New(pool goBolt.IDriverPool, migrations []Migration, opts ...Opt) (*Migrator, error) {
+	if pool == nil {
+		return nil, errors.Wrap(errors.ErrConfiguration, "pool can not be nil")
+	}
+
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sortMigrations(sorted)
+
+	for i, migration := range sorted {
+		if strings.TrimSpace(migration.Up) == "" {
+			return nil, errors.Wrap(errors.ErrConfiguration, "migration [%v] has no up script", migration.Version)
+		}
+		if i > 0 && sorted[i-1].Version == migration.Version {
+			return nil, errors.Wrap(errors.ErrConfiguration, "more than one migration has version [%v]", migration.Version)
+		}
+	}
+
+	ownerBytes := make([]byte, 8)
+	if _, err := rand.Read(ownerBytes); err != nil {
+		return nil, err
+	}
+
+	migrator := &Migrator{
+		pool:        pool,
+		migrations:  sorted,
+		lockTimeout: DefaultLockTimeout,
+		owner:       hex.EncodeToString(ownerBytes),
+	}
+
+	for _, opt := range opts {
+		if opt == nil {
+			return nil, errors.Wrap(errors.ErrConfiguration, "found nil option function in new migrator")
+		}
+
+		if err := opt(migrator); err != nil {
+			return nil, err
+		}
+	}
+
+	return migrator, nil
+}
+
+// Up applies every migration that hasn't been applied
+func (m *Migrator) Up() error {
+	return m.UpTo(math.MaxInt64)
+}
+
+// UpTo applies the migrations up to and including version that haven't been applied.
+// It fails without applying anything if an applied migration was changed
+func (m *Migrator) UpTo(version int64) error {
+	return m.withLock(func(conn connection.IConnection) error {
+		applied, err := m.applied(conn)
+		if err != nil {
+			return err
+		}
+
+		done := make(map[int64]AppliedMigration, len(applied))
+		for _, migration := range applied {
+			done[migration.Version] = migration
+		}
+
+		for _, migration := range m.migrations {
+			if record, ok := done[migration.Version]; ok && record.Checksum != migration.Checksum() {
+				return errors.New("migration [%v] %s has changed since it was applied", migration.Version, migration.Name)
+			}
+		}
+
+		for _, migration := range m.migrations {
+			if migration.Version > version {
+				break
+			}
+
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+
+			params := connection.QueryParams{
+				"version":  migration.Version,
+				"name":     migration.Name,
+				"checksum": migration.Checksum(),
+			}
+			err := m.run(conn, migration.Up, func(query connection.IQuery) error {
+				_, err := query.Exec(recordQuery, params)
+				return err
+			})
+			if err != nil {
+				return errors.Wrap(err, "failed to apply migration [%v] %s", migration.Version, migration.Name)
+			}
+
+			log.Infof("applied migration [%v] %s", migration.Version, migration.Name)
+		}
+
+		return nil
+	})
+}
+
+// Down undoes the newest applied migration
+func (m *Migrator) Down() error {
+	return m.withLock(func(conn connection.IConnection) error {
+		applied, err := m.applied(conn)
+		if err != nil {
+			return err
+		}
+
+		if len(applied) == 0 {
+			return nil
+		}
+
+		return m.undo(conn, applied[len(applied)-1])
+	})
+}
+
+// DownTo undoes the applied migrations newer than version, newest first
+func (m *Migrator) DownTo(version int64) error {
+	return m.withLock(func(conn connection.IConnection) error {
+		applied, err := m.applied(conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(applied) - 1; i >= 0 && applied[i].Version > version; i-- {
+			if err := m.undo(conn, applied[i]); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Applied returns the migrations recorded as applied, oldest first
+func (m *Migrator) Applied() ([]AppliedMigration, error) {
+	conn, err := m.pool.OpenWithDatabase(bolt_mode.ReadMode, m.database)
+	if err != nil {
+		return nil, err
+	}
+	defer m.pool.Reclaim(conn)
+
+	return m.applied(conn)
+}
+
+// ForceUnlock removes the lock whoever holds it, for when a migrator died without releasing it
+func (m *Migrator) ForceUnlock() error {
+	conn, err := m.pool.OpenWithDatabase(bolt_mode.WriteMode, m.database)
+	if err != nil {
+		return err
+	}
+	defer m.pool.Reclaim(conn)
+
+	_, err = conn.Exec(forceUnlockQuery, connection.QueryParams{"name": lockName})
+	return err
+}
+
+// undo runs the down script of the applied migration and removes its record
+func (m *Migrator) undo(conn connection.IConnection, applied AppliedMigration) error {
+	var migration *Migration
+	for i := range m.migrations {
+		if m.migrations[i].Version == applied.Version {
+			migration = &m.migrations[i]
+		}
+	}
+
+	if migration == nil {
+		return errors.New("applied migration [%v] %s is not known to the migrator", applied.Version, applied.Name)
+	}
+
+	if strings.TrimSpace(migration.Down) == "" {
+		return errors.New("migration [%v] %s has no down script", migration.Version, migration.Name)
+	}
+
+	err := m.run(conn, migration.Down, func(query connection.IQuery) error {
+		_, err := query.Exec(removeQuery, connection.QueryParams{"version": migration.Version})
+		return err
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to undo migration [%v] %s", migration.Version, migration.Name)
+	}
+
+	log.Infof("undid migration [%v] %s", migration.Version, migration.Name)
+	return nil
+}
+
+// run renders script and runs its statements, then finish records the result. Everything runs in one
+// transaction unless the script needs to commit as it goes
+func (m *Migrator) run(conn connection.IConnection, script string, finish func(query connection.IQuery) error) error {
+	rendered, err := render(script, conn.GetProtocolVersionNumber())
+	if err != nil {
+		return err
+	}
+
+	statements := splitStatements(rendered)
+	if needsAutocommit(rendered, statements) {
+		for _, statement := range statements {
+			if _, err := conn.Exec(statement, nil); err != nil {
+				return err
+			}
+		}
+
+		return finish(conn)
+	}
+
+	tx, err := conn.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement, nil); err != nil {
+			rollback(tx)
+			return err
+		}
+	}
+
+	if err := finish(tx); err != nil {
+		rollback(tx)
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// rollback rolls tx back if the failure didn't already end it
+func rollback(tx connection.ITransaction) {
+	if tx.IsClosed() {
+		return
+	}
+
+	if err := tx.Rollback(); err != nil {
+		log.Errorf("failed to roll back migration: %s", err)
+	}
+}
+
+// applied reads the :__Migration nodes
+func (m *Migrator) applied(conn connection.IConnection) ([]AppliedMigration, error) {
+	rows, _, err := conn.Query(appliedQuery, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read applied migrations")
+	}
+
+	applied := make([]AppliedMigration, 0, len(rows))
+	for _, row := range rows {
+		if len(row) != 4 {
+			return nil, errors.New("expected 4 columns reading applied migrations, got [%v]", len(row))
+		}
+
+		version, ok := row[0].(int64)
+		if !ok {
+			return nil, errors.New("migration version [%v] is not an integer", row[0])
+		}
+
+		migration := AppliedMigration{Version: version}
+		migration.Name, _ = row[1].(string)
+		migration.Checksum, _ = row[2].(string)
+		if millis, ok := row[3].(int64); ok {
+			migration.AppliedAt = time.Unix(0, millis*int64(time.Millisecond))
+		}
+
+		applied = append(applied, migration)
+	}
+
+	return applied, nil
+}
+
+// withLock runs fn on a write connection while holding the migration lock
+func (m *Migrator) withLock(fn func(conn connection.IConnection) error) error {
+	conn, err := m.pool.OpenWithDatabase(bolt_mode.WriteMode, m.database)
+	if err != nil {
+		return err
+	}
+	defer m.pool.Reclaim(conn)
+
+	// the constraint makes concurrent MERGEs of the lock node safe
+	constraint := CreateUniqueConstraintQuery(conn.GetProtocolVersionNumber(), lockConstraintName, lockLabel, "name")
+	if _, err := conn.Exec(constraint, nil); err != nil && !hasFailureCode(err, "AlreadyExists") {
+		return errors.Wrap(err, "failed to create the migration lock constraint")
+	}
+
+	if err := m.lock(conn); err != nil {
+		return err
+	}
+
+	defer func() {
+		params := connection.QueryParams{"name": lockName, "owner": m.owner}
+		if _, err := conn.Exec(releaseLockQuery, params); err != nil {
+			log.Errorf("failed to release the migration lock: %s", err)
+		}
+	}()
+
+	return fn(conn)
+}
+
+// lock takes the lock node, waiting up to the lock timeout for another migrator to release it
+func (m *Migrator) lock(conn connection.IConnection) error {
+	params := connection.QueryParams{"name": lockName, "owner": m.owner}
+	deadline := time.Now().Add(m.lockTimeout)
+
+	for {
+		rows, _, err := conn.Query(acquireLockQuery, params)
+		// losing a race to create the lock node breaks the constraint, the winner holds the lock
+		if err != nil && !hasFailureCode(err, "ConstraintValidationFailed") && !hasFailureCode(err, "TransientError") {
+			return errors.Wrap(err, "failed to take the migration lock")
+		}
+
+		if err == nil && len(rows) == 1 && len(rows[0]) == 1 && rows[0][0] == m.owner {
+			return nil
+		}
+
+		if !time.Now().Before(deadline) {
+			return errors.Wrap(ErrLocked, "gave up waiting for the migration lock after %s", m.lockTimeout)
+		}
+
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+// hasFailureCode reports if err is a neo4j failure whose code contains part
+func hasFailureCode(err error, part string) bool {
+	if wrapped, ok := err.(*errors.Error); ok {
+		err = wrapped.InnerMost()
+	}
+
+	failure, ok := err.(messages.FailureMessage)
+	return ok && strings.Contains(failure.GetCode(), part)
+}
@@ -0,0 +1,54 @@
+package migrate
+
+import (
+	"fmt"
+	"strings"
+)
+
+// schema syntax changed with neo4j 4, bolt 4 is the first protocol version 4.x servers speak
+const namedSchemaProtocolVersion = 4
+
+// CreateIndexQuery returns the statement creating an index on label's properties.
+// Neo4j 4 names indexes, older servers identify them by label and properties
+func CreateIndexQuery(protocolVersion int, name, label string, properties ...string) string {
+	if protocolVersion >= namedSchemaProtocolVersion {
+		return fmt.Sprintf("CREATE INDEX %s FOR (n:%s) ON (%s)", name, label, nodeProperties("n", properties))
+	}
+
+	return fmt.Sprintf("CREATE INDEX ON :%s(%s)", label, strings.Join(properties, ", "))
+}
+
+// DropIndexQuery returns the statement dropping the index CreateIndexQuery created
+func DropIndexQuery(protocolVersion int, name, label string, properties ...string) string {
+	if protocolVersion >= namedSchemaProtocolVersion {
+		return fmt.Sprintf("DROP INDEX %s", name)
+	}
+
+	return fmt.Sprintf("DROP INDEX ON :%s(%s)", label, strings.Join(properties, ", "))
+}
+
+// CreateUniqueConstraintQuery returns the statement making property unique on nodes with label
+func CreateUniqueConstraintQuery(protocolVersion int, name, label, property string) string {
+	if protocolVersion >= namedSchemaProtocolVersion {
+		return fmt.Sprintf("CREATE CONSTRAINT %s ON (n:%s) ASSERT n.%s IS UNIQUE", name, label, property)
+	}
+
+	return fmt.Sprintf("CREATE CONSTRAINT ON (n:%s) ASSERT n.%s IS UNIQUE", label, property)
+}
+
+// DropUniqueConstraintQuery returns the statement dropping the constraint CreateUniqueConstraintQuery created
+func DropUniqueConstraintQuery(protocolVersion int, name, label, property string) string {
+	if protocolVersion >= namedSchemaProtocolVersion {
+		return fmt.Sprintf("DROP CONSTRAINT %s", name)
+	}
+
+	return fmt.Sprintf("DROP CONSTRAINT ON (n:%s) ASSERT n.%s IS UNIQUE", label, property)
+}
+
+func nodeProperties(variable string, properties []string) string {
+	qualified := make([]string, len(properties))
+	for i, property := range properties {
+		qualified[i] = variable + "." + property
+	}
+	return strings.Join(qualified, ", ")
+}
@@ -0,0 +1,42 @@
+package migrate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaQueries(t *testing.T) {
+	req := require.New(t)
+
+	req.Equal("CREATE INDEX ON :Person(name, age)", CreateIndexQuery(3, "person_name", "Person", "name", "age"))
+	req.Equal("CREATE INDEX person_name FOR (n:Person) ON (n.name, n.age)", CreateIndexQuery(4, "person_name", "Person", "name", "age"))
+
+	req.Equal("DROP INDEX ON :Person(name)", DropIndexQuery(1, "person_name", "Person", "name"))
+	req.Equal("DROP INDEX person_name", DropIndexQuery(4, "person_name", "Person", "name"))
+
+	req.Equal("CREATE CONSTRAINT ON (n:Person) ASSERT n.id IS UNIQUE", CreateUniqueConstraintQuery(2, "person_id", "Person", "id"))
+	req.Equal("CREATE CONSTRAINT person_id ON (n:Person) ASSERT n.id IS UNIQUE", CreateUniqueConstraintQuery(4, "person_id", "Person", "id"))
+
+	req.Equal("DROP CONSTRAINT ON (n:Person) ASSERT n.id IS UNIQUE", DropUniqueConstraintQuery(3, "person_id", "Person", "id"))
+	req.Equal("DROP CONSTRAINT person_id", DropUniqueConstraintQuery(4, "person_id", "Person", "id"))
+}
+
+func TestRender(t *testing.T) {
+	req := require.New(t)
+
+	script := `{{ if ge .ProtocolVersion 4 }}SHOW INDEXES{{ else }}CALL db.indexes(){{ end }}`
+	rendered, err := render(script, 4)
+	req.Nil(err)
+	req.Equal("SHOW INDEXES", rendered)
+
+	rendered, err = render(script, 3)
+	req.Nil(err)
+	req.Equal("CALL db.indexes()", rendered)
+
+	_, err = render("{{ createIndex }}", 4)
+	req.NotNil(err)
+
+	_, err = render("{{ unknown }}", 4)
+	req.NotNil(err)
+}
@@ -1,7 +1,11 @@
 package goBolt
 
 import (
+	"crypto/tls"
+	"github.com/mindstand/go-bolt/connection"
+	"github.com/mindstand/go-bolt/encoding"
 	"github.com/mindstand/go-bolt/errors"
+	"github.com/mindstand/go-bolt/routing"
 	"time"
 )
 
@@ -82,6 +86,57 @@ func WithBasicAuth(username, password string) Opt {
 	}
 }
 
+// allows encrypting connections with a tls config, for in memory certificates, server name and tls versions.
+// Can't be combined with WithTLS or the +s and +ssc schemes
+func WithTLSConfig(config *tls.Config) Opt {
+	return func(client *Client) error {
+		if client == nil {
+			return errors.Wrap(errors.ErrConfiguration, "client can not be nil")
+		}
+
+		if config == nil {
+			return errors.Wrap(errors.ErrConfiguration, "tls config can not be nil")
+		}
+
+		client.tlsConfig = config
+		return nil
+	}
+}
+
+// sets how connections reach neo4j, for proxies, unix sockets or in memory connections in tests.
+// The dialer is given the host and port from the connection string, see connection.SOCKS5Dialer
+func WithDialer(dialer connection.DialFunc) Opt {
+	return func(client *Client) error {
+		if client == nil {
+			return errors.Wrap(errors.ErrConfiguration, "client can not be nil")
+		}
+
+		if dialer == nil {
+			return errors.Wrap(errors.ErrConfiguration, "dialer can not be nil")
+		}
+
+		client.dialer = dialer
+		return nil
+	}
+}
+
+// records the bytes every connection sends and receives, for bug reports and replaying with
+// connection.ReplayDialer. Captures hold the credentials connections authenticate with
+func WithCapture(capture *connection.Capture) Opt {
+	return func(client *Client) error {
+		if client == nil {
+			return errors.Wrap(errors.ErrConfiguration, "client can not be nil")
+		}
+
+		if capture == nil {
+			return errors.Wrap(errors.ErrConfiguration, "capture can not be nil")
+		}
+
+		client.capture = capture
+		return nil
+	}
+}
+
 // allows authentication with tls
 func WithTLS(cacertPath, certPath, keyPath string, tlsNoVerify bool) Opt {
 	return func(client *Client) error {
@@ -156,6 +211,37 @@ func WithStrictProtocolVersion(version int) Opt {
 	}
 }
 
+// offers bolt 4.1 to 4.4 when connecting in place of bolt v1, so neo4j 3.0 - 3.3 can't be reached.
+// Impersonation needs bolt 4.4
+func WithBoltMinorVersions() Opt {
+	return func(client *Client) error {
+		if client == nil {
+			return errors.Wrap(errors.ErrConfiguration, "client can not be nil")
+		}
+
+		client.minorVersions = true
+		return nil
+	}
+}
+
+// bounds the size, collection length and nesting of the messages connections read from the server.
+// Limits left at zero keep encoding.DefaultLimits, encoding.NoLimit turns one off
+func WithDecoderLimits(limits encoding.Limits) Opt {
+	return func(client *Client) error {
+		if client == nil {
+			return errors.Wrap(errors.ErrConfiguration, "client can not be nil")
+		}
+
+		if limits.MaxMessageSize < encoding.NoLimit || limits.MaxCollectionLength < encoding.NoLimit || limits.MaxDepth < encoding.NoLimit {
+			return errors.Wrap(errors.ErrConfiguration, "decoder limits can not be below encoding.NoLimit")
+		}
+
+		limits = limits.WithDefaults()
+		client.decoderLimits = &limits
+		return nil
+	}
+}
+
 // tells client what timeout it should use
 func WithTimeout(timeout time.Duration) Opt {
 	return func(client *Client) error {
@@ -167,3 +253,141 @@ func WithTimeout(timeout time.Duration) Opt {
 		return nil
 	}
 }
+
+// tells pools what to do when every connection is borrowed, defaults to routing.AcquireBlock
+func WithAcquireStrategy(strategy routing.AcquireStrategy) Opt {
+	return func(client *Client) error {
+		if client == nil {
+			return errors.Wrap(errors.ErrConfiguration, "client can not be nil")
+		}
+
+		if strategy < routing.AcquireBlock || strategy > routing.AcquireFailFast {
+			return errors.Wrap(errors.ErrConfiguration, "unknown acquire strategy [%v]", strategy)
+		}
+
+		client.acquireStrategy = strategy
+		return nil
+	}
+}
+
+// tells pools how long to wait for a connection to be reclaimed
+func WithAcquireTimeout(timeout time.Duration) Opt {
+	return func(client *Client) error {
+		if client == nil {
+			return errors.Wrap(errors.ErrConfiguration, "client can not be nil")
+		}
+
+		if timeout <= 0 {
+			return errors.Wrap(errors.ErrConfiguration, "acquire timeout must be greater than 0")
+		}
+
+		client.acquireTimeout = timeout
+		return nil
+	}
+}
+
+// allows the grow strategy to open up to maxOverflow connections past the pool size
+func WithMaxOverflow(maxOverflow int) Opt {
+	return func(client *Client) error {
+		if client == nil {
+			return errors.Wrap(errors.ErrConfiguration, "client can not be nil")
+		}
+
+		if maxOverflow < 0 {
+			return errors.Wrap(errors.ErrConfiguration, "max overflow can not be negative")
+		}
+
+		client.maxOverflow = maxOverflow
+		return nil
+	}
+}
+
+// tells pools how long a connection is used before it is closed, 0 keeps connections forever
+func WithMaxConnectionLifetime(lifetime time.Duration) Opt {
+	return func(client *Client) error {
+		if client == nil {
+			return errors.Wrap(errors.ErrConfiguration, "client can not be nil")
+		}
+
+		if lifetime < 0 {
+			return errors.Wrap(errors.ErrConfiguration, "max connection lifetime can not be negative")
+		}
+
+		client.maxConnLifetime = lifetime
+		return nil
+	}
+}
+
+// tells pools to ping connections idle longer than threshold before handing them out.
+// 0 pings on every borrow, negative disables the check
+func WithIdleTestThreshold(threshold time.Duration) Opt {
+	return func(client *Client) error {
+		if client == nil {
+			return errors.Wrap(errors.ErrConfiguration, "client can not be nil")
+		}
+
+		client.idleTestThreshold = threshold
+		return nil
+	}
+}
+
+// sets the tcp keep alive period, 0 uses the go default and negative disables keep alives
+func WithKeepAlive(keepAlive time.Duration) Opt {
+	return func(client *Client) error {
+		if client == nil {
+			return errors.Wrap(errors.ErrConfiguration, "client can not be nil")
+		}
+
+		client.keepAlive = keepAlive
+		return nil
+	}
+}
+
+// configures the connection pool used without routing, start from DefaultPoolConfig
+func WithPoolConfig(config PoolConfig) Opt {
+	return func(client *Client) error {
+		if client == nil {
+			return errors.Wrap(errors.ErrConfiguration, "client can not be nil")
+		}
+
+		if err := config.validate(); err != nil {
+			return errors.Wrap(errors.ErrConfiguration, err.Error())
+		}
+
+		client.poolConfig = config
+		return nil
+	}
+}
+
+// sets the database queries use when they don't name one, requires bolt v4
+func WithDatabase(db string) Opt {
+	return func(client *Client) error {
+		if client == nil {
+			return errors.Wrap(errors.ErrConfiguration, "client can not be nil")
+		}
+
+		if db == "" {
+			return errors.Wrap(errors.ErrConfiguration, "database can not be empty")
+		}
+
+		client.database = db
+		return nil
+	}
+}
+
+// sets where connections get their credentials from, replacing basic auth.
+// See connection.BasicAuth, BearerAuth, KerberosAuth, CustomAuth, NoAuth and RotatingAuth
+func WithAuthProvider(provider connection.AuthProvider) Opt {
+	return func(client *Client) error {
+		if client == nil {
+			return errors.Wrap(errors.ErrConfiguration, "client can not be nil")
+		}
+
+		if provider == nil {
+			return errors.Wrap(errors.ErrConfiguration, "auth provider can not be nil")
+		}
+
+		client.authProvider = provider
+		return nil
+	}
+}
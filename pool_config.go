@@ -0,0 +1,106 @@
+package goBolt
+
+import (
+	pool "github.com/jolestar/go-commons-pool"
+	"github.com/mindstand/go-bolt/errors"
+	"github.com/mindstand/go-bolt/routing"
+	"time"
+)
+
+// DefaultEvictionInterval is how often the idle evictor checks pooled connections
+const DefaultEvictionInterval = time.Minute
+
+// PoolConfig configures the connection pool used when routing is off.
+// Zero values for MaxTotal, MaxIdle and MaxWait fall back to the pool size and acquire timeout
+type PoolConfig struct {
+	// MinIdle is the number of idle connections the evictor keeps open
+	MinIdle int
+	// MaxIdle is the most idle connections kept, defaults to the pool size
+	MaxIdle int
+	// MaxTotal caps idle and borrowed connections, defaults to the pool size. Can't be below the pool size
+	MaxTotal int
+	// MaxWait is how long a borrow waits for a connection, defaults to the acquire timeout
+	MaxWait time.Duration
+	// EvictionInterval is how often idle connections are checked, 0 disables the evictor
+	EvictionInterval time.Duration
+	// IdleEvictionTime is how long a connection can sit idle before the evictor closes it, 0 disables
+	IdleEvictionTime time.Duration
+	// LIFO hands out the most recently used connection first, FIFO otherwise
+	LIFO bool
+
+	TestOnCreate  bool
+	TestOnBorrow  bool
+	TestOnReturn  bool
+	TestWhileIdle bool
+}
+
+// DefaultPoolConfig returns the pool config used unless WithPoolConfig is passed
+func DefaultPoolConfig() PoolConfig {
+	return PoolConfig{
+		EvictionInterval: DefaultEvictionInterval,
+		IdleEvictionTime: pool.DefaultMinEvictableIdleTime,
+		LIFO:             true,
+		TestOnBorrow:     true,
+		TestWhileIdle:    true,
+	}
+}
+
+// validate checks the settings that don't depend on the pool size
+func (p PoolConfig) validate() error {
+	if p.MinIdle < 0 || p.MaxIdle < 0 || p.MaxTotal < 0 {
+		return errors.New("pool limits can not be negative")
+	}
+
+	if p.MaxWait < 0 || p.EvictionInterval < 0 || p.IdleEvictionTime < 0 {
+		return errors.New("pool durations can not be negative")
+	}
+
+	return nil
+}
+
+// resolve fills in defaults from the client and checks the limits against size
+func (p PoolConfig) resolve(client *Client, size int) (PoolConfig, error) {
+	if size < 1 {
+		return p, errors.Wrap(errors.ErrConfiguration, "pool size must be at least 1, provided [%v]", size)
+	}
+
+	if err := p.validate(); err != nil {
+		return p, errors.Wrap(errors.ErrConfiguration, err.Error())
+	}
+
+	if p.MaxTotal == 0 {
+		p.MaxTotal = size
+		// grow lets the pool open overflow connections, only size of them are kept idle
+		if client.acquireStrategy == routing.AcquireGrow {
+			p.MaxTotal += client.maxOverflow
+		}
+	} else if p.MaxTotal < size {
+		return p, errors.Wrap(errors.ErrConfiguration, "max total [%v] can not be less than pool size [%v]", p.MaxTotal, size)
+	}
+
+	if p.MaxIdle == 0 {
+		p.MaxIdle = size
+	} else if p.MaxIdle > p.MaxTotal {
+		return p, errors.Wrap(errors.ErrConfiguration, "max idle [%v] can not be greater than max total [%v]", p.MaxIdle, p.MaxTotal)
+	}
+
+	if p.MinIdle > p.MaxIdle {
+		return p, errors.Wrap(errors.ErrConfiguration, "min idle [%v] can not be greater than max idle [%v]", p.MinIdle, p.MaxIdle)
+	}
+
+	if p.MaxWait == 0 {
+		p.MaxWait = client.acquireTimeout
+	}
+
+	return p, nil
+}
+
+// PoolStats is a snapshot of a pool's connections
+type PoolStats struct {
+	// Active is the number of borrowed connections
+	Active int
+	// Idle is the number of connections waiting to be borrowed
+	Idle int
+	// Waiters is the number of borrows that haven't been handed a connection yet
+	Waiters int
+}
@@ -18,7 +18,7 @@ type IBoltProtocol interface {
 	GetInitMessage(client string, authToken map[string]interface{}) structures.Structure
 	// creates begin message for the tx
 	// different versions of the protocol use either BeginMessage or RunMessage with the command BEGIN
-	GetTxBeginMessage(database string, accessMode bolt_mode.AccessMode) structures.Structure
+	GetTxBeginMessage(database, impersonatedUser string, accessMode bolt_mode.AccessMode) structures.Structure
 	// creates commit message for the tx
 	// different versions of the protocol use either CommitMessage or RunMessage with the command COMMIT
 	GetTxCommitMessage() structures.Structure
@@ -30,14 +30,21 @@ type IBoltProtocol interface {
 	GetCloseMessage() (structures.Structure, bool)
 	// creates run message
 	// newer versions of bolt protocol require additional information in run message for database specification, tx, and r/w modes
-	GetRunMessage(query string, params map[string]interface{}, dbName string, mode bolt_mode.AccessMode, autoCommit bool) structures.Structure
+	GetRunMessage(query string, params map[string]interface{}, dbName, impersonatedUser string, mode bolt_mode.AccessMode, autoCommit bool) structures.Structure
 	// creates pull all message
 	GetPullAllMessage() structures.Structure
+	// creates a pull message for n records of the stream identified by qid
+	// versions without qid support ignore n and qid and pull everything
+	GetPullMessage(n, qid int64) structures.Structure
 	// gets discard message
 	GetDiscardMessage(qid int64) structures.Structure
 	GetDiscardAllMessage() structures.Structure
 	// newer versions of bolt protocol allow for multi database support
 	SupportsMultiDatabase() bool
+	// bolt 4.4+ can run transactions as another user with imp_user
+	SupportsImpersonation() bool
+	// newer versions of bolt protocol allow several open result streams in an explicit transaction
+	SupportsMultipleResultStreams() bool
 
 	GetResultAvailableAfterKey() string
 	GetResultConsumedAfterKey() string
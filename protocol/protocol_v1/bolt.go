@@ -32,11 +32,19 @@ func (b *BoltProtocolV1) SupportsMultiDatabase() bool {
 	return false
 }
 
+func (b *BoltProtocolV1) SupportsImpersonation() bool {
+	return false
+}
+
+func (b *BoltProtocolV1) SupportsMultipleResultStreams() bool {
+	return false
+}
+
 func (b *BoltProtocolV1) GetCloseMessage() (structures.Structure, bool) {
 	return nil, false
 }
 
-func (b *BoltProtocolV1) GetTxBeginMessage(database string, accessMode bolt_mode.AccessMode) structures.Structure {
+func (b *BoltProtocolV1) GetTxBeginMessage(database, impersonatedUser string, accessMode bolt_mode.AccessMode) structures.Structure {
 	return messages.NewRunMessage("BEGIN", nil)
 }
 
@@ -52,7 +60,7 @@ func (b *BoltProtocolV1) GetInitMessage(client string, authToken map[string]inte
 	return messages.NewInitMessage(client, authToken)
 }
 
-func (b *BoltProtocolV1) GetRunMessage(query string, params map[string]interface{}, dbName string, mode bolt_mode.AccessMode, autoCommit bool) structures.Structure {
+func (b *BoltProtocolV1) GetRunMessage(query string, params map[string]interface{}, dbName, impersonatedUser string, mode bolt_mode.AccessMode, autoCommit bool) structures.Structure {
 	return messages.NewRunMessage(query, params)
 }
 
@@ -60,6 +68,10 @@ func (b *BoltProtocolV1) GetPullAllMessage() structures.Structure {
 	return messages.NewPullAllMessage()
 }
 
+func (b *BoltProtocolV1) GetPullMessage(n, qid int64) structures.Structure {
+	return messages.NewPullAllMessage()
+}
+
 func (b *BoltProtocolV1) Marshal(v interface{}) ([]byte, error) {
 	return encoding_v1.Marshal(v)
 }
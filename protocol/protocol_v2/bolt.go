@@ -32,11 +32,19 @@ func (b *BoltProtocolV2) SupportsMultiDatabase() bool {
 	return false
 }
 
+func (b *BoltProtocolV2) SupportsImpersonation() bool {
+	return false
+}
+
+func (b *BoltProtocolV2) SupportsMultipleResultStreams() bool {
+	return false
+}
+
 func (b *BoltProtocolV2) GetCloseMessage() (structures.Structure, bool) {
 	return nil, false
 }
 
-func (b *BoltProtocolV2) GetTxBeginMessage(database string, accessMode bolt_mode.AccessMode) structures.Structure {
+func (b *BoltProtocolV2) GetTxBeginMessage(database, impersonatedUser string, accessMode bolt_mode.AccessMode) structures.Structure {
 	return messages.NewRunMessage("BEGIN", nil)
 }
 
@@ -52,7 +60,7 @@ func (b *BoltProtocolV2) GetInitMessage(client string, authToken map[string]inte
 	return messages.NewInitMessage(client, authToken)
 }
 
-func (b *BoltProtocolV2) GetRunMessage(query string, params map[string]interface{}, dbName string, mode bolt_mode.AccessMode, autoCommit bool) structures.Structure {
+func (b *BoltProtocolV2) GetRunMessage(query string, params map[string]interface{}, dbName, impersonatedUser string, mode bolt_mode.AccessMode, autoCommit bool) structures.Structure {
 	return messages.NewRunMessage(query, params)
 }
 
@@ -60,6 +68,10 @@ func (b *BoltProtocolV2) GetPullAllMessage() structures.Structure {
 	return messages.NewPullAllMessage()
 }
 
+func (b *BoltProtocolV2) GetPullMessage(n, qid int64) structures.Structure {
+	return messages.NewPullAllMessage()
+}
+
 func (b *BoltProtocolV2) Marshal(v interface{}) ([]byte, error) {
 	return encoding_v2.Marshal(v)
 }
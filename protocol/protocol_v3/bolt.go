@@ -31,6 +31,14 @@ func (b *BoltProtocolV3) SupportsMultiDatabase() bool {
 	return false
 }
 
+func (b *BoltProtocolV3) SupportsImpersonation() bool {
+	return false
+}
+
+func (b *BoltProtocolV3) SupportsMultipleResultStreams() bool {
+	return false
+}
+
 func (b *BoltProtocolV3) GetInitMessage(client string, authToken map[string]interface{}) structures.Structure {
 	if authToken == nil {
 		authToken = map[string]interface{}{}
@@ -45,7 +53,7 @@ func (b *BoltProtocolV3) GetCloseMessage() (structures.Structure, bool) {
 	return messages.NewGoodbyeMessage(), true
 }
 
-func (b *BoltProtocolV3) GetTxBeginMessage(database string, accessMode bolt_mode.AccessMode) structures.Structure {
+func (b *BoltProtocolV3) GetTxBeginMessage(database, impersonatedUser string, accessMode bolt_mode.AccessMode) structures.Structure {
 	return messages.NewBeginMessage(messages.BuildTxMetadataWithDatabase(nil, nil, database, accessMode, nil))
 }
 
@@ -57,7 +65,7 @@ func (b *BoltProtocolV3) GetTxRollbackMessage() structures.Structure {
 	return messages.NewRollbackMessage()
 }
 
-func (b *BoltProtocolV3) GetRunMessage(query string, params map[string]interface{}, dbName string, mode bolt_mode.AccessMode, autoCommit bool) structures.Structure {
+func (b *BoltProtocolV3) GetRunMessage(query string, params map[string]interface{}, dbName, impersonatedUser string, mode bolt_mode.AccessMode, autoCommit bool) structures.Structure {
 	if autoCommit {
 		return messages.NewAutoCommitTxRunMessage(query, params, 0, nil, dbName, mode)
 	} else {
@@ -69,6 +77,10 @@ func (b *BoltProtocolV3) GetPullAllMessage() structures.Structure {
 	return messages.NewPullAllMessage()
 }
 
+func (b *BoltProtocolV3) GetPullMessage(n, qid int64) structures.Structure {
+	return messages.NewPullAllMessage()
+}
+
 func (b *BoltProtocolV3) Marshal(v interface{}) ([]byte, error) {
 	return encoding_v2.Marshal(v)
 }
@@ -9,7 +9,10 @@ import (
 	"io"
 )
 
-type BoltProtocolV4 struct{}
+// BoltProtocolV4 covers bolt 4.0 through 4.4, newer minor versions only add optional fields
+type BoltProtocolV4 struct {
+	MinorVersion int
+}
 
 func (b *BoltProtocolV4) GetResultAvailableAfterKey() string {
 	return "t_first"
@@ -31,6 +34,14 @@ func (b *BoltProtocolV4) SupportsMultiDatabase() bool {
 	return true
 }
 
+func (b *BoltProtocolV4) SupportsImpersonation() bool {
+	return b.MinorVersion >= 4
+}
+
+func (b *BoltProtocolV4) SupportsMultipleResultStreams() bool {
+	return true
+}
+
 func (b *BoltProtocolV4) GetInitMessage(client string, authToken map[string]interface{}) structures.Structure {
 	if authToken == nil {
 		authToken = map[string]interface{}{}
@@ -45,8 +56,8 @@ func (b *BoltProtocolV4) GetCloseMessage() (structures.Structure, bool) {
 	return messages.NewGoodbyeMessage(), true
 }
 
-func (b *BoltProtocolV4) GetTxBeginMessage(database string, accessMode bolt_mode.AccessMode) structures.Structure {
-	return messages.NewBeginMessage(messages.BuildTxMetadataWithDatabase(nil, nil, database, accessMode, nil))
+func (b *BoltProtocolV4) GetTxBeginMessage(database, impersonatedUser string, accessMode bolt_mode.AccessMode) structures.Structure {
+	return messages.NewBeginMessage(messages.BuildTxMetadataWithImpersonation(nil, nil, database, impersonatedUser, accessMode, nil))
 }
 
 func (b *BoltProtocolV4) GetTxCommitMessage() structures.Structure {
@@ -57,9 +68,9 @@ func (b *BoltProtocolV4) GetTxRollbackMessage() structures.Structure {
 	return messages.NewRollbackMessage()
 }
 
-func (b *BoltProtocolV4) GetRunMessage(query string, params map[string]interface{}, dbName string, mode bolt_mode.AccessMode, autoCommit bool) structures.Structure {
+func (b *BoltProtocolV4) GetRunMessage(query string, params map[string]interface{}, dbName, impersonatedUser string, mode bolt_mode.AccessMode, autoCommit bool) structures.Structure {
 	if autoCommit {
-		return messages.NewAutoCommitTxRunMessage(query, params, 0, nil, dbName, mode)
+		return messages.NewImpersonatedAutoCommitTxRunMessage(query, params, 0, nil, dbName, impersonatedUser, mode)
 	} else {
 		return messages.NewUnmanagedTxRunMessage(query, params)
 	}
@@ -69,6 +80,10 @@ func (b *BoltProtocolV4) GetPullAllMessage() structures.Structure {
 	return messages.NewPullMessage(messages.StreamUnlimited, messages.AbsentQueryId)
 }
 
+func (b *BoltProtocolV4) GetPullMessage(n, qid int64) structures.Structure {
+	return messages.NewPullMessage(n, qid)
+}
+
 func (b *BoltProtocolV4) Marshal(v interface{}) ([]byte, error) {
 	return encoding_v2.Marshal(v)
 }
@@ -2,8 +2,12 @@ package protocol_v4
 
 const (
 	ProtocolVersion = 4
+	// MaxMinorVersion is the newest 4.x release the driver speaks
+	MaxMinorVersion = 4
 )
 
 var (
 	ProtocolVersionBytes = []byte{0x00, 0x00, 0x00, 0x04}
+	// ProtocolVersion44Bytes is bolt 4.4, the first version with impersonation
+	ProtocolVersion44Bytes = []byte{0x00, 0x00, 0x04, 0x04}
 )
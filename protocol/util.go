@@ -21,8 +21,9 @@ func GetProtocol(version []byte) (IBoltProtocol, int, error) {
 		return &protocol_v2.BoltProtocolV2{}, protocol_v2.ProtocolVersion, nil
 	} else if bytes.Equal(version, protocol_v3.ProtocolVersionBytes) {
 		return &protocol_v3.BoltProtocolV3{}, protocol_v3.ProtocolVersion, nil
-	} else if bytes.Equal(version, protocol_v4.ProtocolVersionBytes) {
-		return &protocol_v4.BoltProtocolV4{}, protocol_v4.ProtocolVersion, nil
+	} else if len(version) == 4 && version[0] == 0x00 && version[1] == 0x00 && version[3] == 0x04 && version[2] <= protocol_v4.MaxMinorVersion {
+		// bolt 4 versions are [0, 0, minor, 4]
+		return &protocol_v4.BoltProtocolV4{MinorVersion: int(version[2])}, protocol_v4.ProtocolVersion, nil
 	} else {
 		return nil, -1, fmt.Errorf("protocol with bytes [%v] not supported", version)
 	}
@@ -41,6 +41,19 @@ func TestGetProtocol(t *testing.T) {
 	req.IsType(&protocol_v4.BoltProtocolV4{}, protocol)
 	req.Equal(4, version)
 
+	// test v4.4
+	protocol, version, err = GetProtocol(protocol_v4.ProtocolVersion44Bytes)
+	req.Nil(err)
+	req.Equal(&protocol_v4.BoltProtocolV4{MinorVersion: 4}, protocol)
+	req.True(protocol.SupportsImpersonation())
+	req.Equal(4, version)
+
+	// minor versions past the newest supported aren't accepted
+	protocol, version, err = GetProtocol([]byte{0x00, 0x00, 0x05, 0x04})
+	req.NotNil(err)
+	req.Equal(-1, version)
+	req.Nil(protocol)
+
 	// test nil
 	protocol, version, err = GetProtocol(nil)
 	req.NotNil(err)
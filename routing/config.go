@@ -0,0 +1,152 @@
+package routing
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"github.com/mindstand/go-bolt/connection"
+	"github.com/mindstand/go-bolt/encoding"
+	"time"
+)
+
+const (
+	// DefaultAcquireTimeout is how long a borrow waits for a connection when a server's pool is exhausted
+	DefaultAcquireTimeout = 30 * time.Second
+	// DefaultRefreshInterval is how often cluster info is refreshed and the server pools are maintained
+	DefaultRefreshInterval = 2 * time.Minute
+)
+
+// AcquireStrategy decides what a borrow does when every eligible server is at MaxConnsPerServer
+type AcquireStrategy int
+
+const (
+	// AcquireBlock waits up to AcquireTimeout for a connection to be reclaimed
+	AcquireBlock AcquireStrategy = iota
+	// AcquireGrow opens up to MaxOverflowPerServer extra connections, then waits like AcquireBlock.
+	// Overflow connections are closed when they are reclaimed
+	AcquireGrow
+	// AcquireFailFast returns ErrPoolExhausted without waiting
+	AcquireFailFast
+)
+
+func (a AcquireStrategy) String() string {
+	switch a {
+	case AcquireBlock:
+		return "block"
+	case AcquireGrow:
+		return "grow"
+	case AcquireFailFast:
+		return "fail fast"
+	default:
+		return fmt.Sprintf("AcquireStrategy(%d)", int(a))
+	}
+}
+
+// Config configures the routing pool. Limits apply to each server in the cluster individually
+type Config struct {
+	// MaxConnsPerServer caps the idle and borrowed connections held to a single server
+	MaxConnsPerServer int
+	// MinIdlePerServer is the number of idle connections kept open to every server
+	MinIdlePerServer int
+	// MaxIdlePerServer is the most idle connections kept to a server, extras are closed on reclaim
+	MaxIdlePerServer int
+	// AcquireStrategy is what a borrow does once every eligible server is at MaxConnsPerServer
+	AcquireStrategy AcquireStrategy
+	// AcquireTimeout is how long a borrow blocks waiting for a connection to be reclaimed
+	AcquireTimeout time.Duration
+	// MaxOverflowPerServer is how many connections past MaxConnsPerServer AcquireGrow may open to a server
+	MaxOverflowPerServer int
+	// RefreshInterval is how often cluster info is refreshed and idle limits are enforced
+	RefreshInterval time.Duration
+	// MaxConnLifetime is how long a connection is used before it is closed, 0 keeps connections forever
+	MaxConnLifetime time.Duration
+	// IdleTestThreshold is how long a connection can sit idle before it is pinged on borrow.
+	// 0 pings on every borrow, negative never pings
+	IdleTestThreshold time.Duration
+	// KeepAlive is the tcp keep alive period. 0 uses the go default, negative disables keep alives
+	KeepAlive time.Duration
+	// Database is used by borrows that don't name one. Empty routes by the system database
+	// roles and leaves neo4j to pick its default database
+	Database string
+	// AuthProvider supplies the token connections authenticate with, replacing the basic auth part
+	AuthProvider connection.AuthProvider
+	// TLSConfig turns on TLS for every member with this config
+	TLSConfig *tls.Config
+	// Dialer opens the network connection to every member instead of a tcp dial
+	Dialer connection.DialFunc
+	// Capture records the bytes every member connection sends and receives
+	Capture *connection.Capture
+	// MinorVersions offers bolt 4.1 to 4.4 to every member in place of bolt v1. Impersonation needs bolt 4.4
+	MinorVersions bool
+	// DecoderLimits bounds the messages every member connection reads, nil keeps encoding.DefaultLimits
+	DecoderLimits *encoding.Limits
+}
+
+// DefaultConfig returns the routing config used for a pool of the given per server size
+func DefaultConfig(maxConnsPerServer int) Config {
+	return Config{
+		MaxConnsPerServer: maxConnsPerServer,
+		MinIdlePerServer:  1,
+		MaxIdlePerServer:  maxConnsPerServer,
+		AcquireStrategy:   AcquireBlock,
+		AcquireTimeout:    DefaultAcquireTimeout,
+		RefreshInterval:   DefaultRefreshInterval,
+		MaxConnLifetime:   connection.DefaultMaxConnLifetime,
+		IdleTestThreshold: connection.DefaultIdleTestThreshold,
+	}
+}
+
+func (c Config) validate() error {
+	if c.MaxConnsPerServer < 1 {
+		return fmt.Errorf("max connections per server must be at least 1, provided [%v]", c.MaxConnsPerServer)
+	}
+
+	if c.MinIdlePerServer < 0 || c.MaxIdlePerServer < 0 {
+		return errors.New("idle limits can not be negative")
+	}
+
+	if c.MinIdlePerServer > c.MaxIdlePerServer {
+		return fmt.Errorf("min idle [%v] can not be greater than max idle [%v]", c.MinIdlePerServer, c.MaxIdlePerServer)
+	}
+
+	if c.MaxIdlePerServer > c.MaxConnsPerServer {
+		return fmt.Errorf("max idle [%v] can not be greater than max connections [%v]", c.MaxIdlePerServer, c.MaxConnsPerServer)
+	}
+
+	if c.AcquireStrategy < AcquireBlock || c.AcquireStrategy > AcquireFailFast {
+		return fmt.Errorf("unknown acquire strategy [%v]", c.AcquireStrategy)
+	}
+
+	if c.MaxOverflowPerServer < 0 {
+		return errors.New("max overflow can not be negative")
+	}
+
+	if c.AcquireTimeout < 0 {
+		return errors.New("acquire timeout can not be negative")
+	}
+
+	if c.RefreshInterval <= 0 {
+		return errors.New("refresh interval must be greater than 0")
+	}
+
+	if c.MaxConnLifetime < 0 {
+		return errors.New("max connection lifetime can not be negative")
+	}
+
+	return nil
+}
+
+func (c Config) healthCheck() connection.HealthCheck {
+	return connection.HealthCheck{
+		MaxLifetime:       c.MaxConnLifetime,
+		IdleTestThreshold: c.IdleTestThreshold,
+	}
+}
+
+// connLimit is the most connections a borrow may hold open to one server
+func (c Config) connLimit() int {
+	if c.AcquireStrategy == AcquireGrow {
+		return c.MaxConnsPerServer + c.MaxOverflowPerServer
+	}
+	return c.MaxConnsPerServer
+}
@@ -1,6 +1,9 @@
 package routing
 
-import "github.com/mindstand/go-bolt/connection"
+import (
+	"github.com/mindstand/go-bolt/bolt_mode"
+	"github.com/mindstand/go-bolt/connection"
+)
 
 type IRoutingPool interface {
 	Start() error
@@ -8,6 +11,35 @@ type IRoutingPool interface {
 
 	BorrowRConnection() (connection.IConnection, error)
 	BorrowRWConnection() (connection.IConnection, error)
+	// BorrowConnection borrows a connection to a member serving db, queries on it default to db.
+	// Empty db uses Config.Database
+	BorrowConnection(mode bolt_mode.AccessMode, db string) (connection.IConnection, error)
+	// BorrowSession borrows a connection set up for session
+	BorrowSession(mode bolt_mode.AccessMode, session Session) (connection.IConnection, error)
 
 	Reclaim(conn connection.IConnection) error
+
+	// Stats totals the connections across every server
+	Stats() Stats
+}
+
+// Session selects the database and user a borrowed connection runs queries with
+type Session struct {
+	// Database queries default to, empty uses Config.Database
+	Database string
+	// ImpersonatedUser runs queries as another user, requires bolt 4.4
+	ImpersonatedUser string
+	// AuthToken replaces the pool's credentials. Bolt 4 can't re-authenticate a connection so every
+	// token gets its own connections to each server, with the same per server limits
+	AuthToken map[string]interface{}
+}
+
+// Stats is a snapshot of the routing pool's connections
+type Stats struct {
+	// Active is the number of borrowed connections, including ones being opened
+	Active int
+	// Idle is the number of connections waiting to be borrowed
+	Idle int
+	// Waiters is the number of borrows blocked waiting for a connection
+	Waiters int
 }
@@ -10,513 +10,612 @@ import (
 
 	"github.com/mindstand/go-bolt/bolt_mode"
 	"github.com/mindstand/go-bolt/connection"
+	boltErrors "github.com/mindstand/go-bolt/errors"
 	"github.com/mindstand/go-bolt/log"
 )
 
+// ErrAcquireTimeout is returned when no connection frees up within the acquire timeout
+var ErrAcquireTimeout = errors.New("timed out waiting for a connection")
+
+// ErrPoolExhausted is returned by the fail fast strategy when every eligible server is at capacity
+var ErrPoolExhausted = errors.New("no connections available")
+
+// ErrUnknownDatabase is returned when no cluster member hosts the requested database
+var ErrUnknownDatabase = errors.New("database not found in routing table")
+
+// databaseRoutes are the members serving a single database
+type databaseRoutes struct {
+	writers []string
+	readers []string
+}
+
 type routingPool struct {
 	userPassPart  string
 	tlsInfo       string
 	leaderConnStr string
+	// scheme used to connect to cluster members
+	memberScheme string
+
+	config Config
+	health connection.HealthCheck
+
+	// guards everything below
+	mutex sync.Mutex
+	// closed and replaced whenever a connection slot frees up, waking blocked borrows
+	released chan struct{}
+
+	running int32
+	stop    chan struct{}
+	done    chan struct{}
+
+	routingHandler *boltRoutingHandler
+	// creates connections, a nil auth token uses the credentials in connStr. Swapped out in tests
+	connFactory func(connStr string, authToken map[string]interface{}) (connection.IConnection, error)
+
+	// per server pools keyed by connection string
+	servers map[string]*serverPool
+	writers []string
+	readers []string
+	// routes for each database, only known on bolt v4
+	databases map[string]databaseRoutes
+	// spreads ties between equally loaded servers
+	nextServer int
 
-	// access mutex
-	mutex sync.RWMutex
-
-	// running
-	running *int32
-	// is updating
-	updating *int32
-
-	// configuration
-	totalConns      int
-	minWriteIdle    int
-	maxWriteIdle    int
-	minReadIdle     int
-	maxReadIdle     int
-	refreshInterval time.Duration
-	routingHandler  *boltRoutingHandler
-
-	// lookups
-	connStrLookup map[string][]*connectionPoolWrapper
 	borrowedConns map[string]*connectionPoolWrapper
-	heldConns     map[string]*connectionPoolWrapper
-
-	// write stuff
-	rwStrings  []string
-	rwIndex    int
-	writeQueue *Queue
-
-	// read stuff
-	readStrings []string
-	readIndex   int
-	readQueue   *Queue
+	connCount     uint64
+	// borrows blocked waiting on released
+	waiters int
 }
 
 // NewRoutingPool creates a new routing pools
-func NewRoutingPool(leaderConnStr string, numConns int, refreshInterval time.Duration, authPart, tlsInfo string) (IRoutingPool, error) {
+func NewRoutingPool(leaderConnStr string, config Config, authPart, tlsInfo string) (IRoutingPool, error) {
 	if leaderConnStr == "" {
 		return nil, errors.New("leaderConnStr can not be nil")
 	}
 
-	if strings.Contains(leaderConnStr, "+routing") {
-		leaderConnStr = strings.Replace(leaderConnStr, "+routing", "", -1)
+	schemeEnd := strings.Index(leaderConnStr, "://")
+	if schemeEnd < 0 {
+		return nil, fmt.Errorf("leaderConnStr [%s] is missing a scheme", leaderConnStr)
 	}
 
-	if numConns == 0 || numConns == 1 {
-		return nil, fmt.Errorf("number of connections must be greater than 1, provided [%v]", numConns)
+	scheme, err := connection.ParseScheme(leaderConnStr[:schemeEnd])
+	if err != nil {
+		return nil, err
 	}
 
-	run := int32(0)
-	update := int32(0)
+	// cluster members are connected to directly, keeping the leader's encryption
+	memberScheme := scheme.Direct()
+	leaderConnStr = memberScheme + leaderConnStr[schemeEnd:]
+
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	connConfig := connection.Config{
+		KeepAlive:     config.KeepAlive,
+		AuthProvider:  config.AuthProvider,
+		TLSConfig:     config.TLSConfig,
+		Dialer:        config.Dialer,
+		Capture:       config.Capture,
+		MinorVersions: config.MinorVersions,
+		DecoderLimits: config.DecoderLimits,
+	}
 
 	return &routingPool{
-		userPassPart:    authPart,
-		tlsInfo:         tlsInfo,
-		leaderConnStr:   leaderConnStr,
-		mutex:           sync.RWMutex{},
-		running:         &run,
-		updating:        &update,
-		totalConns:      numConns,
-		minWriteIdle:    0,
-		maxWriteIdle:    0,
-		minReadIdle:     0,
-		maxReadIdle:     0,
-		refreshInterval: refreshInterval,
+		userPassPart:  authPart,
+		tlsInfo:       tlsInfo,
+		leaderConnStr: leaderConnStr,
+		memberScheme:  memberScheme,
+		config:        config,
+		health:        config.healthCheck(),
+		released:      make(chan struct{}),
 		routingHandler: &boltRoutingHandler{
 			Leaders:      []neoNodeConfig{},
 			Followers:    []neoNodeConfig{},
 			ReadReplicas: []neoNodeConfig{},
 		},
-		connStrLookup: map[string][]*connectionPoolWrapper{},
+		connFactory: func(connStr string, authToken map[string]interface{}) (connection.IConnection, error) {
+			config := connConfig
+			config.AuthToken = authToken
+			return connection.CreateBoltConnWithConfig(connStr, config)
+		},
+		servers:       map[string]*serverPool{},
+		writers:       []string{},
+		readers:       []string{},
+		databases:     map[string]databaseRoutes{},
 		borrowedConns: map[string]*connectionPoolWrapper{},
-		heldConns:     map[string]*connectionPoolWrapper{},
-		rwStrings:     []string{},
-		rwIndex:       0,
-		writeQueue:    NewQueue(),
-		readStrings:   []string{},
-		readIndex:     0,
-		readQueue:     NewQueue(),
 	}, nil
 }
 
-func (r *routingPool) removeConn(conn *connectionPoolWrapper) error {
-	if conn == nil || conn.Connection == nil {
-		return errors.New("invalid connection, can not be nil")
-	}
-
-	if !conn.Connection.ValidateOpen() {
-		err := conn.Connection.Close()
-		if err != nil {
-			return err
-		}
-	}
-
-	delete(r.heldConns, conn.Connection.GetConnectionId())
-	return nil
+func (r *routingPool) makeConnID(connType bolt_mode.AccessMode) string {
+	return fmt.Sprintf("%v-%d", connType, atomic.AddUint64(&r.connCount, 1))
 }
 
-func (r *routingPool) addWriteConn() error {
-	// validate we have connection strings
-	if r.rwStrings == nil || len(r.rwStrings) == 0 {
-		return errors.New("no connection strings")
-	}
-
-	r.rwIndex++
-
-	// make sure its not an overflow
-	if r.rwIndex == len(r.rwStrings) {
-		r.rwIndex = 0
-	}
-
-	// create the bolt connection
-	connWrap, err := r.newConnection(bolt_mode.WriteMode, r.rwStrings[r.rwIndex])
+func (r *routingPool) newConnection(connType bolt_mode.AccessMode, server *serverPool) (*connectionPoolWrapper, error) {
+	conn, err := r.connFactory(server.connStr, server.authToken)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// add it to the queue
-	r.writeQueue.Enqueue(connWrap)
+	conn.SetConnectionId(r.makeConnID(connType))
 
-	return nil
+	now := time.Now()
+	return &connectionPoolWrapper{
+		Connection: conn,
+		ConnStr:    server.connStr,
+		serverKey:  serverKey(server.connStr, server.authKey),
+		ConnType:   connType,
+		created:    now,
+		lastUsed:   now,
+	}, nil
 }
 
-func (r *routingPool) addReadConn() error {
-	// validate we have connection strings
-	if r.readStrings == nil || len(r.readStrings) == 0 {
-		return errors.New("no connection strings")
-	}
-
-	r.readIndex++
-
-	// make sure its not an overflow
-	if r.readIndex == len(r.readStrings) {
-		r.readIndex = 0
-	}
+// closeConns closes connections that have already been removed from their server pools
+func closeConns(conns []*connectionPoolWrapper) {
+	for _, connWrap := range conns {
+		if connWrap == nil || connWrap.Connection == nil {
+			continue
+		}
 
-	// create the bolt connection
-	connWrap, err := r.newConnection(bolt_mode.ReadMode, r.readStrings[r.readIndex])
-	if err != nil {
-		return err
+		// broken connections still need their socket closed
+		if err := connWrap.Connection.Close(); err != nil && err != boltErrors.ErrClosed {
+			log.Error(err)
+		}
 	}
-
-	// add it to the queue
-	r.readQueue.Enqueue(connWrap)
-
-	return nil
 }
 
-func (r *routingPool) makeConnID(connType bolt_mode.AccessMode) string {
-	return fmt.Sprintf("%v-%s", connType, stringWithCharset(50, charset))
+// signal wakes every borrow waiting on a connection slot. Caller must hold the mutex
+func (r *routingPool) signal() {
+	close(r.released)
+	r.released = make(chan struct{})
 }
 
-func (r *routingPool) newConnection(connType bolt_mode.AccessMode, connStr string) (*connectionPoolWrapper, error) {
-	conn, err := connection.CreateBoltConn(connStr)
-	if err != nil {
-		return nil, err
+// refreshConnections queries the cluster for its members and updates the server pools to match
+func (r *routingPool) refreshConnections() error {
+	r.mutex.Lock()
+	// the configured leader is tried first, then anything already known
+	candidates := append([]string{r.leaderConnStr}, r.writers...)
+	candidates = append(candidates, r.readers...)
+	r.mutex.Unlock()
+
+	var conn connection.IConnection
+	var err error
+	for _, connStr := range candidates {
+		conn, err = r.connFactory(connStr, nil)
+		if err == nil {
+			break
+		}
+		log.Errorf("unable to reach [%s] for cluster info, %s", connStr, err.Error())
 	}
 
-	conn.SetConnectionId(r.makeConnID(connType))
-
-	connWrap := &connectionPoolWrapper{
-		Connection:      conn,
-		ConnStr:         connStr,
-		ConnType:        connType,
-		borrowed:        false,
-		numBorrows:      0,
-		markForDeletion: false,
+	if conn == nil {
+		return fmt.Errorf("unable to reach any cluster member, %w", err)
 	}
 
-	r.heldConns[conn.GetConnectionId()] = connWrap
-	return connWrap, nil
-}
+	defer conn.Close()
 
-func (r *routingPool) refreshConnections() {
-	conn, err := connection.CreateBoltConn(r.leaderConnStr)
+	handler := &boltRoutingHandler{}
+	err = handler.refreshClusterInfo(conn)
 	if err != nil {
-		log.Error(err)
-		return
+		return err
 	}
 
-	defer conn.Close()
-
-	err = r.routingHandler.refreshClusterInfo(conn)
+	var writers, readers []string
+	for _, connStr := range handler.getWriteConnectionStrings() {
+		writers = append(writers, r.addAuthInfoToConnStr(connStr))
+	}
+	for _, connStr := range handler.getReadOnlyConnectionString() {
+		readers = append(readers, r.addAuthInfoToConnStr(connStr))
+	}
 
-	newWriteStrs := r.routingHandler.getWriteConnectionStrings()
-	newReadStrs := r.routingHandler.getReadOnlyConnectionString()
+	// every member serving some database gets a server pool
+	members := append(append([]string{}, writers...), readers...)
+	databases := map[string]databaseRoutes{}
+	for _, db := range handler.getDatabases() {
+		dbWriters, dbReaders, _ := handler.getDatabaseConnectionStrings(db)
 
-	var deadStrs, actualWrites, actualReads []string
+		var routes databaseRoutes
+		for _, connStr := range dbWriters {
+			routes.writers = append(routes.writers, r.addAuthInfoToConnStr(connStr))
+		}
+		for _, connStr := range dbReaders {
+			routes.readers = append(routes.readers, r.addAuthInfoToConnStr(connStr))
+		}
 
-	// check for dead strings
-	for _, curStr := range r.rwStrings {
-		// string is not found, so it must be dead
-		if !stringSliceContains(newWriteStrs, curStr) {
-			deadStrs = append(deadStrs, curStr)
-		} else {
-			actualWrites = append(actualWrites, curStr)
+		for _, connStr := range append(routes.writers, routes.readers...) {
+			if !stringSliceContains(members, connStr) {
+				members = append(members, connStr)
+			}
 		}
+		databases[db] = routes
 	}
 
-	for _, curStr := range r.readStrings {
-		// string is not found, so it must be dead
-		if !stringSliceContains(newReadStrs, curStr) {
-			deadStrs = append(deadStrs, curStr)
-		} else {
-			actualReads = append(actualReads, curStr)
+	r.mutex.Lock()
+	r.routingHandler = handler
+	r.writers = writers
+	r.readers = readers
+	r.databases = databases
+
+	var dead []*connectionPoolWrapper
+	for key, server := range r.servers {
+		if stringSliceContains(members, server.connStr) {
+			server.removed = false
+			continue
 		}
-	}
 
-	// add anything new
-	for _, newStr := range newWriteStrs {
-		newStr = r.addAuthInfoToConnStr(newStr)
-		if !stringSliceContains(actualWrites, newStr) {
-			actualWrites = append(actualWrites, newStr)
+		// borrowed connections get closed when they are reclaimed
+		server.removed = true
+		dead = append(dead, server.drain()...)
+		if server.inUse == 0 {
+			delete(r.servers, key)
 		}
 	}
 
-	for _, newStr := range newReadStrs {
-		newStr = r.addAuthInfoToConnStr(newStr)
-		if !stringSliceContains(actualReads, newStr) {
-			actualReads = append(actualReads, newStr)
+	for _, connStr := range members {
+		if _, ok := r.servers[connStr]; !ok {
+			r.servers[connStr] = newServerPool(connStr)
 		}
 	}
 
-	// mark them for deletion, system will rebalance elsewhere
-	if len(deadStrs) != 0 {
-		for _, str := range deadStrs {
-			conns, ok := r.connStrLookup[str]
-			if !ok || conns == nil || len(conns) == 0 {
-				continue
-			}
+	// new servers may have freed up capacity
+	r.signal()
+	r.mutex.Unlock()
 
-			for _, markedConn := range conns {
-				if markedConn == nil {
-					continue
-				}
+	closeConns(dead)
+	return nil
+}
 
-				markedConn.markForDeletion = true
-			}
+// balance enforces the idle limits on every server pool
+func (r *routingPool) balance() {
+	r.mutex.Lock()
+	var extra []*connectionPoolWrapper
+	missing := map[string]int{}
+	for key, server := range r.servers {
+		if server.removed {
+			continue
 		}
-	}
 
-	r.rwStrings = actualWrites
-	r.readStrings = actualReads
-}
+		extra = append(extra, server.removeExpired(r.health)...)
+		extra = append(extra, server.trimIdle(r.config.MaxIdlePerServer)...)
 
-func (r *routingPool) prune() {
-	reads := []*connectionPoolWrapper{}
-	writes := []*connectionPoolWrapper{}
-	for _, connWrap := range r.readQueue.items {
-		if connWrap.markForDeletion {
-			err := connWrap.Connection.Close()
-			if err != nil {
-				log.Error(err)
-				continue
+		// partitions for overridden credentials are only kept while they have connections
+		if server.authKey != "" {
+			if server.total() == 0 {
+				delete(r.servers, key)
 			}
-		} else {
-			reads = append(reads, connWrap)
+			continue
 		}
-	}
 
-	for _, connWrap := range r.writeQueue.items {
-		if connWrap.markForDeletion {
-			err := r.removeConn(connWrap)
-			if err != nil {
-				log.Error(err)
-				continue
-			}
-		} else {
-			writes = append(writes, connWrap)
+		need := r.config.MinIdlePerServer - len(server.idle)
+		if room := r.config.MaxConnsPerServer - server.total(); need > room {
+			need = room
+		}
+
+		if need > 0 {
+			// reserve the slots so borrows can't overfill the server while dialing
+			server.inUse += need
+			missing[key] = need
 		}
 	}
+	r.mutex.Unlock()
 
-	r.writeQueue = NewQueueFromSlice(writes...)
-	r.readQueue = NewQueueFromSlice(reads...)
-}
+	for key, need := range missing {
+		r.mutex.Lock()
+		server := r.servers[key]
+		r.mutex.Unlock()
 
-func (r *routingPool) balance() {
-	if r.readQueue.Size() < r.minReadIdle {
-		for r.readQueue.Size() == r.minReadIdle {
-			err := r.addReadConn()
+		mode := r.modeForServer(server.connStr)
+		for i := 0; i < need; i++ {
+			connWrap, err := r.newConnection(mode, server)
+
+			r.mutex.Lock()
+			server.inUse--
 			if err != nil {
 				log.Error(err)
-				continue
+			} else if server.removed || !r.isRunning() {
+				extra = append(extra, connWrap)
+			} else {
+				server.pushIdle(connWrap)
 			}
+			r.signal()
+			r.mutex.Unlock()
 		}
 	}
 
-	// make sure amount of conns is correct and within limits
-	if r.writeQueue.Size() < r.minWriteIdle {
-		for r.writeQueue.Size() == r.minWriteIdle {
-			err := r.addWriteConn()
-			if err != nil {
-				log.Error(err)
-				continue
-			}
-		}
-	} else if r.writeQueue.Size() > r.maxWriteIdle {
-		for r.writeQueue.Size() == r.maxWriteIdle {
-			err := r.removeConn(r.writeQueue.Dequeue())
-			if err != nil {
-				log.Error(err)
-				continue
-			}
-		}
+	closeConns(extra)
+}
+
+// modeForServer reports which role a server currently has
+func (r *routingPool) modeForServer(connStr string) bolt_mode.AccessMode {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if stringSliceContains(r.writers, connStr) {
+		return bolt_mode.WriteMode
 	}
+	return bolt_mode.ReadMode
+}
 
-	// make sure amount of conns is correct and within limits
-	if r.readQueue.Size() < r.minReadIdle {
-		for r.readQueue.Size() == r.minReadIdle {
-			err := r.addReadConn()
-			if err != nil {
-				log.Error(err)
-				continue
-			}
-		}
-	} else if r.readQueue.Size() > r.maxReadIdle {
-		for r.readQueue.Size() == r.maxReadIdle {
-			err := r.removeConn(r.readQueue.Dequeue())
-			if err != nil {
+func (r *routingPool) refreshHandler() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.config.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			// refresh connections and mark dead servers
+			if err := r.refreshConnections(); err != nil {
 				log.Error(err)
-				continue
 			}
+			// balance pool to params
+			r.balance()
 		}
 	}
 }
 
-func (r *routingPool) refreshHandler() {
-	for r.isRunning() {
-		// block routine until interval is up
-		<-time.After(r.refreshInterval)
-		r.mutex.Lock()
-		// refresh connections and mark dead conns
-		r.refreshConnections()
-		// remove dead conns
-		r.prune()
-		// balance pool to params
-		r.balance()
+func (r *routingPool) Start() error {
+	if !atomic.CompareAndSwapInt32(&r.running, 0, 1) {
+		return errors.New("pool already running")
+	}
+
+	err := r.refreshConnections()
+	if err != nil {
+		r.setRunning(false)
+		return err
+	}
+
+	r.mutex.Lock()
+	if len(r.writers) == 0 {
 		r.mutex.Unlock()
+		r.setRunning(false)
+		return errors.New("no connection strings")
 	}
+	r.mutex.Unlock()
+
+	// warm up the server pools
+	r.balance()
+
+	r.stop = make(chan struct{})
+	r.done = make(chan struct{})
+	go r.refreshHandler()
+
+	return nil
 }
 
-func (r *routingPool) Start() error {
-	if r.isRunning() {
-		return errors.New("pool already running")
+func (r *routingPool) Stop() error {
+	if !atomic.CompareAndSwapInt32(&r.running, 1, 0) {
+		return errors.New("routingPool is not running")
 	}
 
-	r.setRunning(true)
+	close(r.stop)
+	<-r.done
 
-	if r.totalConns%2 == 0 {
-		r.maxWriteIdle = r.totalConns / 2
-		r.maxReadIdle = r.totalConns / 2
-	} else {
-		// write would have one more if the number of connections is odd
-		r.maxWriteIdle = ((r.totalConns - 1) / 2) + 1
-		r.maxReadIdle = (r.totalConns - 1) / 2
+	r.mutex.Lock()
+	var idle []*connectionPoolWrapper
+	for _, server := range r.servers {
+		idle = append(idle, server.drain()...)
 	}
+	// fail anything still waiting on a connection
+	r.signal()
+	r.mutex.Unlock()
 
-	//conn, err := connection.CreateBoltConn(r.leaderConnStr)
-	//if err != nil {
-	//	return err
-	//}
-	//
-	//defer conn.Close()
+	closeConns(idle)
+	return nil
+}
 
-	// refresh connections and mark dead conns
-	r.refreshConnections()
+// routes returns the writers and readers for db, empty db uses the system database roles. Caller must hold the mutex
+func (r *routingPool) routes(db string) (writers, readers []string, err error) {
+	if db == "" {
+		return r.writers, r.readers, nil
+	}
 
-	for i := 0; i < r.maxWriteIdle; i++ {
-		err := r.addWriteConn()
-		if err != nil {
-			return err
-		}
+	routes, ok := r.databases[db]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w, [%s]", ErrUnknownDatabase, db)
 	}
 
-	for i := 0; i < r.maxReadIdle; i++ {
-		err := r.addReadConn()
-		if err != nil {
-			return err
-		}
+	return routes.writers, routes.readers, nil
+}
+
+// partition returns the server pool for connStr holding connections authenticated with the session's token,
+// creating it on first use. Caller must hold the mutex
+func (r *routingPool) partition(connStr string, session *Session) (*serverPool, bool) {
+	authKey := connection.AuthTokenKey(session.AuthToken)
+	if server, ok := r.servers[serverKey(connStr, authKey)]; ok || authKey == "" {
+		return server, ok
 	}
 
-	go r.refreshHandler()
+	// follow the default pool so partitions go away with their server
+	if server, ok := r.servers[connStr]; !ok || server.removed {
+		return nil, false
+	}
 
-	return nil
+	server := newServerPool(connStr)
+	server.authKey = authKey
+	server.authToken = session.AuthToken
+	r.servers[serverKey(connStr, authKey)] = server
+	return server, true
 }
 
-func (r *routingPool) Stop() error {
-	if !r.isRunning() {
-		return errors.New("routingPool is not running")
+// pickServer chooses the least connected server with a free slot for the mode and session's database.
+// Reads fall back to the writers when the database has no read servers. Caller must hold the mutex
+func (r *routingPool) pickServer(mode bolt_mode.AccessMode, session *Session) (*serverPool, bool, error) {
+	writers, readers, err := r.routes(session.Database)
+	if err != nil {
+		return nil, false, err
 	}
 
-	r.setRunning(false)
+	connStrs := writers
+	if mode == bolt_mode.ReadMode && len(readers) != 0 {
+		connStrs = readers
+	}
 
-	for r.readQueue.Size() != 0 {
-		err := r.removeConn(r.readQueue.Dequeue())
-		if err != nil {
-			return err
-		}
+	if len(connStrs) == 0 {
+		return nil, false, errors.New("no connection strings")
 	}
 
-	for r.writeQueue.Size() != 0 {
-		err := r.removeConn(r.writeQueue.Dequeue())
-		if err != nil {
-			return err
+	var best *serverPool
+	for i := range connStrs {
+		server, ok := r.partition(connStrs[(r.nextServer+i)%len(connStrs)], session)
+		if !ok || !server.hasCapacity(r.config.connLimit()) {
+			continue
+		}
+
+		if best == nil || server.inUse < best.inUse {
+			best = server
 		}
 	}
 
-	return nil
+	r.nextServer++
+	return best, best != nil, nil
 }
 
-func (r *routingPool) internalBorrow(queue *Queue) (*connectionPoolWrapper, error) {
-	if queue == nil {
-		return nil, errors.New("queue can not be nil")
+func (r *routingPool) borrow(mode bolt_mode.AccessMode, session Session) (connection.IConnection, error) {
+	if session.Database == "" {
+		session.Database = r.config.Database
 	}
 
-	conn := queue.Dequeue()
-	if conn == nil {
-		return nil, fmt.Errorf("stale queue")
-	}
+	deadline := time.Now().Add(r.config.AcquireTimeout)
+	refreshed := false
+
+	r.mutex.Lock()
+	for {
+		if !r.isRunning() {
+			r.mutex.Unlock()
+			return nil, errors.New("pool is not running")
+		}
 
-	if conn.markForDeletion {
-		err := r.removeConn(conn)
+		server, ok, err := r.pickServer(mode, &session)
+		if errors.Is(err, ErrUnknownDatabase) && !refreshed {
+			// the database may have been created since the last refresh
+			refreshed = true
+			r.mutex.Unlock()
+			if err := r.refreshConnections(); err != nil {
+				return nil, err
+			}
+			r.mutex.Lock()
+			continue
+		}
 		if err != nil {
+			r.mutex.Unlock()
 			return nil, err
 		}
-		return r.internalBorrow(queue)
-	}
 
-	if !conn.Connection.ValidateOpen() {
-		err := r.removeConn(conn)
+		if !ok {
+			if r.config.AcquireStrategy == AcquireFailFast {
+				r.mutex.Unlock()
+				return nil, ErrPoolExhausted
+			}
+
+			// every server is at capacity, wait for something to come back
+			released := r.released
+			r.waiters++
+			r.mutex.Unlock()
+
+			err := r.wait(released, deadline)
+
+			r.mutex.Lock()
+			r.waiters--
+			if err != nil {
+				r.mutex.Unlock()
+				return nil, err
+			}
+			continue
+		}
+
+		connWrap := server.popIdle()
+		server.inUse++
+		r.mutex.Unlock()
+
+		if connWrap == nil {
+			connWrap, err = r.newConnection(mode, server)
+		} else if !r.health.Healthy(connWrap.Connection, connWrap.created, time.Since(connWrap.lastUsed)) {
+			closeConns([]*connectionPoolWrapper{connWrap})
+			connWrap, err = r.newConnection(mode, server)
+		}
+
+		r.mutex.Lock()
 		if err != nil {
+			server.inUse--
+			r.signal()
+			r.mutex.Unlock()
 			return nil, err
 		}
-		return r.internalBorrow(queue)
-	}
 
-	delete(r.heldConns, conn.Connection.GetConnectionId())
-	r.borrowedConns[conn.Connection.GetConnectionId()] = conn
+		connWrap.ConnType = mode
+		connWrap.Connection.SetDatabase(session.Database)
+		connWrap.Connection.SetImpersonatedUser(session.ImpersonatedUser)
+		r.borrowedConns[connWrap.Connection.GetConnectionId()] = connWrap
+		r.mutex.Unlock()
 
-	return conn, nil
+		return connWrap.Connection, nil
+	}
 }
 
-func (r *routingPool) BorrowRConnection() (connection.IConnection, error) {
-	r.mutex.RLock()
-	defer r.mutex.RUnlock()
+// wait blocks until a connection slot frees up or the deadline passes
+func (r *routingPool) wait(released chan struct{}, deadline time.Time) error {
+	remaining := time.Until(deadline)
+	if remaining <= 0 {
+		return ErrAcquireTimeout
+	}
 
-	connWrap, err := r.internalBorrow(r.readQueue)
-	if err != nil {
-		return nil, err
+	timer := time.NewTimer(remaining)
+	defer timer.Stop()
+
+	select {
+	case <-released:
+		return nil
+	case <-timer.C:
+		return ErrAcquireTimeout
 	}
+}
 
-	return connWrap.Connection, nil
+func (r *routingPool) BorrowRConnection() (connection.IConnection, error) {
+	return r.borrow(bolt_mode.ReadMode, Session{})
 }
 
 func (r *routingPool) BorrowRWConnection() (connection.IConnection, error) {
-	r.mutex.RLock()
-	defer r.mutex.RUnlock()
-
-	connWrap, err := r.internalBorrow(r.writeQueue)
-	if err != nil {
-		return nil, err
-	}
-
-	return connWrap.Connection, nil
+	return r.borrow(bolt_mode.WriteMode, Session{})
 }
 
-func (r *routingPool) isRunning() bool {
-	return atomic.LoadInt32(r.running) == 1
+func (r *routingPool) BorrowConnection(mode bolt_mode.AccessMode, db string) (connection.IConnection, error) {
+	return r.borrow(mode, Session{Database: db})
 }
 
-func (r *routingPool) setRunning(b bool) {
-	if b {
-		atomic.StoreInt32(r.running, 1)
-	} else {
-		atomic.StoreInt32(r.running, 0)
-	}
+func (r *routingPool) BorrowSession(mode bolt_mode.AccessMode, session Session) (connection.IConnection, error) {
+	return r.borrow(mode, session)
 }
 
-func (r *routingPool) isUpdating() bool {
-	return atomic.LoadInt32(r.updating) == 1
+func (r *routingPool) isRunning() bool {
+	return atomic.LoadInt32(&r.running) == 1
 }
 
-func (r *routingPool) setUpdating(b bool) {
+func (r *routingPool) setRunning(b bool) {
 	if b {
-		atomic.StoreInt32(r.updating, 1)
+		atomic.StoreInt32(&r.running, 1)
 	} else {
-		atomic.StoreInt32(r.updating, 0)
+		atomic.StoreInt32(&r.running, 0)
 	}
 }
 
 func (r *routingPool) Reclaim(conn connection.IConnection) error {
-	r.mutex.Lock()
-	defer r.mutex.Unlock()
-
-	if !r.isRunning() {
-		return errors.New("pool is not running")
+	if conn == nil {
+		return errors.New("cannot reclaim nil connection")
 	}
 
 	connId := conn.GetConnectionId()
 
+	r.mutex.Lock()
 	connWrap, ok := r.borrowedConns[connId]
+	if ok {
+		delete(r.borrowedConns, connId)
+	}
+	r.mutex.Unlock()
+
 	if !ok {
 		err := conn.Close()
 		if err != nil {
@@ -525,37 +624,66 @@ func (r *routingPool) Reclaim(conn connection.IConnection) error {
 		return fmt.Errorf("connection not found with id [%s]", connId)
 	}
 
-	// remove from borrowed and replace in held
-	delete(r.borrowedConns, connId)
-	r.heldConns[connId] = connWrap
-
-	// check if the connection is dead, if it is discard and make a new one
-	if !connWrap.Connection.ValidateOpen() {
-		err := r.removeConn(connWrap)
-		if err != nil {
-			if connWrap.ConnType == bolt_mode.WriteMode {
-				return r.addWriteConn()
-			} else {
-				return r.addReadConn()
-			}
+	// roll back anything left open, a connection that can't be made idle is not reused
+	healthy := connWrap.Connection.ValidateOpen()
+	if healthy {
+		if err := connWrap.Connection.MakeIdle(); err != nil {
+			log.Error(err)
+			healthy = false
 		}
 	}
 
-	if connWrap.ConnType == bolt_mode.WriteMode {
-		r.writeQueue.Enqueue(connWrap)
-	} else {
-		r.readQueue.Enqueue(connWrap)
+	r.mutex.Lock()
+	server, ok := r.servers[connWrap.serverKey]
+	if ok {
+		server.inUse--
+	}
+
+	// expired connections and overflow from AcquireGrow are closed rather than kept
+	keep := healthy && ok && r.isRunning() && !server.removed && !r.health.Expired(connWrap.created) &&
+		len(server.idle) < r.config.MaxIdlePerServer && server.total() < r.config.MaxConnsPerServer
+	if keep {
+		connWrap.lastUsed = time.Now()
+		server.pushIdle(connWrap)
+	} else if ok && server.removed && server.total() == 0 {
+		delete(r.servers, connWrap.serverKey)
+	}
+
+	r.signal()
+	running := r.isRunning()
+	r.mutex.Unlock()
+
+	if !keep {
+		closeConns([]*connectionPoolWrapper{connWrap})
+	}
+
+	if !running {
+		return errors.New("pool is not running")
 	}
 
 	return nil
 }
 
-func (r *routingPool) addAuthInfoToConnStr(connStr string) string {
+func (r *routingPool) Stats() Stats {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
 
-	if r.userPassPart == "" && r.tlsInfo == "" {
-		return connStr
+	stats := Stats{Waiters: r.waiters}
+	for _, server := range r.servers {
+		stats.Active += server.inUse
+		stats.Idle += len(server.idle)
 	}
+	return stats
+}
 
-	hostPort := strings.Replace(connStr, "bolt://", "", -1)
-	return fmt.Sprintf("bolt://%s@%s%s", r.userPassPart, hostPort, r.tlsInfo)
+func (r *routingPool) addAuthInfoToConnStr(connStr string) string {
+	hostPort := connStr
+	if schemeEnd := strings.Index(connStr, "://"); schemeEnd >= 0 {
+		hostPort = connStr[schemeEnd+len("://"):]
+	}
+
+	if r.userPassPart == "" {
+		return fmt.Sprintf("%s://%s%s", r.memberScheme, hostPort, r.tlsInfo)
+	}
+	return fmt.Sprintf("%s://%s@%s%s", r.memberScheme, r.userPassPart, hostPort, r.tlsInfo)
 }
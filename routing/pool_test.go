@@ -0,0 +1,903 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mindstand/go-bolt/bolt_mode"
+	"github.com/mindstand/go-bolt/connection"
+	"github.com/mindstand/go-bolt/encoding"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConn stands in for a bolt connection. Embedding the interface satisfies the
+// unexported methods, anything the pool shouldn't call panics
+type fakeConn struct {
+	connection.IConnection
+	connStr string
+	cluster *fakeCluster
+	auth    map[string]interface{}
+
+	mutex    sync.Mutex
+	id       string
+	database string
+	impUser  string
+	closed   bool
+	pings    int
+	pingFail bool
+}
+
+func (f *fakeConn) GetConnectionId() string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.id
+}
+
+func (f *fakeConn) SetConnectionId(id string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.id = id
+}
+
+func (f *fakeConn) SetDatabase(db string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.database = db
+}
+
+func (f *fakeConn) GetDatabase() string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.database
+}
+
+func (f *fakeConn) SetImpersonatedUser(user string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.impUser = user
+}
+
+func (f *fakeConn) GetImpersonatedUser() string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.impUser
+}
+
+func (f *fakeConn) ValidateOpen() bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return !f.closed
+}
+
+func (f *fakeConn) Ping() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.pings++
+	if f.pingFail {
+		return errors.New("no response")
+	}
+	return nil
+}
+
+func (f *fakeConn) MakeIdle() error {
+	return nil
+}
+
+func (f *fakeConn) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.closed {
+		return errors.New("already closed")
+	}
+	f.closed = true
+	atomic.AddInt32(&f.cluster.closed, 1)
+	return nil
+}
+
+func (f *fakeConn) GetProtocolVersionNumber() int {
+	if f.cluster.multiDatabase() {
+		return 4
+	}
+	return 3
+}
+
+func (f *fakeConn) Query(query string, params connection.QueryParams) ([][]interface{}, connection.IResult, error) {
+	return f.cluster.overview(), nil, nil
+}
+
+func (f *fakeConn) QueryWithDb(query string, params connection.QueryParams, db string) ([][]interface{}, connection.IResult, error) {
+	if db != neoV4SystemDb {
+		return nil, nil, fmt.Errorf("cluster overview run against [%s]", db)
+	}
+	return f.cluster.overview(), nil, nil
+}
+
+// fakeCluster answers cluster overview queries and counts the connections made
+type fakeCluster struct {
+	mutex     sync.Mutex
+	leaders   []string
+	followers []string
+	// roles by host and database, the cluster answers like bolt v4 when set
+	databases map[string]map[string]string
+
+	opened int32
+	closed int32
+}
+
+func (f *fakeCluster) setMembers(leaders, followers []string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.leaders = leaders
+	f.followers = followers
+}
+
+func (f *fakeCluster) setDatabases(databases map[string]map[string]string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.databases = databases
+}
+
+func (f *fakeCluster) multiDatabase() bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.databases != nil
+}
+
+func (f *fakeCluster) overview() [][]interface{} {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	var rows [][]interface{}
+	add := func(hosts []string, role string) {
+		for _, host := range hosts {
+			if f.databases == nil {
+				rows = append(rows, []interface{}{host, []interface{}{"bolt://" + host}, role, []interface{}{}, "default"})
+				continue
+			}
+
+			roles := map[string]interface{}{neoV4SystemDb: role}
+			for db, dbRole := range f.databases[host] {
+				roles[db] = dbRole
+			}
+			rows = append(rows, []interface{}{host, []interface{}{"bolt://" + host}, roles, []interface{}{}})
+		}
+	}
+	add(f.leaders, "LEADER")
+	add(f.followers, "FOLLOWER")
+	return rows
+}
+
+func (f *fakeCluster) connect(connStr string, authToken map[string]interface{}) (connection.IConnection, error) {
+	atomic.AddInt32(&f.opened, 1)
+	return &fakeConn{connStr: connStr, cluster: f, auth: authToken}, nil
+}
+
+func (f *fakeCluster) open() int32 {
+	return atomic.LoadInt32(&f.opened) - atomic.LoadInt32(&f.closed)
+}
+
+func newTestPool(t *testing.T, config Config, leaders, followers []string) (*routingPool, *fakeCluster) {
+	cluster := &fakeCluster{}
+	cluster.setMembers(leaders, followers)
+
+	pool, err := NewRoutingPool("bolt+routing://"+leaders[0], config, "", "")
+	require.Nil(t, err)
+
+	r := pool.(*routingPool)
+	r.connFactory = cluster.connect
+	require.Nil(t, r.Start())
+	t.Cleanup(func() {
+		_ = r.Stop()
+	})
+
+	return r, cluster
+}
+
+func testConfig(maxConns int) Config {
+	config := DefaultConfig(maxConns)
+	config.MinIdlePerServer = 0
+	config.AcquireTimeout = 100 * time.Millisecond
+	config.RefreshInterval = time.Hour
+	return config
+}
+
+func (r *routingPool) stats(connStr string) (idle, inUse int) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	server, ok := r.servers[connStr]
+	if !ok {
+		return 0, 0
+	}
+	return len(server.idle), server.inUse
+}
+
+func TestConfigValidation(t *testing.T) {
+	req := require.New(t)
+
+	req.Nil(DefaultConfig(5).validate())
+
+	config := DefaultConfig(0)
+	req.NotNil(config.validate())
+
+	config = DefaultConfig(5)
+	config.MinIdlePerServer = 6
+	req.NotNil(config.validate())
+
+	config = DefaultConfig(5)
+	config.MaxIdlePerServer = 10
+	req.NotNil(config.validate())
+
+	config = DefaultConfig(5)
+	config.RefreshInterval = 0
+	req.NotNil(config.validate())
+
+	config = DefaultConfig(5)
+	config.AcquireStrategy = AcquireStrategy(-1)
+	req.NotNil(config.validate())
+
+	config = DefaultConfig(5)
+	config.MaxOverflowPerServer = -1
+	req.NotNil(config.validate())
+
+	config = DefaultConfig(5)
+	config.MaxConnLifetime = -1
+	req.NotNil(config.validate())
+}
+
+func TestLeastConnectedBalancing(t *testing.T) {
+	req := require.New(t)
+	pool, _ := newTestPool(t, testConfig(5), []string{"leader:7687"}, []string{"f1:7687", "f2:7687", "f3:7687"})
+
+	var conns []connection.IConnection
+	for i := 0; i < 6; i++ {
+		conn, err := pool.BorrowRConnection()
+		req.Nil(err)
+		conns = append(conns, conn)
+	}
+
+	// six reads spread over three followers
+	perServer := map[string]int{}
+	for _, conn := range conns {
+		perServer[conn.(*fakeConn).connStr]++
+	}
+	req.Equal(map[string]int{"bolt://f1:7687": 2, "bolt://f2:7687": 2, "bolt://f3:7687": 2}, perServer)
+
+	// free up f2, the next borrow has to land there
+	for _, conn := range conns {
+		if conn.(*fakeConn).connStr == "bolt://f2:7687" {
+			req.Nil(pool.Reclaim(conn))
+		}
+	}
+
+	conn, err := pool.BorrowRConnection()
+	req.Nil(err)
+	req.Equal("bolt://f2:7687", conn.(*fakeConn).connStr)
+
+	// writes only go to the leader
+	conn, err = pool.BorrowRWConnection()
+	req.Nil(err)
+	req.Equal("bolt://leader:7687", conn.(*fakeConn).connStr)
+}
+
+func TestReadsFallBackToWriters(t *testing.T) {
+	req := require.New(t)
+	pool, _ := newTestPool(t, testConfig(2), []string{"leader:7687"}, nil)
+
+	conn, err := pool.BorrowRConnection()
+	req.Nil(err)
+	req.Equal("bolt://leader:7687", conn.(*fakeConn).connStr)
+}
+
+func TestDatabaseRouting(t *testing.T) {
+	req := require.New(t)
+
+	cluster := &fakeCluster{}
+	cluster.setMembers([]string{"a:7687"}, []string{"b:7687", "c:7687"})
+	// b leads movies even though a leads the system database
+	cluster.setDatabases(map[string]map[string]string{
+		"a:7687": {"neo4j": "LEADER", "movies": "FOLLOWER"},
+		"b:7687": {"neo4j": "FOLLOWER", "movies": "LEADER"},
+		"c:7687": {"neo4j": "FOLLOWER"},
+	})
+
+	config := testConfig(5)
+	config.Database = "neo4j"
+	pool, err := NewRoutingPool("bolt+routing://a:7687", config, "", "")
+	req.Nil(err)
+
+	r := pool.(*routingPool)
+	r.connFactory = cluster.connect
+	req.Nil(r.Start())
+	defer r.Stop()
+
+	// borrows without a database use the configured one
+	conn, err := r.BorrowRWConnection()
+	req.Nil(err)
+	req.Equal("bolt://a:7687", conn.(*fakeConn).connStr)
+	req.Equal("neo4j", conn.GetDatabase())
+	req.Nil(r.Reclaim(conn))
+
+	conn, err = r.BorrowConnection(bolt_mode.WriteMode, "movies")
+	req.Nil(err)
+	req.Equal("bolt://b:7687", conn.(*fakeConn).connStr)
+	req.Equal("movies", conn.GetDatabase())
+
+	// c doesn't host movies
+	for i := 0; i < 4; i++ {
+		conn, err := r.BorrowConnection(bolt_mode.ReadMode, "movies")
+		req.Nil(err)
+		req.Equal("bolt://a:7687", conn.(*fakeConn).connStr)
+	}
+
+	// reused connections pick up the new database
+	req.Nil(r.Reclaim(conn))
+	conn, err = r.BorrowConnection(bolt_mode.ReadMode, "neo4j")
+	req.Nil(err)
+	req.Equal("neo4j", conn.GetDatabase())
+
+	_, err = r.BorrowConnection(bolt_mode.ReadMode, "missing")
+	req.True(errors.Is(err, ErrUnknownDatabase))
+
+	// databases created after the last refresh are found on borrow
+	cluster.setDatabases(map[string]map[string]string{
+		"a:7687": {"neo4j": "LEADER", "movies": "FOLLOWER"},
+		"b:7687": {"neo4j": "FOLLOWER", "movies": "LEADER"},
+		"c:7687": {"neo4j": "FOLLOWER", "fresh": "LEADER"},
+	})
+	conn, err = r.BorrowConnection(bolt_mode.WriteMode, "fresh")
+	req.Nil(err)
+	req.Equal("bolt://c:7687", conn.(*fakeConn).connStr)
+}
+
+func TestBorrowSession(t *testing.T) {
+	req := require.New(t)
+	pool, _ := newTestPool(t, testConfig(1), []string{"leader:7687"}, nil)
+
+	alice := map[string]interface{}{"scheme": "basic", "principal": "alice", "credentials": "secret"}
+
+	conn, err := pool.BorrowSession(bolt_mode.WriteMode, Session{ImpersonatedUser: "bob"})
+	req.Nil(err)
+	req.Equal("bob", conn.GetImpersonatedUser())
+	req.Nil(conn.(*fakeConn).auth)
+
+	// overridden credentials get their own connections, so the default one being borrowed doesn't block
+	aliceConn, err := pool.BorrowSession(bolt_mode.WriteMode, Session{AuthToken: alice})
+	req.Nil(err)
+	req.Equal(alice, aliceConn.(*fakeConn).auth)
+	req.Equal("", aliceConn.GetImpersonatedUser())
+
+	// both partitions are at their limit
+	_, err = pool.BorrowRWConnection()
+	req.True(errors.Is(err, ErrAcquireTimeout))
+
+	req.Nil(pool.Reclaim(aliceConn))
+	req.Nil(pool.Reclaim(conn))
+
+	// connections are reused within their partition and the session settings are reset
+	conn, err = pool.BorrowRWConnection()
+	req.Nil(err)
+	req.Nil(conn.(*fakeConn).auth)
+	req.Equal("", conn.GetImpersonatedUser())
+
+	again, err := pool.BorrowSession(bolt_mode.WriteMode, Session{AuthToken: map[string]interface{}{"scheme": "basic", "principal": "alice", "credentials": "secret"}})
+	req.Nil(err)
+	req.True(aliceConn == again)
+	req.Nil(pool.Reclaim(again))
+	req.Nil(pool.Reclaim(conn))
+
+	// idle partitions are trimmed like any other server, empty ones are dropped
+	pool.config.MaxIdlePerServer = 0
+	pool.balance()
+	pool.mutex.Lock()
+	req.Len(pool.servers, 1)
+	pool.mutex.Unlock()
+}
+
+func TestBorrowBlocksUntilReclaim(t *testing.T) {
+	req := require.New(t)
+	config := testConfig(2)
+	config.AcquireTimeout = 5 * time.Second
+	pool, _ := newTestPool(t, config, []string{"leader:7687"}, nil)
+
+	first, err := pool.BorrowRWConnection()
+	req.Nil(err)
+	_, err = pool.BorrowRWConnection()
+	req.Nil(err)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = pool.Reclaim(first)
+	}()
+
+	start := time.Now()
+	conn, err := pool.BorrowRWConnection()
+	req.Nil(err)
+	req.Equal(first, conn)
+	req.True(time.Since(start) >= 50*time.Millisecond)
+}
+
+func TestStats(t *testing.T) {
+	req := require.New(t)
+	config := testConfig(1)
+	config.AcquireTimeout = 5 * time.Second
+	pool, _ := newTestPool(t, config, []string{"leader:7687"}, []string{"f1:7687"})
+
+	write, err := pool.BorrowRWConnection()
+	req.Nil(err)
+	read, err := pool.BorrowRConnection()
+	req.Nil(err)
+	req.Equal(Stats{Active: 2}, pool.Stats())
+
+	done := make(chan error)
+	go func() {
+		conn, err := pool.BorrowRWConnection()
+		if err == nil {
+			err = pool.Reclaim(conn)
+		}
+		done <- err
+	}()
+
+	req.Eventually(func() bool {
+		return pool.Stats().Waiters == 1
+	}, time.Second, time.Millisecond)
+
+	req.Nil(pool.Reclaim(write))
+	req.Nil(<-done)
+	req.Nil(pool.Reclaim(read))
+	req.Equal(Stats{Idle: 2}, pool.Stats())
+}
+
+func TestBorrowTimesOut(t *testing.T) {
+	req := require.New(t)
+	pool, cluster := newTestPool(t, testConfig(1), []string{"leader:7687"}, nil)
+
+	_, err := pool.BorrowRWConnection()
+	req.Nil(err)
+
+	start := time.Now()
+	_, err = pool.BorrowRWConnection()
+	req.Equal(ErrAcquireTimeout, err)
+	req.True(time.Since(start) >= 100*time.Millisecond)
+
+	// the per server max was never exceeded
+	_, inUse := pool.stats("bolt://leader:7687")
+	req.Equal(1, inUse)
+	req.EqualValues(1, cluster.open())
+}
+
+func TestBorrowFailFast(t *testing.T) {
+	req := require.New(t)
+	config := testConfig(1)
+	config.AcquireStrategy = AcquireFailFast
+	config.AcquireTimeout = time.Hour
+	pool, _ := newTestPool(t, config, []string{"leader:7687"}, nil)
+
+	conn, err := pool.BorrowRWConnection()
+	req.Nil(err)
+
+	_, err = pool.BorrowRWConnection()
+	req.Equal(ErrPoolExhausted, err)
+
+	req.Nil(pool.Reclaim(conn))
+	_, err = pool.BorrowRWConnection()
+	req.Nil(err)
+}
+
+func TestBorrowGrow(t *testing.T) {
+	req := require.New(t)
+	config := testConfig(2)
+	config.AcquireStrategy = AcquireGrow
+	config.MaxOverflowPerServer = 2
+	pool, cluster := newTestPool(t, config, []string{"leader:7687"}, nil)
+
+	var conns []connection.IConnection
+	for i := 0; i < 4; i++ {
+		conn, err := pool.BorrowRWConnection()
+		req.Nil(err)
+		conns = append(conns, conn)
+	}
+
+	// overflow is used up, grow falls back to waiting
+	_, err := pool.BorrowRWConnection()
+	req.Equal(ErrAcquireTimeout, err)
+	req.EqualValues(4, cluster.open())
+
+	// overflow connections are closed on reclaim, the pool shrinks back to its max
+	for _, conn := range conns {
+		req.Nil(pool.Reclaim(conn))
+	}
+	idle, inUse := pool.stats("bolt://leader:7687")
+	req.Equal(2, idle)
+	req.Equal(0, inUse)
+	req.EqualValues(2, cluster.open())
+}
+
+func TestIdleMaintenance(t *testing.T) {
+	req := require.New(t)
+	config := testConfig(4)
+	config.MinIdlePerServer = 2
+	config.MaxIdlePerServer = 3
+	pool, cluster := newTestPool(t, config, []string{"leader:7687"}, []string{"f1:7687"})
+
+	// start warms every server up to min idle
+	idle, _ := pool.stats("bolt://leader:7687")
+	req.Equal(2, idle)
+	idle, _ = pool.stats("bolt://f1:7687")
+	req.Equal(2, idle)
+
+	var conns []connection.IConnection
+	for i := 0; i < 4; i++ {
+		conn, err := pool.BorrowRWConnection()
+		req.Nil(err)
+		conns = append(conns, conn)
+	}
+
+	// borrowing drained the idle conns, balance can't go over the max to refill
+	pool.balance()
+	idle, inUse := pool.stats("bolt://leader:7687")
+	req.Equal(0, idle)
+	req.Equal(4, inUse)
+
+	// reclaiming past max idle closes the extra connection
+	for _, conn := range conns {
+		req.Nil(pool.Reclaim(conn))
+	}
+	idle, inUse = pool.stats("bolt://leader:7687")
+	req.Equal(3, idle)
+	req.Equal(0, inUse)
+	req.True(conns[3].(*fakeConn).closed)
+
+	// lowering max idle trims the oldest idle conns on the next balance
+	pool.mutex.Lock()
+	pool.config.MaxIdlePerServer = 2
+	pool.mutex.Unlock()
+	pool.balance()
+	idle, _ = pool.stats("bolt://leader:7687")
+	req.Equal(2, idle)
+	req.EqualValues(4, cluster.open())
+}
+
+func TestIdleConnectionsArePinged(t *testing.T) {
+	req := require.New(t)
+	config := testConfig(2)
+	config.IdleTestThreshold = 20 * time.Millisecond
+	pool, _ := newTestPool(t, config, []string{"leader:7687"}, nil)
+
+	conn, err := pool.BorrowRWConnection()
+	req.Nil(err)
+	req.Nil(pool.Reclaim(conn))
+
+	// reused straight away, no ping needed
+	again, err := pool.BorrowRWConnection()
+	req.Nil(err)
+	req.Equal(conn, again)
+	req.Equal(0, conn.(*fakeConn).pings)
+	req.Nil(pool.Reclaim(again))
+
+	time.Sleep(30 * time.Millisecond)
+	again, err = pool.BorrowRWConnection()
+	req.Nil(err)
+	req.Equal(conn, again)
+	req.Equal(1, conn.(*fakeConn).pings)
+	req.Nil(pool.Reclaim(again))
+
+	// a connection that fails the ping is swapped for a new one
+	conn.(*fakeConn).pingFail = true
+	time.Sleep(30 * time.Millisecond)
+	again, err = pool.BorrowRWConnection()
+	req.Nil(err)
+	req.NotEqual(conn, again)
+	req.True(conn.(*fakeConn).closed)
+
+	_, inUse := pool.stats("bolt://leader:7687")
+	req.Equal(1, inUse)
+}
+
+func TestExpiredConnectionsAreClosed(t *testing.T) {
+	req := require.New(t)
+	config := testConfig(2)
+	config.MaxConnLifetime = 20 * time.Millisecond
+	pool, cluster := newTestPool(t, config, []string{"leader:7687"}, nil)
+
+	first, err := pool.BorrowRWConnection()
+	req.Nil(err)
+	second, err := pool.BorrowRWConnection()
+	req.Nil(err)
+	req.Nil(pool.Reclaim(first))
+
+	time.Sleep(30 * time.Millisecond)
+
+	// expired while borrowed, closed on reclaim
+	req.Nil(pool.Reclaim(second))
+	req.True(second.(*fakeConn).closed)
+
+	// expired while idle, replaced on borrow
+	conn, err := pool.BorrowRWConnection()
+	req.Nil(err)
+	req.NotEqual(first, conn)
+	req.True(first.(*fakeConn).closed)
+	req.Nil(pool.Reclaim(conn))
+
+	// balance clears out expired idle connections
+	time.Sleep(30 * time.Millisecond)
+	pool.balance()
+	idle, _ := pool.stats("bolt://leader:7687")
+	req.Equal(0, idle)
+	req.EqualValues(0, cluster.open())
+}
+
+func TestRemovedServerIsDrained(t *testing.T) {
+	req := require.New(t)
+	config := testConfig(3)
+	config.MinIdlePerServer = 1
+	pool, cluster := newTestPool(t, config, []string{"leader:7687"}, []string{"f1:7687", "f2:7687"})
+
+	borrowed, err := pool.BorrowRConnection()
+	req.Nil(err)
+	gone := borrowed.(*fakeConn).connStr
+
+	var stays []string
+	for _, host := range []string{"f1:7687", "f2:7687"} {
+		if "bolt://"+host != gone {
+			stays = append(stays, host)
+		}
+	}
+
+	cluster.setMembers([]string{"leader:7687"}, stays)
+	req.Nil(pool.refreshConnections())
+
+	// reads only go to the remaining follower now
+	for i := 0; i < 3; i++ {
+		conn, err := pool.BorrowRConnection()
+		req.Nil(err)
+		req.Equal("bolt://"+stays[0], conn.(*fakeConn).connStr)
+		req.Nil(pool.Reclaim(conn))
+	}
+
+	// the borrowed connection to the old server is closed instead of pooled
+	req.Nil(pool.Reclaim(borrowed))
+	req.True(borrowed.(*fakeConn).closed)
+
+	pool.mutex.Lock()
+	_, ok := pool.servers[gone]
+	pool.mutex.Unlock()
+	req.False(ok)
+}
+
+func TestStopClosesConnections(t *testing.T) {
+	req := require.New(t)
+	config := testConfig(3)
+	config.MinIdlePerServer = 2
+	pool, cluster := newTestPool(t, config, []string{"leader:7687"}, []string{"f1:7687"})
+
+	conn, err := pool.BorrowRWConnection()
+	req.Nil(err)
+
+	req.Nil(pool.Stop())
+	req.NotNil(pool.Stop())
+
+	_, err = pool.BorrowRWConnection()
+	req.NotNil(err)
+
+	// reclaiming after stop still closes the connection
+	req.NotNil(pool.Reclaim(conn))
+	req.EqualValues(0, cluster.open())
+}
+
+func TestConcurrentBorrowReclaim(t *testing.T) {
+	req := require.New(t)
+	config := testConfig(3)
+	config.MinIdlePerServer = 1
+	config.AcquireTimeout = 10 * time.Second
+	pool, cluster := newTestPool(t, config, []string{"leader:7687"}, []string{"f1:7687", "f2:7687"})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				borrow := pool.BorrowRConnection
+				if (i+j)%3 == 0 {
+					borrow = pool.BorrowRWConnection
+				}
+
+				conn, err := borrow()
+				if err != nil {
+					errs <- err
+					return
+				}
+
+				if j%10 == 0 {
+					pool.balance()
+				}
+
+				if err := pool.Reclaim(conn); err != nil {
+					errs <- fmt.Errorf("reclaim: %w", err)
+					return
+				}
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		req.Nil(err)
+	}
+
+	// nothing leaked past the per server limit
+	for _, connStr := range []string{"bolt://leader:7687", "bolt://f1:7687", "bolt://f2:7687"} {
+		idle, inUse := pool.stats(connStr)
+		req.Equal(0, inUse)
+		req.True(idle <= 3)
+	}
+	req.True(cluster.open() <= 9)
+}
+
+func TestSecureSchemes(t *testing.T) {
+	cases := []struct {
+		leader string
+		member string
+	}{
+		{"neo4j://a:7687", "bolt://a:7687"},
+		{"neo4j+s://a:7687", "bolt+s://a:7687"},
+		{"neo4j+ssc://a:7687", "bolt+ssc://a:7687"},
+		{"bolt+routing://a:7687", "bolt://a:7687"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.leader, func(t *testing.T) {
+			req := require.New(t)
+
+			cluster := &fakeCluster{}
+			cluster.setMembers([]string{"a:7687"}, []string{"b:7687"})
+
+			pool, err := NewRoutingPool(c.leader, testConfig(1), "", "")
+			req.Nil(err)
+
+			r := pool.(*routingPool)
+			r.connFactory = cluster.connect
+			req.Nil(r.Start())
+			defer r.Stop()
+
+			conn, err := r.BorrowRWConnection()
+			req.Nil(err)
+			req.Equal(c.member, conn.(*fakeConn).connStr)
+			req.Nil(r.Reclaim(conn))
+
+			// members from the routing table keep the leader's encryption
+			conn, err = r.BorrowRConnection()
+			req.Nil(err)
+			req.Equal(strings.Replace(c.member, "a:", "b:", 1), conn.(*fakeConn).connStr)
+			req.Nil(r.Reclaim(conn))
+		})
+	}
+
+	_, err := NewRoutingPool("http://a:7687", testConfig(1), "", "")
+	require.NotNil(t, err)
+}
+
+func TestMemberHandshake(t *testing.T) {
+	req := require.New(t)
+
+	// handshakeSent opens a member connection to a server that agrees on no version and returns the versions
+	// it was offered
+	handshakeSent := func(config Config) []byte {
+		sent := make(chan []byte, 1)
+		config.Dialer = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			client, server := net.Pipe()
+			t.Cleanup(func() {
+				_ = client.Close()
+				_ = server.Close()
+			})
+
+			go func() {
+				handshake := make([]byte, 20)
+				if _, err := io.ReadFull(server, handshake); err != nil {
+					return
+				}
+				sent <- handshake[4:]
+				_, _ = server.Write([]byte{0x00, 0x00, 0x00, 0x00})
+			}()
+			return client, nil
+		}
+
+		pool, err := NewRoutingPool("neo4j://a:7687", config, "", "")
+		req.Nil(err)
+
+		_, err = pool.(*routingPool).connFactory("bolt://a:7687", nil)
+		req.NotNil(err)
+		return <-sent
+	}
+
+	req.Equal([]byte{
+		0x00, 0x00, 0x00, 0x04,
+		0x00, 0x00, 0x00, 0x03,
+		0x00, 0x00, 0x00, 0x02,
+		0x00, 0x00, 0x00, 0x01,
+	}, handshakeSent(testConfig(1)))
+
+	// impersonation needs bolt 4.4, which members are only offered with MinorVersions
+	config := testConfig(1)
+	config.MinorVersions = true
+	req.Equal([]byte{
+		0x00, 0x03, 0x04, 0x04,
+		0x00, 0x00, 0x00, 0x04,
+		0x00, 0x00, 0x00, 0x03,
+		0x00, 0x00, 0x00, 0x02,
+	}, handshakeSent(config))
+}
+
+func TestMemberDecoderLimits(t *testing.T) {
+	req := require.New(t)
+
+	// connect opens a member connection to a server that agrees on bolt 4.4 and answers HELLO with an empty SUCCESS
+	connect := func(config Config) error {
+		config.Dialer = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			client, server := net.Pipe()
+			t.Cleanup(func() {
+				_ = client.Close()
+				_ = server.Close()
+			})
+
+			go func() {
+				if _, err := io.ReadFull(server, make([]byte, 20)); err != nil {
+					return
+				}
+				if _, err := server.Write([]byte{0x00, 0x00, 0x04, 0x04}); err != nil {
+					return
+				}
+
+				// read HELLO up to its end of message
+				for {
+					header := make([]byte, 2)
+					if _, err := io.ReadFull(server, header); err != nil {
+						return
+					}
+					size := int(header[0])<<8 | int(header[1])
+					if size == 0 {
+						break
+					}
+					if _, err := io.ReadFull(server, make([]byte, size)); err != nil {
+						return
+					}
+				}
+				if _, err := server.Write([]byte{0x00, 0x03, 0xb1, 0x70, 0xa0, 0x00, 0x00}); err != nil {
+					return
+				}
+				// GOODBYE
+				_, _ = io.Copy(io.Discard, server)
+			}()
+			return client, nil
+		}
+
+		pool, err := NewRoutingPool("neo4j://a:7687", config, "", "")
+		req.Nil(err)
+
+		conn, err := pool.(*routingPool).connFactory("bolt://a:7687", nil)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+
+	req.Nil(connect(testConfig(1)))
+
+	// the SUCCESS is over a message size of 2 bytes
+	config := testConfig(1)
+	config.DecoderLimits = &encoding.Limits{MaxMessageSize: 2}
+	err := connect(config)
+	req.NotNil(err)
+	req.Contains(err.Error(), "message size")
+}
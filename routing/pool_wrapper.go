@@ -3,13 +3,17 @@ package routing
 import (
 	"github.com/mindstand/go-bolt/bolt_mode"
 	"github.com/mindstand/go-bolt/connection"
+	"time"
 )
 
 type connectionPoolWrapper struct {
-	Connection      connection.IConnection
-	ConnStr         string
-	ConnType        bolt_mode.AccessMode
-	borrowed        bool
-	numBorrows      int
-	markForDeletion bool
+	Connection connection.IConnection
+	ConnStr    string
+	// serverKey is the server pool the connection belongs to
+	serverKey string
+	ConnType  bolt_mode.AccessMode
+	// created is when the connection was opened, for enforcing max lifetime
+	created time.Time
+	// lastUsed is when the connection was last returned to its server pool
+	lastUsed time.Time
 }
@@ -27,6 +27,8 @@ type neoNodeConfig struct {
 	Id         string
 	Addresses  []string
 	Database   string
+	// Databases maps database names to the node's role for them, only filled on bolt v4
+	Databases  map[string]string
 	Groups     []string
 	BoltString string
 	Action     bolt_mode.AccessMode
@@ -93,6 +95,43 @@ func (c *boltRoutingHandler) getReadOnlyConnectionString() []string {
 	return connStrs
 }
 
+// getDatabases returns the names of every database the cluster members report
+func (c *boltRoutingHandler) getDatabases() []string {
+	var dbs []string
+	for _, nodes := range [][]neoNodeConfig{c.Leaders, c.Followers, c.ReadReplicas} {
+		for _, node := range nodes {
+			for db := range node.Databases {
+				if !stringSliceContains(dbs, db) {
+					dbs = append(dbs, db)
+				}
+			}
+		}
+	}
+
+	return dbs
+}
+
+// getDatabaseConnectionStrings returns the members that write and read db, ok is false when no member has db
+func (c *boltRoutingHandler) getDatabaseConnectionStrings(db string) (writers, readers []string, ok bool) {
+	for _, nodes := range [][]neoNodeConfig{c.Leaders, c.Followers, c.ReadReplicas} {
+		for _, node := range nodes {
+			role, hasDb := node.Databases[db]
+			if !hasDb {
+				continue
+			}
+
+			ok = true
+			if nodeType, _ := c.infoFromRoleString(role); nodeType == Leader {
+				writers = append(writers, node.BoltString)
+			} else if nodeType == Follower || nodeType == ReadReplica {
+				readers = append(readers, node.BoltString)
+			}
+		}
+	}
+
+	return writers, readers, ok
+}
+
 func (c *boltRoutingHandler) refreshClusterInfo(conn connection.IConnection) error {
 	if conn == nil {
 		return errors.New("bolt connection can not be nil")
@@ -118,8 +157,9 @@ func (c *boltRoutingHandler) refreshClusterInfo(conn connection.IConnection) err
 	for _, row := range rows {
 		var id, role string
 		var addresses []string
+		var databases map[string]string
 		if conn.GetProtocolVersionNumber() == 4 {
-			id, addresses, role, err = c.parseRowV4(row)
+			id, addresses, role, databases, err = c.parseRowV4(row)
 		} else {
 			id, addresses, role, _, _, err = c.parseRowV3(row)
 		}
@@ -146,6 +186,7 @@ func (c *boltRoutingHandler) refreshClusterInfo(conn connection.IConnection) err
 			Id:         id,
 			BoltString: boltStr,
 			Addresses:  addresses,
+			Databases:  databases,
 			// Database:   database,
 			// Groups:     groups,
 			Action: action,
@@ -178,37 +219,41 @@ func (c *boltRoutingHandler) refreshClusterInfo(conn connection.IConnection) err
  [0]   [1]      [2]       [3]
   id  address databases groups
 */
-func (c *boltRoutingHandler) parseRowV4(row []interface{}) (id string, addresses []string, role string, err error) {
+func (c *boltRoutingHandler) parseRowV4(row []interface{}) (id string, addresses []string, role string, databases map[string]string, err error) {
 	if len(row) != 4 {
-		return "", nil, "", fmt.Errorf("expected [4] rows but go [%v]", len(row))
+		return "", nil, "", nil, fmt.Errorf("expected [4] rows but go [%v]", len(row))
 	}
 
 	id, ok := row[0].(string)
 	if !ok {
-		return "", nil, "", errors.New("unable to parse id into string")
+		return "", nil, "", nil, errors.New("unable to parse id into string")
 	}
 
 	addresses, err = c.convertInterfaceToStringArr(row[1])
 	if err != nil {
-		return "", nil, "", errors.New("unable to parse addresses into []string")
+		return "", nil, "", nil, errors.New("unable to parse addresses into []string")
 	}
 
 	databsesMap, ok := row[2].(map[string]interface{})
 	if !ok {
-		return "", nil, "", errors.New("unable to parse addresses into map[string]interface{}")
+		return "", nil, "", nil, errors.New("unable to parse addresses into map[string]interface{}")
 	}
 
-	roleInt, ok := databsesMap["system"]
-	if !ok {
-		return "", nil, "", errors.New("role not found")
+	databases = make(map[string]string, len(databsesMap))
+	for name, dbRoleInt := range databsesMap {
+		dbRole, ok := dbRoleInt.(string)
+		if !ok {
+			return "", nil, "", nil, fmt.Errorf("unable to convert role for database [%s] from [%T] to [string]", name, dbRoleInt)
+		}
+		databases[name] = dbRole
 	}
 
-	role, ok = roleInt.(string)
+	role, ok = databases[neoV4SystemDb]
 	if !ok {
-		return "", nil, "", fmt.Errorf("unable to convert role from [%T] to [string]", roleInt)
+		return "", nil, "", nil, errors.New("role not found")
 	}
 
-	return id, addresses, role, nil
+	return id, addresses, role, databases, nil
 }
 
 /*
@@ -0,0 +1,99 @@
+package routing
+
+import "github.com/mindstand/go-bolt/connection"
+
+// serverPool holds the connections to a single neo4j server.
+// It is only accessed while holding the routing pool mutex
+type serverPool struct {
+	connStr string
+	// authKey and authToken are set on partitions for sessions that override the pool's credentials
+	authKey   string
+	authToken map[string]interface{}
+	// idle connections, most recently used last
+	idle []*connectionPoolWrapper
+	// borrowed connections plus connections being opened
+	inUse int
+	// removed is set once the server drops out of the cluster, connections are closed as they come back
+	removed bool
+}
+
+func newServerPool(connStr string) *serverPool {
+	return &serverPool{
+		connStr: connStr,
+		idle:    []*connectionPoolWrapper{},
+	}
+}
+
+// serverKey is the key of the server pool for connStr and the credentials identified by authKey
+func serverKey(connStr, authKey string) string {
+	if authKey == "" {
+		return connStr
+	}
+	return connStr + "#" + authKey
+}
+
+// total is the number of connections the server pool is responsible for
+func (s *serverPool) total() int {
+	return s.inUse + len(s.idle)
+}
+
+// hasCapacity determines if a connection can be handed out without waiting
+func (s *serverPool) hasCapacity(maxConns int) bool {
+	return !s.removed && (len(s.idle) != 0 || s.total() < maxConns)
+}
+
+// popIdle takes the most recently used idle connection
+func (s *serverPool) popIdle() *connectionPoolWrapper {
+	if len(s.idle) == 0 {
+		return nil
+	}
+
+	last := len(s.idle) - 1
+	connWrap := s.idle[last]
+	s.idle[last] = nil
+	s.idle = s.idle[:last]
+	return connWrap
+}
+
+// pushIdle returns a connection to the idle set
+func (s *serverPool) pushIdle(connWrap *connectionPoolWrapper) {
+	s.idle = append(s.idle, connWrap)
+}
+
+// trimIdle removes idle connections over max, oldest first
+func (s *serverPool) trimIdle(max int) []*connectionPoolWrapper {
+	if len(s.idle) <= max {
+		return nil
+	}
+
+	extra := len(s.idle) - max
+	trimmed := append([]*connectionPoolWrapper{}, s.idle[:extra]...)
+	s.idle = append(s.idle[:0], s.idle[extra:]...)
+	return trimmed
+}
+
+// removeExpired removes idle connections past their max lifetime
+func (s *serverPool) removeExpired(health connection.HealthCheck) []*connectionPoolWrapper {
+	var expired []*connectionPoolWrapper
+	kept := s.idle[:0]
+	for _, connWrap := range s.idle {
+		if health.Expired(connWrap.created) {
+			expired = append(expired, connWrap)
+		} else {
+			kept = append(kept, connWrap)
+		}
+	}
+
+	for i := len(kept); i < len(s.idle); i++ {
+		s.idle[i] = nil
+	}
+	s.idle = kept
+	return expired
+}
+
+// drain removes every idle connection
+func (s *serverPool) drain() []*connectionPoolWrapper {
+	drained := s.idle
+	s.idle = []*connectionPoolWrapper{}
+	return drained
+}
@@ -0,0 +1,21 @@
+package goBolt
+
+import "github.com/mindstand/go-bolt/routing"
+
+// SessionConfig selects the database, user and credentials a connection runs queries with
+type SessionConfig struct {
+	// Database queries default to, empty uses the client's database
+	Database string
+	// ImpersonatedUser runs queries as another user, requires bolt 4.4 and the impersonate privilege
+	ImpersonatedUser string
+	// AuthToken replaces the client's credentials, see messages.BuildAuthTokenBasic
+	AuthToken map[string]interface{}
+}
+
+func (s SessionConfig) routingSession() routing.Session {
+	return routing.Session{
+		Database:         s.Database,
+		ImpersonatedUser: s.ImpersonatedUser,
+		AuthToken:        s.AuthToken,
+	}
+}
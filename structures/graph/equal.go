@@ -0,0 +1,35 @@
+package graph
+
+import "reflect"
+
+// labelsEqual compares labels as sets, neo4j doesn't keep them in order
+func labelsEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	counts := make(map[string]int, len(a))
+	for _, label := range a {
+		counts[label]++
+	}
+	for _, label := range b {
+		if counts[label] == 0 {
+			return false
+		}
+		counts[label]--
+	}
+
+	return true
+}
+
+// propertiesEqual compares properties, nil and empty are the same
+func propertiesEqual(a, b map[string]interface{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	if len(a) == 0 {
+		return true
+	}
+
+	return reflect.DeepEqual(a, b)
+}
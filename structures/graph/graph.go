@@ -0,0 +1,105 @@
+package graph
+
+import "sort"
+
+// Graph is the nodes and relationships of a result, each once, like the graph view of neo4j's http api.
+// It marshals as {"nodes": [...], "relationships": [...]}
+type Graph struct {
+	Nodes         []Node         `json:"nodes"`
+	Relationships []Relationship `json:"relationships"`
+
+	nodeIndexes map[int64]int
+	relIndexes  map[int64]int
+}
+
+// Collect returns the graph of values, see Graph.Add
+func Collect(values ...interface{}) *Graph {
+	g := &Graph{}
+	for _, val := range values {
+		g.Add(val)
+	}
+	return g
+}
+
+// Add adds the nodes and relationships in val to the graph, in the order they are first seen. Paths add what
+// they walk and lists and maps are searched all the way down. Unbound relationships outside of a path are
+// skipped, there is no telling which nodes they join
+func (g *Graph) Add(val interface{}) {
+	switch v := val.(type) {
+	case Node:
+		g.addNode(v)
+	case *Node:
+		if v != nil {
+			g.addNode(*v)
+		}
+	case Relationship:
+		g.addRelationship(v)
+	case *Relationship:
+		if v != nil {
+			g.addRelationship(*v)
+		}
+	case Path:
+		g.addPath(v)
+	case *Path:
+		if v != nil {
+			g.addPath(*v)
+		}
+	case []interface{}:
+		for _, item := range v {
+			g.Add(item)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		// in key order, so the graph comes out the same every time
+		sort.Strings(keys)
+		for _, key := range keys {
+			g.Add(v[key])
+		}
+	}
+}
+
+func (g *Graph) addNode(node Node) {
+	if g.nodeIndexes == nil {
+		g.nodeIndexes = map[int64]int{}
+	}
+	if _, ok := g.nodeIndexes[node.NodeIdentity]; ok {
+		return
+	}
+
+	g.nodeIndexes[node.NodeIdentity] = len(g.Nodes)
+	g.Nodes = append(g.Nodes, node)
+}
+
+func (g *Graph) addRelationship(rel Relationship) {
+	if g.relIndexes == nil {
+		g.relIndexes = map[int64]int{}
+	}
+	if _, ok := g.relIndexes[rel.RelIdentity]; ok {
+		return
+	}
+
+	g.relIndexes[rel.RelIdentity] = len(g.Relationships)
+	g.Relationships = append(g.Relationships, rel)
+}
+
+// addPath adds the nodes and relationships the path walks. A path Segments can't follow adds just its nodes
+func (g *Graph) addPath(path Path) {
+	segments, err := path.Segments()
+	if err != nil {
+		for _, node := range path.Nodes {
+			g.addNode(node)
+		}
+		return
+	}
+
+	if len(path.Nodes) != 0 {
+		g.addNode(path.Start())
+	}
+	for _, segment := range segments {
+		g.addRelationship(segment.Relationship)
+		g.addNode(segment.End)
+	}
+}
@@ -0,0 +1,299 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/mindstand/go-bolt/errors"
+	"github.com/mindstand/go-bolt/structures/types"
+)
+
+// The json schema of the graph types follows neo4j's http api, except ids are numbers rather than strings.
+// Properties are written with types.JSONValue, so temporal values are ISO-8601 strings and points are
+// {"type": "Point", ...} objects
+//
+//	node:                 {"id": 1, "labels": ["Person"], "properties": {"name": "Alice"}}
+//	relationship:         {"id": 2, "type": "KNOWS", "startNode": 1, "endNode": 3, "properties": {}}
+//	unbound relationship: {"id": 2, "type": "KNOWS", "properties": {}}
+//	path:                 {"nodes": [node, ...], "relationships": [relationship, ...]}
+//	graph:                {"nodes": [node, ...], "relationships": [relationship, ...]}
+//
+// A path lists its nodes in the order it walks them, a node for each step and one more, and its relationships
+// bound to the nodes they join, keeping their own direction. Labels and properties are never null. Json has
+// a single number type, so reading properties back gives an int64 for whole numbers and a float64 otherwise,
+// and temporal values and points come back as they were written, as strings and maps
+
+type jsonNode struct {
+	ID         int64                  `json:"id"`
+	Labels     []string               `json:"labels"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type jsonRelationship struct {
+	ID         int64                  `json:"id"`
+	Type       string                 `json:"type"`
+	StartNode  int64                  `json:"startNode"`
+	EndNode    int64                  `json:"endNode"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type jsonUnboundRelationship struct {
+	ID         int64                  `json:"id"`
+	Type       string                 `json:"type"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type jsonPath struct {
+	Nodes         []Node         `json:"nodes"`
+	Relationships []Relationship `json:"relationships"`
+}
+
+type jsonGraph jsonPath
+
+// jsonProperties converts properties for writing, nil is written as {}
+func jsonProperties(properties map[string]interface{}) map[string]interface{} {
+	if properties == nil {
+		return map[string]interface{}{}
+	}
+	return types.JSONValue(properties).(map[string]interface{})
+}
+
+// unmarshalJSON reads data into v with numbers kept as json.Number, so whole numbers can be made int64
+func unmarshalJSON(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// fromJSONProperties turns the json.Numbers in properties into int64s, or float64s when they aren't whole
+func fromJSONProperties(properties map[string]interface{}) map[string]interface{} {
+	if properties == nil {
+		return map[string]interface{}{}
+	}
+	return fromJSONValue(properties).(map[string]interface{})
+}
+
+func fromJSONValue(val interface{}) interface{} {
+	switch v := val.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case []interface{}:
+		for i, item := range v {
+			v[i] = fromJSONValue(item)
+		}
+		return v
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = fromJSONValue(item)
+		}
+		return v
+	default:
+		return val
+	}
+}
+
+// MarshalJSON writes the node as {"id": id, "labels": [...], "properties": {...}}
+func (n Node) MarshalJSON() ([]byte, error) {
+	labels := n.Labels
+	if labels == nil {
+		labels = []string{}
+	}
+
+	return json.Marshal(jsonNode{
+		ID:         n.NodeIdentity,
+		Labels:     labels,
+		Properties: jsonProperties(n.Properties),
+	})
+}
+
+// UnmarshalJSON reads a node written by MarshalJSON
+func (n *Node) UnmarshalJSON(data []byte) error {
+	var node jsonNode
+	if err := unmarshalJSON(data, &node); err != nil {
+		return err
+	}
+
+	labels := node.Labels
+	if labels == nil {
+		labels = []string{}
+	}
+
+	*n = Node{
+		NodeIdentity: node.ID,
+		Labels:       labels,
+		Properties:   fromJSONProperties(node.Properties),
+	}
+	return nil
+}
+
+// MarshalJSON writes the relationship as {"id": id, "type": type, "startNode": id, "endNode": id, "properties": {...}}
+func (r Relationship) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonRelationship{
+		ID:         r.RelIdentity,
+		Type:       r.Type,
+		StartNode:  r.StartNodeIdentity,
+		EndNode:    r.EndNodeIdentity,
+		Properties: jsonProperties(r.Properties),
+	})
+}
+
+// UnmarshalJSON reads a relationship written by MarshalJSON
+func (r *Relationship) UnmarshalJSON(data []byte) error {
+	var rel jsonRelationship
+	if err := unmarshalJSON(data, &rel); err != nil {
+		return err
+	}
+
+	*r = Relationship{
+		RelIdentity:       rel.ID,
+		StartNodeIdentity: rel.StartNode,
+		EndNodeIdentity:   rel.EndNode,
+		Type:              rel.Type,
+		Properties:        fromJSONProperties(rel.Properties),
+	}
+	return nil
+}
+
+// MarshalJSON writes the relationship as {"id": id, "type": type, "properties": {...}}
+func (r UnboundRelationship) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonUnboundRelationship{
+		ID:         r.RelIdentity,
+		Type:       r.Type,
+		Properties: jsonProperties(r.Properties),
+	})
+}
+
+// UnmarshalJSON reads a relationship written by MarshalJSON
+func (r *UnboundRelationship) UnmarshalJSON(data []byte) error {
+	var rel jsonUnboundRelationship
+	if err := unmarshalJSON(data, &rel); err != nil {
+		return err
+	}
+
+	*r = UnboundRelationship{
+		RelIdentity: rel.ID,
+		Type:        rel.Type,
+		Properties:  fromJSONProperties(rel.Properties),
+	}
+	return nil
+}
+
+// MarshalJSON writes the path as {"nodes": [...], "relationships": [...]}, nodes in the order the path walks
+// them and relationships bound to the nodes they join
+func (p Path) MarshalJSON() ([]byte, error) {
+	segments, err := p.Segments()
+	if err != nil {
+		return nil, err
+	}
+
+	path := jsonPath{
+		Nodes:         make([]Node, 0, len(segments)+1),
+		Relationships: make([]Relationship, 0, len(segments)),
+	}
+	if len(p.Nodes) != 0 {
+		path.Nodes = append(path.Nodes, p.Start())
+	}
+	for _, segment := range segments {
+		path.Relationships = append(path.Relationships, segment.Relationship)
+		path.Nodes = append(path.Nodes, segment.End)
+	}
+
+	return json.Marshal(path)
+}
+
+// UnmarshalJSON reads a path written by MarshalJSON, rebuilding its sequence. Every relationship has to join
+// the nodes either side of it
+func (p *Path) UnmarshalJSON(data []byte) error {
+	var path jsonPath
+	if err := json.Unmarshal(data, &path); err != nil {
+		return err
+	}
+
+	if len(path.Nodes) == 0 {
+		if len(path.Relationships) != 0 {
+			return errors.New("path has %d relationships but no nodes", len(path.Relationships))
+		}
+		*p = Path{Nodes: []Node{}, Relationships: []UnboundRelationship{}, Sequence: []int{}}
+		return nil
+	}
+	if len(path.Nodes) != len(path.Relationships)+1 {
+		return errors.New("path has %d nodes for %d relationships, expected %d", len(path.Nodes), len(path.Relationships), len(path.Relationships)+1)
+	}
+
+	result := Path{
+		Nodes:         []Node{path.Nodes[0]},
+		Relationships: []UnboundRelationship{},
+		Sequence:      make([]int, 0, len(path.Relationships)*2),
+	}
+	nodeIndexes := map[int64]int{path.Nodes[0].NodeIdentity: 0}
+	relIndexes := map[int64]int{}
+
+	for i, rel := range path.Relationships {
+		start, end := path.Nodes[i], path.Nodes[i+1]
+
+		relIndex, ok := relIndexes[rel.RelIdentity]
+		if !ok {
+			result.Relationships = append(result.Relationships, UnboundRelationship{
+				RelIdentity: rel.RelIdentity,
+				Type:        rel.Type,
+				Properties:  rel.Properties,
+			})
+			relIndex = len(result.Relationships)
+			relIndexes[rel.RelIdentity] = relIndex
+		}
+
+		switch {
+		case rel.StartNodeIdentity == start.NodeIdentity && rel.EndNodeIdentity == end.NodeIdentity:
+		case rel.StartNodeIdentity == end.NodeIdentity && rel.EndNodeIdentity == start.NodeIdentity:
+			relIndex = -relIndex
+		default:
+			return errors.New("path relationship %d goes from %d to %d, it doesn't join nodes %d and %d",
+				rel.RelIdentity, rel.StartNodeIdentity, rel.EndNodeIdentity, start.NodeIdentity, end.NodeIdentity)
+		}
+
+		nodeIndex, ok := nodeIndexes[end.NodeIdentity]
+		if !ok {
+			result.Nodes = append(result.Nodes, end)
+			nodeIndex = len(result.Nodes) - 1
+			nodeIndexes[end.NodeIdentity] = nodeIndex
+		}
+
+		result.Sequence = append(result.Sequence, relIndex, nodeIndex)
+	}
+
+	*p = result
+	return nil
+}
+
+// MarshalJSON writes the graph as {"nodes": [...], "relationships": [...]}, an empty graph has empty lists
+func (g Graph) MarshalJSON() ([]byte, error) {
+	graph := jsonGraph{Nodes: g.Nodes, Relationships: g.Relationships}
+	if graph.Nodes == nil {
+		graph.Nodes = []Node{}
+	}
+	if graph.Relationships == nil {
+		graph.Relationships = []Relationship{}
+	}
+	return json.Marshal(graph)
+}
+
+// UnmarshalJSON reads a graph written by MarshalJSON, more can be added to it after
+func (g *Graph) UnmarshalJSON(data []byte) error {
+	var graph jsonGraph
+	if err := json.Unmarshal(data, &graph); err != nil {
+		return err
+	}
+
+	*g = Graph{}
+	for _, node := range graph.Nodes {
+		g.addNode(node)
+	}
+	for _, rel := range graph.Relationships {
+		g.addRelationship(rel)
+	}
+	return nil
+}
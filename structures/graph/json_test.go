@@ -0,0 +1,177 @@
+package graph
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mindstand/go-bolt/structures/types"
+	"github.com/mindstand/gotime"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeJSON(t *testing.T) {
+	req := require.New(t)
+
+	node := Node{
+		NodeIdentity: 1,
+		Labels:       []string{"Person"},
+		Properties: map[string]interface{}{
+			"name":      "Alice",
+			"age":       int64(42),
+			"height":    1.5,
+			"born":      gotime.NewDate(1978, 2, 3),
+			"home":      &types.Point2D{SRID: 7203, X: 1, Y: 2},
+			"nicknames": []interface{}{"Al", int64(7)},
+		},
+	}
+
+	data, err := json.Marshal(node)
+	req.Nil(err)
+	req.JSONEq(`{
+		"id": 1,
+		"labels": ["Person"],
+		"properties": {
+			"name": "Alice",
+			"age": 42,
+			"height": 1.5,
+			"born": "1978-02-03",
+			"home": {"type": "Point", "coordinates": [1, 2], "crs": {"srid": 7203, "name": "cartesian"}},
+			"nicknames": ["Al", 7]
+		}
+	}`, string(data))
+
+	var read Node
+	req.Nil(json.Unmarshal(data, &read))
+	req.Equal(int64(1), read.NodeIdentity)
+	req.Equal([]string{"Person"}, read.Labels)
+	req.Equal(int64(42), read.Properties["age"])
+	req.Equal(1.5, read.Properties["height"])
+	req.Equal("1978-02-03", read.Properties["born"])
+	req.Equal([]interface{}{"Al", int64(7)}, read.Properties["nicknames"])
+
+	// labels and properties are never null
+	data, err = json.Marshal(Node{NodeIdentity: 2})
+	req.Nil(err)
+	req.Equal(`{"id":2,"labels":[],"properties":{}}`, string(data))
+}
+
+func TestRelationshipJSON(t *testing.T) {
+	req := require.New(t)
+
+	rel := knows.Bind(1, 2)
+	data, err := json.Marshal(rel)
+	req.Nil(err)
+	req.Equal(`{"id":10,"type":"KNOWS","startNode":1,"endNode":2,"properties":{"since":2001}}`, string(data))
+
+	var read Relationship
+	req.Nil(json.Unmarshal(data, &read))
+	req.True(rel.Equal(read))
+
+	data, err = json.Marshal(knows)
+	req.Nil(err)
+	req.Equal(`{"id":10,"type":"KNOWS","properties":{"since":2001}}`, string(data))
+
+	var unbound UnboundRelationship
+	req.Nil(json.Unmarshal(data, &unbound))
+	req.True(knows.Equal(unbound))
+}
+
+func TestPathJSON(t *testing.T) {
+	req := require.New(t)
+
+	data, err := json.Marshal(mixedPath)
+	req.Nil(err)
+	req.JSONEq(`{
+		"nodes": [
+			{"id": 1, "labels": ["Person"], "properties": {"name": "Alice"}},
+			{"id": 2, "labels": ["Person"], "properties": {"name": "Bob"}},
+			{"id": 3, "labels": ["Person"], "properties": {"name": "Carol"}}
+		],
+		"relationships": [
+			{"id": 10, "type": "KNOWS", "startNode": 1, "endNode": 2, "properties": {"since": 2001}},
+			{"id": 11, "type": "LIKES", "startNode": 3, "endNode": 2, "properties": {}}
+		]
+	}`, string(data))
+
+	var read Path
+	req.Nil(json.Unmarshal(data, &read))
+	req.Equal(mixedPath.Sequence, read.Sequence)
+	req.True(mixedPath.Equal(read))
+
+	// the same relationship walked both ways comes back once
+	revisit := Path{
+		Nodes:         []Node{alice, bob},
+		Relationships: []UnboundRelationship{knows},
+		Sequence:      []int{1, 1, -1, 0},
+	}
+	data, err = json.Marshal(revisit)
+	req.Nil(err)
+
+	req.Nil(json.Unmarshal(data, &read))
+	req.Len(read.Nodes, 2)
+	req.Len(read.Relationships, 1)
+	req.Equal(revisit.Sequence, read.Sequence)
+
+	// a lone node is a path of no relationships
+	data, err = json.Marshal(Path{Nodes: []Node{alice}})
+	req.Nil(err)
+	req.Nil(json.Unmarshal(data, &read))
+	req.Equal(alice.NodeIdentity, read.Start().NodeIdentity)
+	req.Equal(0, read.Len())
+
+	_, err = json.Marshal(Path{Nodes: []Node{alice}, Sequence: []int{1}})
+	req.NotNil(err)
+
+	// relationships have to join the nodes either side of them
+	req.NotNil(json.Unmarshal([]byte(`{
+		"nodes": [{"id": 1}, {"id": 2}],
+		"relationships": [{"id": 10, "type": "KNOWS", "startNode": 1, "endNode": 3}]
+	}`), &read))
+	req.NotNil(json.Unmarshal([]byte(`{"nodes": [{"id": 1}], "relationships": [{"id": 10}]}`), &read))
+}
+
+func TestGraph(t *testing.T) {
+	req := require.New(t)
+
+	dave := Node{NodeIdentity: 4}
+	friend := Relationship{RelIdentity: 12, StartNodeIdentity: 4, EndNodeIdentity: 1, Type: "FRIEND"}
+
+	g := Collect(
+		alice,
+		[]interface{}{&bob, mixedPath},
+		map[string]interface{}{"b": friend, "a": dave, "skipped": knows},
+		"not graph",
+	)
+
+	ids := func() (nodes, rels []int64) {
+		for _, node := range g.Nodes {
+			nodes = append(nodes, node.NodeIdentity)
+		}
+		for _, rel := range g.Relationships {
+			rels = append(rels, rel.RelIdentity)
+		}
+		return
+	}
+
+	nodes, rels := ids()
+	req.Equal([]int64{1, 2, 3, 4}, nodes)
+	req.Equal([]int64{10, 11, 12}, rels)
+	req.Equal(int64(3), g.Relationships[1].StartNodeIdentity)
+
+	data, err := json.Marshal(g)
+	req.Nil(err)
+
+	var read Graph
+	req.Nil(json.Unmarshal(data, &read))
+	req.Len(read.Nodes, 4)
+	req.Len(read.Relationships, 3)
+
+	// more can be added after reading, without duplicates
+	read.Add(alice)
+	read.Add(Node{NodeIdentity: 5})
+	req.Len(read.Nodes, 5)
+
+	data, err = json.Marshal(Graph{})
+	req.Nil(err)
+	req.Equal(`{"nodes":[],"relationships":[]}`, string(data))
+}
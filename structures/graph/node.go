@@ -25,3 +25,8 @@ func (n Node) AllFields() []interface{} {
 	}
 	return []interface{}{n.NodeIdentity, labels, n.Properties}
 }
+
+// Equal reports whether both are the same node with the same labels, in any order, and properties
+func (n Node) Equal(other Node) bool {
+	return n.NodeIdentity == other.NodeIdentity && labelsEqual(n.Labels, other.Labels) && propertiesEqual(n.Properties, other.Properties)
+}
@@ -1,11 +1,15 @@
 package graph
 
+import "github.com/mindstand/go-bolt/errors"
+
 const (
 	// PathSignature is the signature byte for a Path object
 	PathSignature = 0x50
 )
 
-// Path Represents a Path structure
+// Path Represents a Path structure. Nodes and Relationships hold each node and relationship once, Sequence
+// walks them from the first node as pairs of a relationship index and the index of the node it leads to.
+// Relationship indexes count from 1 and are negative when the path goes against the relationship's direction
 type Path struct {
 	Nodes         []Node
 	Relationships []UnboundRelationship
@@ -33,3 +37,106 @@ func (p Path) AllFields() []interface{} {
 	}
 	return []interface{}{nodes, relationships, sequences}
 }
+
+// Segment is one step of a path, from Start over Relationship to End. Start and End are in the order the path
+// walks, the relationship keeps its own direction
+type Segment struct {
+	Start        Node
+	Relationship Relationship
+	End          Node
+	// Reversed is set when the path walks the relationship from its end node to its start node
+	Reversed bool
+}
+
+// Segments expands the path into its steps in order, binding each relationship to the nodes it joins
+func (p Path) Segments() ([]Segment, error) {
+	if len(p.Sequence)%2 != 0 {
+		return nil, errors.New("path sequence has an odd length of %d", len(p.Sequence))
+	}
+	if len(p.Sequence) == 0 {
+		return []Segment{}, nil
+	}
+	if len(p.Nodes) == 0 {
+		return nil, errors.New("path has a sequence but no nodes")
+	}
+
+	segments := make([]Segment, 0, len(p.Sequence)/2)
+	start := p.Nodes[0]
+	for i := 0; i < len(p.Sequence); i += 2 {
+		relIndex, nodeIndex := p.Sequence[i], p.Sequence[i+1]
+		if nodeIndex < 0 || nodeIndex >= len(p.Nodes) {
+			return nil, errors.New("path sequence has node index %d, the path has %d nodes", nodeIndex, len(p.Nodes))
+		}
+		end := p.Nodes[nodeIndex]
+
+		segment := Segment{Start: start, End: end}
+		switch {
+		case relIndex > 0 && relIndex <= len(p.Relationships):
+			segment.Relationship = p.Relationships[relIndex-1].Bind(start.NodeIdentity, end.NodeIdentity)
+		case relIndex < 0 && -relIndex <= len(p.Relationships):
+			segment.Relationship = p.Relationships[-relIndex-1].Bind(end.NodeIdentity, start.NodeIdentity)
+			segment.Reversed = true
+		default:
+			return nil, errors.New("path sequence has relationship index %d, the path has %d relationships", relIndex, len(p.Relationships))
+		}
+
+		segments = append(segments, segment)
+		start = end
+	}
+
+	return segments, nil
+}
+
+// Start returns the node the path starts at
+func (p Path) Start() Node {
+	if len(p.Nodes) == 0 {
+		return Node{}
+	}
+	return p.Nodes[0]
+}
+
+// End returns the node the path ends at, which is the start for a path of no relationships
+func (p Path) End() Node {
+	if len(p.Sequence) < 2 {
+		return p.Start()
+	}
+
+	nodeIndex := p.Sequence[len(p.Sequence)-1]
+	if nodeIndex < 0 || nodeIndex >= len(p.Nodes) {
+		return Node{}
+	}
+	return p.Nodes[nodeIndex]
+}
+
+// Len returns how many relationships the path walks, counting a relationship each time it is walked
+func (p Path) Len() int {
+	return len(p.Sequence) / 2
+}
+
+// Equal reports whether both paths walk the same nodes over the same relationships in the same order. Paths
+// with a sequence Segments can't follow are never equal
+func (p Path) Equal(other Path) bool {
+	if p.Len() != other.Len() || !p.Start().Equal(other.Start()) {
+		return false
+	}
+
+	segments, err := p.Segments()
+	if err != nil {
+		return false
+	}
+	otherSegments, err := other.Segments()
+	if err != nil {
+		return false
+	}
+
+	for i, segment := range segments {
+		otherSegment := otherSegments[i]
+		if segment.Reversed != otherSegment.Reversed ||
+			!segment.Relationship.Equal(otherSegment.Relationship) ||
+			!segment.End.Equal(otherSegment.End) {
+			return false
+		}
+	}
+
+	return true
+}
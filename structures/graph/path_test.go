@@ -0,0 +1,150 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	alice = Node{NodeIdentity: 1, Labels: []string{"Person"}, Properties: map[string]interface{}{"name": "Alice"}}
+	bob   = Node{NodeIdentity: 2, Labels: []string{"Person"}, Properties: map[string]interface{}{"name": "Bob"}}
+	carol = Node{NodeIdentity: 3, Labels: []string{"Person"}, Properties: map[string]interface{}{"name": "Carol"}}
+	knows = UnboundRelationship{RelIdentity: 10, Type: "KNOWS", Properties: map[string]interface{}{"since": int64(2001)}}
+	likes = UnboundRelationship{RelIdentity: 11, Type: "LIKES", Properties: map[string]interface{}{}}
+)
+
+// (alice)-[:KNOWS]->(bob)<-[:LIKES]-(carol)
+var mixedPath = Path{
+	Nodes:         []Node{alice, bob, carol},
+	Relationships: []UnboundRelationship{knows, likes},
+	Sequence:      []int{1, 1, -2, 2},
+}
+
+func TestPathSegments(t *testing.T) {
+	req := require.New(t)
+
+	segments, err := mixedPath.Segments()
+	req.Nil(err)
+	req.Equal([]Segment{
+		{
+			Start:        alice,
+			Relationship: Relationship{RelIdentity: 10, StartNodeIdentity: 1, EndNodeIdentity: 2, Type: "KNOWS", Properties: knows.Properties},
+			End:          bob,
+		},
+		{
+			Start:        bob,
+			Relationship: Relationship{RelIdentity: 11, StartNodeIdentity: 3, EndNodeIdentity: 2, Type: "LIKES", Properties: likes.Properties},
+			End:          carol,
+			Reversed:     true,
+		},
+	}, segments)
+
+	req.Equal(alice, mixedPath.Start())
+	req.Equal(carol, mixedPath.End())
+	req.Equal(2, mixedPath.Len())
+}
+
+func TestPathSegmentsRevisit(t *testing.T) {
+	req := require.New(t)
+
+	// (alice)-[:KNOWS]->(bob)<-[:KNOWS]-(alice), the same relationship walked both ways
+	path := Path{
+		Nodes:         []Node{alice, bob},
+		Relationships: []UnboundRelationship{knows},
+		Sequence:      []int{1, 1, -1, 0},
+	}
+
+	segments, err := path.Segments()
+	req.Nil(err)
+	req.Len(segments, 2)
+	req.Equal(segments[0].Relationship, segments[1].Relationship)
+	req.False(segments[0].Reversed)
+	req.True(segments[1].Reversed)
+	req.Equal(bob, segments[1].Start)
+	req.Equal(alice, segments[1].End)
+
+	req.Equal(alice, path.End())
+	req.Equal(2, path.Len())
+}
+
+func TestPathSingleNode(t *testing.T) {
+	req := require.New(t)
+
+	path := Path{Nodes: []Node{alice}, Relationships: []UnboundRelationship{}, Sequence: []int{}}
+	segments, err := path.Segments()
+	req.Nil(err)
+	req.Empty(segments)
+	req.Equal(alice, path.Start())
+	req.Equal(alice, path.End())
+	req.Equal(0, path.Len())
+
+	req.Equal(Node{}, Path{}.Start())
+	req.Equal(Node{}, Path{}.End())
+}
+
+func TestPathSegmentsMalformed(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		path Path
+	}{
+		{name: "odd sequence", path: Path{Nodes: []Node{alice, bob}, Relationships: []UnboundRelationship{knows}, Sequence: []int{1}}},
+		{name: "no nodes", path: Path{Relationships: []UnboundRelationship{knows}, Sequence: []int{1, 1}}},
+		{name: "node out of range", path: Path{Nodes: []Node{alice, bob}, Relationships: []UnboundRelationship{knows}, Sequence: []int{1, 2}}},
+		{name: "relationship out of range", path: Path{Nodes: []Node{alice, bob}, Relationships: []UnboundRelationship{knows}, Sequence: []int{-2, 1}}},
+		{name: "relationship zero", path: Path{Nodes: []Node{alice, bob}, Relationships: []UnboundRelationship{knows}, Sequence: []int{0, 1}}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := require.New(t)
+
+			_, err := tt.path.Segments()
+			req.NotNil(err)
+			req.False(tt.path.Equal(tt.path))
+		})
+	}
+}
+
+func TestPathEqual(t *testing.T) {
+	req := require.New(t)
+
+	// the same path with its nodes and relationships listed in another order
+	reordered := Path{
+		Nodes:         []Node{alice, carol, bob},
+		Relationships: []UnboundRelationship{likes, knows},
+		Sequence:      []int{2, 2, -1, 1},
+	}
+	req.True(mixedPath.Equal(reordered))
+	req.True(reordered.Equal(mixedPath))
+
+	// walked the other way around
+	backwards := Path{
+		Nodes:         []Node{carol, bob, alice},
+		Relationships: []UnboundRelationship{likes, knows},
+		Sequence:      []int{1, 1, -2, 2},
+	}
+	req.False(mixedPath.Equal(backwards))
+
+	shorter := Path{Nodes: []Node{alice, bob}, Relationships: []UnboundRelationship{knows}, Sequence: []int{1, 1}}
+	req.False(mixedPath.Equal(shorter))
+}
+
+func TestGraphEqual(t *testing.T) {
+	req := require.New(t)
+
+	req.True(alice.Equal(Node{NodeIdentity: 1, Labels: []string{"Person"}, Properties: map[string]interface{}{"name": "Alice"}}))
+	req.False(alice.Equal(bob))
+
+	// labels in any order, nil and empty properties alike
+	a := Node{NodeIdentity: 4, Labels: []string{"Person", "Admin"}}
+	b := Node{NodeIdentity: 4, Labels: []string{"Admin", "Person"}, Properties: map[string]interface{}{}}
+	req.True(a.Equal(b))
+	req.False(a.Equal(Node{NodeIdentity: 4, Labels: []string{"Admin", "Admin"}}))
+
+	bound := knows.Bind(1, 2)
+	req.Equal(Relationship{RelIdentity: 10, StartNodeIdentity: 1, EndNodeIdentity: 2, Type: "KNOWS", Properties: knows.Properties}, bound)
+	req.True(bound.Equal(knows.Bind(1, 2)))
+	req.False(bound.Equal(knows.Bind(2, 1)))
+
+	req.True(knows.Equal(UnboundRelationship{RelIdentity: 10, Type: "KNOWS", Properties: map[string]interface{}{"since": int64(2001)}}))
+	req.False(knows.Equal(likes))
+}
@@ -0,0 +1,248 @@
+package graph
+
+import (
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/mindstand/go-bolt/errors"
+	"github.com/mindstand/go-bolt/structures/types"
+)
+
+// PropertyAs returns the property key as a T. found is false when there is no such property, and err is set
+// when there is one that isn't a T. Neo4j sends every integer as an int64 and every float as a float64, so
+// numbers are converted to other number types as long as the value fits, and integers are taken as floats
+func PropertyAs[T any](properties map[string]interface{}, key string) (value T, found bool, err error) {
+	property, ok := properties[key]
+	if !ok {
+		return value, false, nil
+	}
+
+	if typed, ok := property.(T); ok {
+		return typed, true, nil
+	}
+
+	to := reflect.TypeOf(&value).Elem()
+	converted, err := convertNumber(key, property, to)
+	if err != nil {
+		return value, true, err
+	}
+
+	return converted.Interface().(T), true, nil
+}
+
+// convertNumber converts a number property to another number type, failing when it doesn't fit
+func convertNumber(key string, property interface{}, to reflect.Type) (reflect.Value, error) {
+	from := reflect.ValueOf(property)
+	if property == nil || !isNumber(from.Kind()) || !isNumber(to.Kind()) {
+		return reflect.Value{}, errors.New("property %s is a %T, not a %s", key, property, to)
+	}
+
+	converted := reflect.New(to).Elem()
+	switch to.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch {
+		case isInt(from.Kind()) && !converted.OverflowInt(from.Int()):
+			converted.SetInt(from.Int())
+			return converted, nil
+		case isUint(from.Kind()) && from.Uint() <= math.MaxInt64 && !converted.OverflowInt(int64(from.Uint())):
+			converted.SetInt(int64(from.Uint()))
+			return converted, nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch {
+		case isInt(from.Kind()) && from.Int() >= 0 && !converted.OverflowUint(uint64(from.Int())):
+			converted.SetUint(uint64(from.Int()))
+			return converted, nil
+		case isUint(from.Kind()) && !converted.OverflowUint(from.Uint()):
+			converted.SetUint(from.Uint())
+			return converted, nil
+		}
+	case reflect.Float32, reflect.Float64:
+		switch {
+		case isInt(from.Kind()):
+			converted.SetFloat(float64(from.Int()))
+			return converted, nil
+		case isUint(from.Kind()):
+			converted.SetFloat(float64(from.Uint()))
+			return converted, nil
+		case !converted.OverflowFloat(from.Float()):
+			converted.SetFloat(from.Float())
+			return converted, nil
+		}
+	}
+
+	// floats aren't truncated to integers
+	if (isInt(to.Kind()) || isUint(to.Kind())) && !isInt(from.Kind()) && !isUint(from.Kind()) {
+		return reflect.Value{}, errors.New("property %s is a %T, not a %s", key, property, to)
+	}
+	return reflect.Value{}, errors.New("property %s of %v doesn't fit a %s", key, property, to)
+}
+
+func isInt(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Int64
+}
+
+func isUint(kind reflect.Kind) bool {
+	return kind >= reflect.Uint && kind <= reflect.Uint64
+}
+
+func isNumber(kind reflect.Kind) bool {
+	return isInt(kind) || isUint(kind) || kind == reflect.Float32 || kind == reflect.Float64
+}
+
+// timeProperty returns a temporal property as a time.Time. Dates, local times and times of day from gotime
+// are read in the zone they carry
+func timeProperty(properties map[string]interface{}, key string) (time.Time, bool, error) {
+	property, ok := properties[key]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+
+	switch t := property.(type) {
+	case time.Time:
+		return t, true, nil
+	case types.DateTime:
+		return t.Time, true, nil
+	case interface{ GetTime() time.Time }:
+		return t.GetTime(), true, nil
+	default:
+		return time.Time{}, true, errors.New("property %s is a %T, not a time", key, property)
+	}
+}
+
+// pointProperty returns a spatial property as a Point3D, a 2D point comes back with a Z of 0
+func pointProperty(properties map[string]interface{}, key string) (types.Point3D, bool, error) {
+	property, ok := properties[key]
+	if !ok {
+		return types.Point3D{}, false, nil
+	}
+
+	switch p := property.(type) {
+	case types.Point3D:
+		return p, true, nil
+	case *types.Point3D:
+		if p != nil {
+			return *p, true, nil
+		}
+	case types.Point2D:
+		return types.Point3D{SRID: p.SRID, X: p.X, Y: p.Y}, true, nil
+	case *types.Point2D:
+		if p != nil {
+			return types.Point3D{SRID: p.SRID, X: p.X, Y: p.Y}, true, nil
+		}
+	}
+
+	return types.Point3D{}, true, errors.New("property %s is a %T, not a point", key, property)
+}
+
+// durationProperty returns a duration property, a time.Duration set on the client is converted
+func durationProperty(properties map[string]interface{}, key string) (types.Duration, bool, error) {
+	property, ok := properties[key]
+	if !ok {
+		return types.Duration{}, false, nil
+	}
+
+	switch d := property.(type) {
+	case types.Duration:
+		return d, true, nil
+	case *types.Duration:
+		if d != nil {
+			return *d, true, nil
+		}
+	case time.Duration:
+		return types.DurationFromTime(d), true, nil
+	}
+
+	return types.Duration{}, true, errors.New("property %s is a %T, not a duration", key, property)
+}
+
+// HasLabel reports whether the node has label
+func (n Node) HasLabel(label string) bool {
+	for _, l := range n.Labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+// GetString returns the property key as a string
+func (n Node) GetString(key string) (string, bool, error) {
+	return PropertyAs[string](n.Properties, key)
+}
+
+// GetInt64 returns the property key as an int64
+func (n Node) GetInt64(key string) (int64, bool, error) {
+	return PropertyAs[int64](n.Properties, key)
+}
+
+// GetFloat64 returns the property key as a float64, integers are converted
+func (n Node) GetFloat64(key string) (float64, bool, error) {
+	return PropertyAs[float64](n.Properties, key)
+}
+
+// GetBool returns the property key as a bool
+func (n Node) GetBool(key string) (bool, bool, error) {
+	return PropertyAs[bool](n.Properties, key)
+}
+
+// GetTime returns the temporal property key as a time.Time
+func (n Node) GetTime(key string) (time.Time, bool, error) {
+	return timeProperty(n.Properties, key)
+}
+
+// GetList returns the list property key
+func (n Node) GetList(key string) ([]interface{}, bool, error) {
+	return PropertyAs[[]interface{}](n.Properties, key)
+}
+
+// GetPoint returns the spatial property key, a 2D point comes back with a Z of 0
+func (n Node) GetPoint(key string) (types.Point3D, bool, error) {
+	return pointProperty(n.Properties, key)
+}
+
+// GetDuration returns the duration property key
+func (n Node) GetDuration(key string) (types.Duration, bool, error) {
+	return durationProperty(n.Properties, key)
+}
+
+// GetString returns the property key as a string
+func (r Relationship) GetString(key string) (string, bool, error) {
+	return PropertyAs[string](r.Properties, key)
+}
+
+// GetInt64 returns the property key as an int64
+func (r Relationship) GetInt64(key string) (int64, bool, error) {
+	return PropertyAs[int64](r.Properties, key)
+}
+
+// GetFloat64 returns the property key as a float64, integers are converted
+func (r Relationship) GetFloat64(key string) (float64, bool, error) {
+	return PropertyAs[float64](r.Properties, key)
+}
+
+// GetBool returns the property key as a bool
+func (r Relationship) GetBool(key string) (bool, bool, error) {
+	return PropertyAs[bool](r.Properties, key)
+}
+
+// GetTime returns the temporal property key as a time.Time
+func (r Relationship) GetTime(key string) (time.Time, bool, error) {
+	return timeProperty(r.Properties, key)
+}
+
+// GetList returns the list property key
+func (r Relationship) GetList(key string) ([]interface{}, bool, error) {
+	return PropertyAs[[]interface{}](r.Properties, key)
+}
+
+// GetPoint returns the spatial property key, a 2D point comes back with a Z of 0
+func (r Relationship) GetPoint(key string) (types.Point3D, bool, error) {
+	return pointProperty(r.Properties, key)
+}
+
+// GetDuration returns the duration property key
+func (r Relationship) GetDuration(key string) (types.Duration, bool, error) {
+	return durationProperty(r.Properties, key)
+}
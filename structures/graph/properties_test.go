@@ -0,0 +1,199 @@
+package graph
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/mindstand/go-bolt/structures/types"
+	"github.com/mindstand/gotime"
+	"github.com/stretchr/testify/require"
+)
+
+// person is a node as the decoder returns it, integers are int64 and floats float64
+var person = Node{
+	NodeIdentity: 1,
+	Labels:       []string{"Person", "Admin"},
+	Properties: map[string]interface{}{
+		"name":     "Alice",
+		"age":      int64(42),
+		"score":    1.5,
+		"active":   true,
+		"tags":     []interface{}{"a", "b"},
+		"born":     time.Date(1980, 1, 2, 3, 4, 5, 0, time.UTC),
+		"birthday": gotime.NewDate(1980, 1, 2),
+		"home":     types.Point2D{SRID: 7203, X: 1, Y: 2},
+		"office":   &types.Point3D{SRID: 9157, X: 1, Y: 2, Z: 3},
+		"tenure":   types.NewDuration(1, 2, 3, 4),
+		"nothing":  nil,
+	},
+}
+
+func TestNodeProperties(t *testing.T) {
+	req := require.New(t)
+
+	name, found, err := person.GetString("name")
+	req.Nil(err)
+	req.True(found)
+	req.Equal("Alice", name)
+
+	age, found, err := person.GetInt64("age")
+	req.Nil(err)
+	req.True(found)
+	req.EqualValues(42, age)
+
+	score, _, err := person.GetFloat64("score")
+	req.Nil(err)
+	req.Equal(1.5, score)
+
+	// integers are numbers too
+	ageFloat, _, err := person.GetFloat64("age")
+	req.Nil(err)
+	req.Equal(42.0, ageFloat)
+
+	active, _, err := person.GetBool("active")
+	req.Nil(err)
+	req.True(active)
+
+	tags, _, err := person.GetList("tags")
+	req.Nil(err)
+	req.Equal([]interface{}{"a", "b"}, tags)
+
+	born, _, err := person.GetTime("born")
+	req.Nil(err)
+	req.Equal(1980, born.Year())
+
+	birthday, _, err := person.GetTime("birthday")
+	req.Nil(err)
+	req.Equal(time.January, birthday.Month())
+	req.Equal(2, birthday.Day())
+
+	home, _, err := person.GetPoint("home")
+	req.Nil(err)
+	req.Equal(types.Point3D{SRID: 7203, X: 1, Y: 2}, home)
+
+	office, _, err := person.GetPoint("office")
+	req.Nil(err)
+	req.Equal(types.Point3D{SRID: 9157, X: 1, Y: 2, Z: 3}, office)
+
+	tenure, _, err := person.GetDuration("tenure")
+	req.Nil(err)
+	req.Equal(types.NewDuration(1, 2, 3, 4), tenure)
+
+	req.True(person.HasLabel("Admin"))
+	req.False(person.HasLabel("admin"))
+}
+
+func TestPropertiesMissingAndMistyped(t *testing.T) {
+	req := require.New(t)
+
+	// a missing property isn't an error
+	_, found, err := person.GetString("email")
+	req.Nil(err)
+	req.False(found)
+
+	_, found, err = person.GetTime("email")
+	req.Nil(err)
+	req.False(found)
+
+	// one of the wrong type is
+	_, found, err = person.GetString("age")
+	req.True(found)
+	req.NotNil(err)
+
+	_, _, err = person.GetInt64("score")
+	req.NotNil(err, "floats aren't truncated")
+
+	_, _, err = person.GetBool("nothing")
+	req.NotNil(err)
+
+	_, _, err = person.GetTime("name")
+	req.NotNil(err)
+
+	_, _, err = person.GetPoint("name")
+	req.NotNil(err)
+
+	_, _, err = person.GetDuration("name")
+	req.NotNil(err)
+
+	_, _, err = person.GetList("name")
+	req.NotNil(err)
+}
+
+func TestRelationshipProperties(t *testing.T) {
+	req := require.New(t)
+
+	rel := Relationship{RelIdentity: 1, Type: "KNOWS", Properties: map[string]interface{}{
+		"since":  int64(2001),
+		"weight": 0.5,
+		"for":    90 * time.Minute,
+	}}
+
+	since, found, err := rel.GetInt64("since")
+	req.Nil(err)
+	req.True(found)
+	req.EqualValues(2001, since)
+
+	weight, _, err := rel.GetFloat64("weight")
+	req.Nil(err)
+	req.Equal(0.5, weight)
+
+	// a time.Duration set on the client reads as a neo4j duration
+	duration, _, err := rel.GetDuration("for")
+	req.Nil(err)
+	req.Equal(types.Duration{Seconds: 5400}, duration)
+
+	_, found, err = rel.GetString("missing")
+	req.Nil(err)
+	req.False(found)
+}
+
+func TestPropertyAs(t *testing.T) {
+	req := require.New(t)
+
+	properties := map[string]interface{}{
+		"small":    int64(42),
+		"big":      int64(math.MaxInt32) + 1,
+		"negative": int64(-1),
+		"huge":     math.MaxFloat64,
+		"name":     "Alice",
+		"point":    types.Point2D{SRID: 7203, X: 1, Y: 2},
+		"plain":    42,
+	}
+
+	// int64 converts to any integer it fits
+	small, found, err := PropertyAs[int](properties, "small")
+	req.Nil(err)
+	req.True(found)
+	req.Equal(42, small)
+
+	small8, _, err := PropertyAs[uint8](properties, "small")
+	req.Nil(err)
+	req.EqualValues(42, small8)
+
+	_, _, err = PropertyAs[int32](properties, "big")
+	req.NotNil(err)
+
+	_, _, err = PropertyAs[uint](properties, "negative")
+	req.NotNil(err)
+
+	_, _, err = PropertyAs[float32](properties, "huge")
+	req.NotNil(err)
+
+	// and the other way, for properties set on the client
+	plain, _, err := PropertyAs[int64](properties, "plain")
+	req.Nil(err)
+	req.EqualValues(42, plain)
+
+	point, _, err := PropertyAs[types.Point2D](properties, "point")
+	req.Nil(err)
+	req.Equal(7203, point.SRID)
+
+	_, found, err = PropertyAs[float64](properties, "name")
+	req.True(found)
+	req.NotNil(err)
+
+	_, found, err = PropertyAs[string](nil, "name")
+	req.Nil(err)
+	req.False(found)
+}
@@ -23,3 +23,12 @@ func (r Relationship) Signature() int {
 func (r Relationship) AllFields() []interface{} {
 	return []interface{}{r.RelIdentity, r.StartNodeIdentity, r.EndNodeIdentity, r.Type, r.Properties}
 }
+
+// Equal reports whether both are the same relationship between the same nodes with the same type and properties
+func (r Relationship) Equal(other Relationship) bool {
+	return r.RelIdentity == other.RelIdentity &&
+		r.StartNodeIdentity == other.StartNodeIdentity &&
+		r.EndNodeIdentity == other.EndNodeIdentity &&
+		r.Type == other.Type &&
+		propertiesEqual(r.Properties, other.Properties)
+}
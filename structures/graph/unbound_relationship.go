@@ -21,3 +21,19 @@ func (r UnboundRelationship) Signature() int {
 func (r UnboundRelationship) AllFields() []interface{} {
 	return []interface{}{r.RelIdentity, r.Type, r.Properties}
 }
+
+// Bind gives the relationship the nodes it goes from and to, paths send relationships without them
+func (r UnboundRelationship) Bind(startNodeIdentity, endNodeIdentity int64) Relationship {
+	return Relationship{
+		RelIdentity:       r.RelIdentity,
+		StartNodeIdentity: startNodeIdentity,
+		EndNodeIdentity:   endNodeIdentity,
+		Type:              r.Type,
+		Properties:        r.Properties,
+	}
+}
+
+// Equal reports whether both are the same relationship with the same type and properties
+func (r UnboundRelationship) Equal(other UnboundRelationship) bool {
+	return r.RelIdentity == other.RelIdentity && r.Type == other.Type && propertiesEqual(r.Properties, other.Properties)
+}
@@ -8,6 +8,7 @@ import (
 const (
 	bookmarksMetadataKey  = "bookmarks"
 	databaseNameKey       = "db"
+	impersonatedUserKey   = "imp_user"
 	txTimeoutMetadataKey  = "tx_timeout"
 	txMetadataMetadataKey = "tx_metadata"
 	modeKey               = "mode"
@@ -21,14 +22,20 @@ func BuildTxMetadata(txTimeout *time.Duration, txMetadata map[string]interface{}
 }
 
 func BuildTxMetadataWithDatabase(txTimeout *time.Duration, txMetadata map[string]interface{}, databaseName string, mode bolt_mode.AccessMode, bookmark interface{}) map[string]interface{} {
+	return BuildTxMetadataWithImpersonation(txTimeout, txMetadata, databaseName, "", mode, bookmark)
+}
+
+// BuildTxMetadataWithImpersonation adds the user the transaction runs as, only bolt 4.4+ accepts imp_user
+func BuildTxMetadataWithImpersonation(txTimeout *time.Duration, txMetadata map[string]interface{}, databaseName, impersonatedUser string, mode bolt_mode.AccessMode, bookmark interface{}) map[string]interface{} {
 	// todo replace once bookmarks are supported
 	bookmarksPresent := false
 	txTimeoutPresent := txTimeout != nil && *txTimeout != 0
 	txMetaDataPresent := txMetadata != nil && len(txMetadata) != 0
 	accessModePresent := mode != bolt_mode.ReadMode
 	databaseNamePresent := databaseName != ""
+	impersonatedUserPresent := impersonatedUser != ""
 
-	if !bookmarksPresent && !txTimeoutPresent && !txMetaDataPresent && !accessModePresent && !databaseNamePresent {
+	if !bookmarksPresent && !txTimeoutPresent && !txMetaDataPresent && !accessModePresent && !databaseNamePresent && !impersonatedUserPresent {
 		return map[string]interface{}{}
 	}
 
@@ -54,5 +61,9 @@ func BuildTxMetadataWithDatabase(txTimeout *time.Duration, txMetadata map[string
 		toReturn[databaseNameKey] = databaseName
 	}
 
+	if impersonatedUserPresent {
+		toReturn[impersonatedUserKey] = impersonatedUser
+	}
+
 	return toReturn
 }
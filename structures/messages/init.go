@@ -28,7 +28,7 @@ func BuildAuthTokenBasicWithRealm(username, password, realm string) map[string]i
 		CredentialsKey: password,
 	}
 
-	if realm == "" {
+	if realm != "" {
 		toReturn[RealmKey] = realm
 	}
 
@@ -47,6 +47,40 @@ func BuildAuthTokenKerberos(base64EncodedTicket string) map[string]interface{} {
 	}
 }
 
+// BuildAuthTokenBearer builds a token for single sign on, token is the encoded token from the identity provider
+func BuildAuthTokenBearer(token string) map[string]interface{} {
+	return map[string]interface{}{
+		SchemeKey:      "bearer",
+		CredentialsKey: token,
+	}
+}
+
+// BuildAuthTokenNone builds a token for servers with auth disabled
+func BuildAuthTokenNone() map[string]interface{} {
+	return map[string]interface{}{
+		SchemeKey: "none",
+	}
+}
+
+// BuildAuthTokenCustom builds a token for a custom auth plugin, empty realm and parameters are left out
+func BuildAuthTokenCustom(principal, credentials, realm, scheme string, parameters map[string]interface{}) map[string]interface{} {
+	toReturn := map[string]interface{}{
+		SchemeKey:      scheme,
+		PrincipalKey:   principal,
+		CredentialsKey: credentials,
+	}
+
+	if realm != "" {
+		toReturn[RealmKey] = realm
+	}
+
+	if len(parameters) != 0 {
+		toReturn[ParametersKey] = parameters
+	}
+
+	return toReturn
+}
+
 // NewInitMessage Gets a new InitMessage struct
 func NewInitMessage(clientName string, authToken map[string]interface{}) InitMessage {
 	//authToken["user_agent"] = clientName
@@ -21,7 +21,12 @@ type RunWithMetadataMessage struct {
 // todo update to support bookmarks at some point
 // this would be used outside an explicit tx
 func NewAutoCommitTxRunMessage(query string, params map[string]interface{}, timeout time.Duration, txConfig map[string]interface{}, dbName string, mode bolt_mode.AccessMode) RunWithMetadataMessage {
-	return newRunMessageWithMetadata(query, params, BuildTxMetadataWithDatabase(&timeout, txConfig, dbName, mode, nil))
+	return NewImpersonatedAutoCommitTxRunMessage(query, params, timeout, txConfig, dbName, "", mode)
+}
+
+// runs the query outside an explicit tx as impersonatedUser, requires bolt 4.4+
+func NewImpersonatedAutoCommitTxRunMessage(query string, params map[string]interface{}, timeout time.Duration, txConfig map[string]interface{}, dbName, impersonatedUser string, mode bolt_mode.AccessMode) RunWithMetadataMessage {
+	return newRunMessageWithMetadata(query, params, BuildTxMetadataWithImpersonation(&timeout, txConfig, dbName, impersonatedUser, mode, nil))
 }
 
 // this would be used in an explicit transaction
@@ -0,0 +1,67 @@
+package types
+
+import (
+	"sync"
+	"time"
+)
+
+// DateTime is a neo4j datetime that keeps track of whether it has a named zone or a fixed offset.
+// Named zones are sent to neo4j by id so daylight saving rules are kept, offsets are sent as seconds
+type DateTime struct {
+	Time time.Time
+	// ZoneId is the IANA zone id, such as America/New_York. Empty for a fixed offset
+	ZoneId string
+}
+
+// NewDateTime creates a DateTime, using the zone id of t's location when it is a named IANA zone.
+// Local, UTC and fixed zones keep only their offset
+func NewDateTime(t time.Time) DateTime {
+	return DateTime{
+		Time:   t,
+		ZoneId: zoneIdOf(t),
+	}
+}
+
+// NewDateTimeWithOffset creates a DateTime that is sent to neo4j with t's offset, dropping any zone id
+func NewDateTimeWithOffset(t time.Time) DateTime {
+	return DateTime{
+		Time: t,
+	}
+}
+
+// HasZoneId determines if the DateTime has a named zone rather than a fixed offset
+func (d DateTime) HasZoneId() bool {
+	return d.ZoneId != ""
+}
+
+// knownZones caches whether location names are loadable IANA zones
+var knownZones sync.Map
+
+// zoneIdOf returns the IANA id of t's location, or an empty string when it doesn't have one
+func zoneIdOf(t time.Time) string {
+	name := t.Location().String()
+	if name == "" || name == "Local" || name == "UTC" {
+		return ""
+	}
+
+	var loc *time.Location
+	if cached, ok := knownZones.Load(name); ok {
+		loc, _ = cached.(*time.Location)
+	} else {
+		// fixed zones can have any name, nil is cached for names that aren't zones
+		loc, _ = time.LoadLocation(name)
+		knownZones.Store(name, loc)
+	}
+
+	if loc == nil {
+		return ""
+	}
+
+	// a fixed zone sharing a name with a real zone won't agree with its offset
+	_, offset := t.Zone()
+	if _, zoneOffset := t.In(loc).Zone(); zoneOffset != offset {
+		return ""
+	}
+
+	return name
+}
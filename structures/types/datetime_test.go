@@ -0,0 +1,43 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDateTime(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	require.Nil(t, err)
+
+	now := time.Date(2021, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name   string
+		time   time.Time
+		zoneId string
+	}{
+		{"utc", now, ""},
+		{"local", now.Local(), ""},
+		{"unnamed offset", now.In(time.FixedZone("", 3600)), ""},
+		{"named offset", now.In(time.FixedZone("UTC+1", 3600)), ""},
+		// EST is a real zone but the offset doesn't match in summer
+		{"fixed zone named like a zone", now.In(time.FixedZone("EST", -4*3600)), ""},
+		{"iana zone", now.In(newYork), "America/New_York"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := require.New(t)
+
+			dt := NewDateTime(c.time)
+			req.Equal(c.zoneId, dt.ZoneId)
+			req.Equal(c.zoneId != "", dt.HasZoneId())
+			req.True(c.time.Equal(dt.Time))
+		})
+	}
+
+	req := require.New(t)
+	req.False(NewDateTimeWithOffset(now.In(newYork)).HasZoneId())
+}
@@ -0,0 +1,291 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DurationStructSignature byte = 'E'
+	DurationStructSize      int  = 4
+
+	nanosPerSecond = int64(time.Second)
+)
+
+// ErrDurationNotRepresentable is returned when a Duration can't be converted to a time.Duration
+var ErrDurationNotRepresentable = errors.New("duration can not be represented as a time.Duration")
+
+// Duration is a neo4j duration. Months and days are calendar units, so unlike time.Duration
+// a Duration like P1M2D has no fixed length. Nanos is always in [0, 1e9)
+type Duration struct {
+	Months  int64
+	Days    int64
+	Seconds int64
+	Nanos   int64
+}
+
+// NewDuration creates a Duration, carrying nanos outside of [0, 1e9) into seconds
+func NewDuration(months, days, seconds, nanos int64) Duration {
+	seconds += nanos / nanosPerSecond
+	nanos %= nanosPerSecond
+	if nanos < 0 {
+		seconds--
+		nanos += nanosPerSecond
+	}
+
+	return Duration{
+		Months:  months,
+		Days:    days,
+		Seconds: seconds,
+		Nanos:   nanos,
+	}
+}
+
+// DurationFromTime converts a time.Duration into a Duration of seconds and nanos
+func DurationFromTime(d time.Duration) Duration {
+	return NewDuration(0, 0, 0, int64(d))
+}
+
+// ToTime converts the duration to a time.Duration. Durations with months or days
+// have no fixed length and fail with ErrDurationNotRepresentable, as do durations
+// outside the range of time.Duration
+func (d Duration) ToTime() (time.Duration, error) {
+	if d.Months != 0 || d.Days != 0 {
+		return 0, fmt.Errorf("%w, [%s] has calendar units", ErrDurationNotRepresentable, d)
+	}
+
+	// borrow a second for negative durations so the intermediate doesn't overflow
+	seconds, nanos := d.Seconds, d.Nanos
+	if seconds < 0 && nanos > 0 {
+		seconds++
+		nanos -= nanosPerSecond
+	}
+
+	secs, err := addChecked(0, seconds, nanosPerSecond)
+	if err != nil {
+		return 0, fmt.Errorf("%w, [%s] is out of range", ErrDurationNotRepresentable, d)
+	}
+
+	total, err := addChecked(secs, nanos, 1)
+	if err != nil {
+		return 0, fmt.Errorf("%w, [%s] is out of range", ErrDurationNotRepresentable, d)
+	}
+
+	return time.Duration(total), nil
+}
+
+func (d *Duration) Signature() int {
+	return int(DurationStructSignature)
+}
+
+func (d *Duration) AllFields() []interface{} {
+	return []interface{}{d.Months, d.Days, d.Seconds, d.Nanos}
+}
+
+// String formats the duration as ISO-8601, for example P1Y2M3DT4H5M6.7S
+func (d Duration) String() string {
+	if d == (Duration{}) {
+		return "PT0S"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("P")
+
+	writeUnit := func(val int64, unit byte) {
+		if val != 0 {
+			sb.WriteString(strconv.FormatInt(val, 10))
+			sb.WriteByte(unit)
+		}
+	}
+
+	writeUnit(d.Months/12, 'Y')
+	writeUnit(d.Months%12, 'M')
+	writeUnit(d.Days, 'D')
+
+	if d.Seconds == 0 && d.Nanos == 0 {
+		return sb.String()
+	}
+
+	sb.WriteString("T")
+
+	// split the time part on its absolute value so every component carries the sign
+	sign := ""
+	secs, nanos := uint64(d.Seconds), d.Nanos
+	if d.Seconds < 0 {
+		sign = "-"
+		secs = uint64(^d.Seconds) + 1
+		if nanos != 0 {
+			secs--
+			nanos = nanosPerSecond - nanos
+		}
+	}
+
+	if hours := secs / 3600; hours != 0 {
+		sb.WriteString(sign + strconv.FormatUint(hours, 10) + "H")
+	}
+	if minutes := secs % 3600 / 60; minutes != 0 {
+		sb.WriteString(sign + strconv.FormatUint(minutes, 10) + "M")
+	}
+
+	seconds := secs % 60
+	if seconds != 0 || nanos != 0 {
+		sb.WriteString(sign + strconv.FormatUint(seconds, 10))
+		if nanos != 0 {
+			sb.WriteString(strings.TrimRight(fmt.Sprintf(".%09d", nanos), "0"))
+		}
+		sb.WriteString("S")
+	}
+
+	return sb.String()
+}
+
+// ParseDuration parses an ISO-8601 duration such as P1Y2M3W4DT5H6M7.8S.
+// Components can be negative, as can the whole duration. Only seconds may have a fraction
+func ParseDuration(s string) (Duration, error) {
+	input := s
+	negate := false
+	if strings.HasPrefix(s, "-") {
+		negate = true
+		s = s[1:]
+	} else if strings.HasPrefix(s, "+") {
+		s = s[1:]
+	}
+
+	if len(s) < 2 || (s[0] != 'P' && s[0] != 'p') {
+		return Duration{}, fmt.Errorf("invalid duration [%s], must start with P", input)
+	}
+	s = strings.ToUpper(s[1:])
+
+	datePart, timePart := s, ""
+	if idx := strings.IndexByte(s, 'T'); idx != -1 {
+		datePart, timePart = s[:idx], s[idx+1:]
+		if timePart == "" {
+			return Duration{}, fmt.Errorf("invalid duration [%s], time designator with no time", input)
+		}
+	}
+
+	var months, days, seconds, nanos int64
+
+	err := parseDurationUnits(datePart, "YMWD", func(unit byte, whole, frac int64) error {
+		if frac != 0 {
+			return fmt.Errorf("fractional [%c] is not supported", unit)
+		}
+
+		var err error
+		switch unit {
+		case 'Y':
+			months, err = addChecked(months, whole, 12)
+		case 'M':
+			months, err = addChecked(months, whole, 1)
+		case 'W':
+			days, err = addChecked(days, whole, 7)
+		case 'D':
+			days, err = addChecked(days, whole, 1)
+		}
+		return err
+	})
+	if err != nil {
+		return Duration{}, fmt.Errorf("invalid duration [%s], %w", input, err)
+	}
+
+	err = parseDurationUnits(timePart, "HMS", func(unit byte, whole, frac int64) error {
+		if frac != 0 && unit != 'S' {
+			return fmt.Errorf("fractional [%c] is not supported", unit)
+		}
+
+		var err error
+		switch unit {
+		case 'H':
+			seconds, err = addChecked(seconds, whole, 3600)
+		case 'M':
+			seconds, err = addChecked(seconds, whole, 60)
+		case 'S':
+			seconds, err = addChecked(seconds, whole, 1)
+			nanos = frac
+		}
+		return err
+	})
+	if err != nil {
+		return Duration{}, fmt.Errorf("invalid duration [%s], %w", input, err)
+	}
+
+	if datePart == "" && timePart == "" {
+		return Duration{}, fmt.Errorf("invalid duration [%s], no components", input)
+	}
+
+	if negate {
+		months, days, seconds, nanos = -months, -days, -seconds, -nanos
+	}
+
+	return NewDuration(months, days, seconds, nanos), nil
+}
+
+// parseDurationUnits walks number/unit pairs, units have to appear in the order given.
+// frac is the fraction in nanoseconds and has the same sign as whole
+func parseDurationUnits(s, units string, handle func(unit byte, whole, frac int64) error) error {
+	for s != "" {
+		end := strings.IndexFunc(s, func(r rune) bool {
+			return r != '-' && r != '+' && r != '.' && r != ',' && (r < '0' || r > '9')
+		})
+		if end == -1 {
+			return fmt.Errorf("missing unit after [%s]", s)
+		}
+		if end == 0 {
+			return fmt.Errorf("missing number before [%c]", s[0])
+		}
+
+		number, unit := s[:end], s[end]
+		idx := strings.IndexByte(units, unit)
+		if idx == -1 {
+			return fmt.Errorf("unexpected unit [%c]", unit)
+		}
+		units = units[idx+1:]
+		s = s[end+1:]
+
+		wholePart, fracPart := number, ""
+		if sep := strings.IndexAny(number, ".,"); sep != -1 {
+			wholePart, fracPart = number[:sep], number[sep+1:]
+			if fracPart == "" || len(fracPart) > 9 || strings.IndexFunc(fracPart, func(r rune) bool { return r < '0' || r > '9' }) != -1 {
+				return fmt.Errorf("invalid fraction [%s]", number)
+			}
+		}
+
+		whole, err := strconv.ParseInt(wholePart, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number [%s]", number)
+		}
+
+		var frac int64
+		if fracPart != "" {
+			frac, _ = strconv.ParseInt(fracPart+strings.Repeat("0", 9-len(fracPart)), 10, 64)
+			if strings.HasPrefix(wholePart, "-") {
+				frac = -frac
+			}
+		}
+
+		if err := handle(unit, whole, frac); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addChecked returns total + val*multiplier, failing on overflow
+func addChecked(total, val, multiplier int64) (int64, error) {
+	if val != 0 && (val > math.MaxInt64/multiplier || val < math.MinInt64/multiplier) {
+		return 0, errors.New("component overflows")
+	}
+
+	scaled := val * multiplier
+	sum := total + scaled
+	if (scaled > 0 && sum < total) || (scaled < 0 && sum > total) {
+		return 0, errors.New("component overflows")
+	}
+
+	return sum, nil
+}
@@ -0,0 +1,115 @@
+package types
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDuration(t *testing.T) {
+	req := require.New(t)
+
+	req.Equal(Duration{Seconds: 2, Nanos: 500}, NewDuration(0, 0, 0, 2000000500))
+	req.Equal(Duration{Seconds: -1, Nanos: 999999999}, NewDuration(0, 0, 0, -1))
+	req.Equal(Duration{Months: 1, Days: -2, Seconds: 3}, NewDuration(1, -2, 3, 0))
+}
+
+func TestDurationString(t *testing.T) {
+	cases := []struct {
+		duration Duration
+		iso      string
+	}{
+		{Duration{}, "PT0S"},
+		{NewDuration(14, 3, 0, 0), "P1Y2M3D"},
+		{NewDuration(1, 0, 0, 0), "P1M"},
+		{NewDuration(0, 0, 3723, 0), "PT1H2M3S"},
+		{NewDuration(0, 0, 0, 500000000), "PT0.5S"},
+		{NewDuration(0, 0, 6, 7000), "PT6.000007S"},
+		{NewDuration(0, 0, 0, -500000000), "PT-0.5S"},
+		{NewDuration(-14, -3, -3723, -100), "P-1Y-2M-3DT-1H-2M-3.0000001S"},
+		{NewDuration(0, 1, 60, 0), "P1DT1M"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.iso, func(t *testing.T) {
+			req := require.New(t)
+			req.Equal(c.iso, c.duration.String())
+
+			parsed, err := ParseDuration(c.iso)
+			req.Nil(err)
+			req.Equal(c.duration, parsed)
+		})
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	cases := []struct {
+		iso      string
+		duration Duration
+	}{
+		{"P1W", NewDuration(0, 7, 0, 0)},
+		{"P2W3D", NewDuration(0, 17, 0, 0)},
+		{"p1y", NewDuration(12, 0, 0, 0)},
+		{"-P1M2D", NewDuration(-1, -2, 0, 0)},
+		{"+PT90S", NewDuration(0, 0, 90, 0)},
+		{"PT1,25S", NewDuration(0, 0, 1, 250000000)},
+		{"PT36H", NewDuration(0, 0, 129600, 0)},
+		{"-PT1.5S", NewDuration(0, 0, -2, 500000000)},
+	}
+
+	for _, c := range cases {
+		t.Run(c.iso, func(t *testing.T) {
+			req := require.New(t)
+			parsed, err := ParseDuration(c.iso)
+			req.Nil(err)
+			req.Equal(c.duration, parsed)
+		})
+	}
+
+	invalid := []string{
+		"",
+		"P",
+		"1D",
+		"PT",
+		"P1H",
+		"PT1D",
+		"P1D1M",
+		"P1.5D",
+		"PT1.5M",
+		"PT1.S",
+		"PT1.0000000001S",
+		"PD",
+		"P1",
+		"P9223372036854775807Y",
+	}
+
+	for _, iso := range invalid {
+		_, err := ParseDuration(iso)
+		require.NotNil(t, err, iso)
+	}
+}
+
+func TestDurationTimeConversion(t *testing.T) {
+	req := require.New(t)
+
+	for _, d := range []time.Duration{0, time.Nanosecond, -time.Nanosecond, 90 * time.Minute, -1500 * time.Millisecond, math.MaxInt64, math.MinInt64} {
+		converted, err := DurationFromTime(d).ToTime()
+		req.Nil(err)
+		req.Equal(d, converted)
+	}
+
+	_, err := NewDuration(1, 0, 0, 0).ToTime()
+	req.True(errors.Is(err, ErrDurationNotRepresentable))
+
+	_, err = NewDuration(0, 1, 0, 0).ToTime()
+	req.True(errors.Is(err, ErrDurationNotRepresentable))
+
+	_, err = NewDuration(0, 0, math.MaxInt64/int64(time.Second)+1, 0).ToTime()
+	req.True(errors.Is(err, ErrDurationNotRepresentable))
+
+	_, err = NewDuration(0, 0, math.MinInt64/int64(time.Second)-1, 0).ToTime()
+	req.True(errors.Is(err, ErrDurationNotRepresentable))
+}
@@ -0,0 +1,194 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mindstand/gotime"
+)
+
+// formats temporal values are written to json in, the ISO-8601 forms neo4j's http api uses
+const (
+	dateFormat          = "2006-01-02"
+	timeFormat          = "15:04:05.999999999Z07:00"
+	localTimeFormat     = "15:04:05.999999999"
+	localDateTimeFormat = "2006-01-02T15:04:05.999999999"
+)
+
+// crsNames names the coordinate reference systems neo4j supports by srid
+var crsNames = map[int]string{
+	4326: "wgs-84",
+	4979: "wgs-84-3d",
+	7203: "cartesian",
+	9157: "cartesian-3d",
+}
+
+// jsonPoint is the json form of a point, like neo4j's http api:
+//
+//	{"type": "Point", "coordinates": [1, 2], "crs": {"srid": 7203, "name": "cartesian"}}
+type jsonPoint struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+	CRS         jsonCRS   `json:"crs"`
+}
+
+type jsonCRS struct {
+	SRID int    `json:"srid"`
+	Name string `json:"name,omitempty"`
+}
+
+func marshalPoint(srid int, coordinates ...float64) ([]byte, error) {
+	return json.Marshal(jsonPoint{
+		Type:        "Point",
+		Coordinates: coordinates,
+		CRS:         jsonCRS{SRID: srid, Name: crsNames[srid]},
+	})
+}
+
+func unmarshalPoint(data []byte, dimensions int) (jsonPoint, error) {
+	var point jsonPoint
+	if err := json.Unmarshal(data, &point); err != nil {
+		return point, err
+	}
+
+	if point.Type != "Point" {
+		return point, fmt.Errorf("expected a json Point, got type [%s]", point.Type)
+	}
+	if len(point.Coordinates) != dimensions {
+		return point, fmt.Errorf("expected a point of %d coordinates, got %d", dimensions, len(point.Coordinates))
+	}
+
+	return point, nil
+}
+
+// MarshalJSON writes the point as {"type": "Point", "coordinates": [x, y], "crs": {"srid": srid, "name": name}}
+func (p Point2D) MarshalJSON() ([]byte, error) {
+	return marshalPoint(p.SRID, p.X, p.Y)
+}
+
+// UnmarshalJSON reads a point written by MarshalJSON, it has to have two coordinates
+func (p *Point2D) UnmarshalJSON(data []byte) error {
+	point, err := unmarshalPoint(data, 2)
+	if err != nil {
+		return err
+	}
+
+	*p = Point2D{SRID: point.CRS.SRID, X: point.Coordinates[0], Y: point.Coordinates[1]}
+	return nil
+}
+
+// MarshalJSON writes the point as {"type": "Point", "coordinates": [x, y, z], "crs": {"srid": srid, "name": name}}
+func (p Point3D) MarshalJSON() ([]byte, error) {
+	return marshalPoint(p.SRID, p.X, p.Y, p.Z)
+}
+
+// UnmarshalJSON reads a point written by MarshalJSON, it has to have three coordinates
+func (p *Point3D) UnmarshalJSON(data []byte) error {
+	point, err := unmarshalPoint(data, 3)
+	if err != nil {
+		return err
+	}
+
+	*p = Point3D{SRID: point.CRS.SRID, X: point.Coordinates[0], Y: point.Coordinates[1], Z: point.Coordinates[2]}
+	return nil
+}
+
+// MarshalJSON writes the duration as its ISO-8601 string, like P1M2DT3.5S
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON reads an ISO-8601 duration string
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	parsed, err := ParseDuration(s)
+	if err != nil {
+		return err
+	}
+
+	*d = parsed
+	return nil
+}
+
+// MarshalJSON writes the datetime as an RFC 3339 string, followed by the zone id in brackets when it has one
+// like neo4j writes them: 2020-04-14T12:30:00+01:00[Europe/London]
+func (d DateTime) MarshalJSON() ([]byte, error) {
+	s := d.Time.Format(time.RFC3339Nano)
+	if d.HasZoneId() {
+		s += "[" + d.ZoneId + "]"
+	}
+	return json.Marshal(s)
+}
+
+// UnmarshalJSON reads a datetime written by MarshalJSON, a zone id has to be one the system knows
+func (d *DateTime) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	zoneId := ""
+	if strings.HasSuffix(s, "]") {
+		open := strings.LastIndex(s, "[")
+		if open < 0 {
+			return fmt.Errorf("invalid datetime [%s], the zone id isn't opened with [", s)
+		}
+		zoneId = s[open+1 : len(s)-1]
+		s = s[:open]
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return err
+	}
+
+	if zoneId != "" {
+		loc, err := time.LoadLocation(zoneId)
+		if err != nil {
+			return fmt.Errorf("unable to load zone info for [%s], %w", zoneId, err)
+		}
+		t = t.In(loc)
+	}
+
+	*d = DateTime{Time: t, ZoneId: zoneId}
+	return nil
+}
+
+// JSONValue returns val ready for encoding/json. The temporal types from gotime become ISO-8601 strings,
+// dates as 2020-04-14, times as 12:30:00+01:00, local times as 12:30:00 and local datetimes as
+// 2020-04-14T12:30:00. A time.Duration becomes a Duration. Lists and maps are converted all the way down,
+// everything else, which marshals itself, is returned as it is
+func JSONValue(val interface{}) interface{} {
+	switch v := val.(type) {
+	case gotime.Date:
+		return v.GetTime().Format(dateFormat)
+	case gotime.Clock:
+		return v.GetTime().Format(timeFormat)
+	case gotime.LocalClock:
+		return v.GetTime().Format(localTimeFormat)
+	case gotime.LocalTime:
+		return v.GetTime().Format(localDateTimeFormat)
+	case time.Duration:
+		return DurationFromTime(v)
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = JSONValue(item)
+		}
+		return list
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[key] = JSONValue(item)
+		}
+		return m
+	default:
+		return val
+	}
+}
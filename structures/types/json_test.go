@@ -0,0 +1,110 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mindstand/gotime"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPointJSON(t *testing.T) {
+	req := require.New(t)
+
+	data, err := json.Marshal(Point2D{SRID: 7203, X: 1, Y: 2.5})
+	req.Nil(err)
+	req.Equal(`{"type":"Point","coordinates":[1,2.5],"crs":{"srid":7203,"name":"cartesian"}}`, string(data))
+
+	var p2 Point2D
+	req.Nil(json.Unmarshal(data, &p2))
+	req.Equal(Point2D{SRID: 7203, X: 1, Y: 2.5}, p2)
+
+	// pointers marshal the same, that's how points are sent
+	data, err = json.Marshal(&Point3D{SRID: 4979, X: 1, Y: 2, Z: 3})
+	req.Nil(err)
+	req.Equal(`{"type":"Point","coordinates":[1,2,3],"crs":{"srid":4979,"name":"wgs-84-3d"}}`, string(data))
+
+	var p3 Point3D
+	req.Nil(json.Unmarshal(data, &p3))
+	req.Equal(Point3D{SRID: 4979, X: 1, Y: 2, Z: 3}, p3)
+
+	// unknown srids have no name
+	data, err = json.Marshal(Point2D{SRID: 1})
+	req.Nil(err)
+	req.Equal(`{"type":"Point","coordinates":[0,0],"crs":{"srid":1}}`, string(data))
+
+	req.NotNil(json.Unmarshal([]byte(`{"type":"Point","coordinates":[1,2],"crs":{"srid":7203}}`), &p3))
+	req.NotNil(json.Unmarshal([]byte(`{"type":"Polygon","coordinates":[1,2],"crs":{"srid":7203}}`), &p2))
+}
+
+func TestDurationJSON(t *testing.T) {
+	req := require.New(t)
+
+	d := NewDuration(14, 2, 3, 500000000)
+	data, err := json.Marshal(d)
+	req.Nil(err)
+	req.Equal(`"P1Y2M2DT3.5S"`, string(data))
+
+	var read Duration
+	req.Nil(json.Unmarshal(data, &read))
+	req.Equal(d, read)
+
+	req.NotNil(json.Unmarshal([]byte(`"1 day"`), &read))
+	req.NotNil(json.Unmarshal([]byte(`12`), &read))
+}
+
+func TestDateTimeJSON(t *testing.T) {
+	req := require.New(t)
+
+	london, err := time.LoadLocation("Europe/London")
+	req.Nil(err)
+
+	zoned := NewDateTime(time.Date(2020, 4, 14, 12, 30, 0, 5, london))
+	data, err := json.Marshal(zoned)
+	req.Nil(err)
+	req.Equal(`"2020-04-14T12:30:00.000000005+01:00[Europe/London]"`, string(data))
+
+	var read DateTime
+	req.Nil(json.Unmarshal(data, &read))
+	req.Equal("Europe/London", read.ZoneId)
+	req.True(zoned.Time.Equal(read.Time))
+	req.Equal(london.String(), read.Time.Location().String())
+
+	offset := NewDateTimeWithOffset(time.Date(2020, 4, 14, 12, 30, 0, 0, time.FixedZone("", -5*3600)))
+	data, err = json.Marshal(offset)
+	req.Nil(err)
+	req.Equal(`"2020-04-14T12:30:00-05:00"`, string(data))
+
+	req.Nil(json.Unmarshal(data, &read))
+	req.False(read.HasZoneId())
+	req.True(offset.Time.Equal(read.Time))
+
+	req.NotNil(json.Unmarshal([]byte(`"2020-04-14T12:30:00+01:00[Nowhere/Atlantis]"`), &read))
+	req.NotNil(json.Unmarshal([]byte(`"2020-04-14T12:30:00+01:00Europe/London]"`), &read))
+	req.NotNil(json.Unmarshal([]byte(`"yesterday"`), &read))
+}
+
+func TestJSONValue(t *testing.T) {
+	req := require.New(t)
+
+	val := JSONValue(map[string]interface{}{
+		"date":          gotime.NewDate(2020, 4, 14),
+		"time":          gotime.NewClock(12, 30, 0, 0, time.FixedZone("", 3600)),
+		"localTime":     gotime.NewLocalClock(12, 30, 1, 500),
+		"localDateTime": gotime.NewLocalTimeFromTime(time.Date(2020, 4, 14, 12, 30, 0, 0, time.UTC)),
+		"duration":      []interface{}{90 * time.Second},
+		"other":         int64(1),
+	})
+
+	data, err := json.Marshal(val)
+	req.Nil(err)
+	req.JSONEq(`{
+		"date": "2020-04-14",
+		"time": "12:30:00+01:00",
+		"localTime": "12:30:01.0000005",
+		"localDateTime": "2020-04-14T12:30:00",
+		"duration": ["PT1M30S"],
+		"other": 1
+	}`, string(data))
+}
@@ -3,6 +3,7 @@ package goBolt
 import (
 	"fmt"
 	"github.com/mindstand/go-bolt/bolt_mode"
+	"github.com/mindstand/go-bolt/structures/types"
 	"github.com/mindstand/gotime"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -115,10 +116,11 @@ func TestDecoderTimeIntegration(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		returned, ok := all[0][0].(time.Time)
+		returnedDateTime, ok := all[0][0].(types.DateTime)
 		if !ok {
-			t.Fatal("malformed response, could not assert as time.Time")
+			t.Fatal("malformed response, could not assert as types.DateTime")
 		}
+		returned := returnedDateTime.Time
 		returnedFormatted := returned.Format(time.RFC3339Nano)
 
 		assert.Equal(t, nowFormatted, returnedFormatted, "Time string mismatch")
@@ -138,10 +140,11 @@ func TestDecoderTimeIntegration(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		returned, ok := all[0][0].(time.Time)
+		returnedDateTime, ok := all[0][0].(types.DateTime)
 		if !ok {
-			t.Fatal("malformed response, could not assert as time.Time")
+			t.Fatal("malformed response, could not assert as types.DateTime")
 		}
+		returned := returnedDateTime.Time
 		returnedFormatted := returned.Format(time.RFC3339Nano)
 
 		assert.Equal(t, sampleFormatted, returnedFormatted, "Time string mismatch")
@@ -161,14 +164,16 @@ func TestDecoderTimeIntegration(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		returned, ok := all[0][0].(time.Time)
+		returnedDateTime, ok := all[0][0].(types.DateTime)
 		if !ok {
-			t.Fatal("malformed response, could not assert as time.Time")
+			t.Fatal("malformed response, could not assert as types.DateTime")
 		}
+		returned := returnedDateTime.Time
 		returnedFormatted := returned.Format(time.RFC3339Nano)
 
 		assert.Equal(t, sample.Format(time.RFC3339Nano), returnedFormatted, "Time string mismatch")
 		assert.True(t, sample.Equal(returned), "Time object mismatch")
+		assert.Equal(t, loc.String(), returnedDateTime.ZoneId, "Zone id mismatch")
 	})
 
 	t.Run("LocalDateTime", func(t *testing.T) {
@@ -309,10 +314,11 @@ func TestEncoderTimeIntegration(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		returned, ok := all[0][0].(time.Time)
+		returnedDateTime, ok := all[0][0].(types.DateTime)
 		if !ok {
-			t.Fatal("malformed response, could not assert at time.Time")
+			t.Fatal("malformed response, could not assert as types.DateTime")
 		}
+		returned := returnedDateTime.Time
 
 		assert.Equal(t, now.Format(time.RFC3339Nano), returned.Format(time.RFC3339Nano))
 		assert.True(t, now.Equal(returned), "Time object mismatch")